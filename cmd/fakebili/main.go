@@ -0,0 +1,47 @@
+package main
+
+import (
+	"biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/fakebili"
+	"flag"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var log = common.GetLogger("fakebili")
+
+// 本地假 B 站接口，worker 设置 BILI_SHOW_BASE_URL、BILI_API_BASE_URL、GT_BASE_URL 指向它即可联调
+func main() {
+	addr := flag.String("addr", ":18080", "监听地址")
+	var scenario fakebili.Scenario
+	flag.BoolVar(&scenario.SoldOut, "sold-out", false, "createV2 始终返回库存不足")
+	flag.IntVar(&scenario.Price, "price", 0, "实际票价（分），与请求不一致时返回 100034")
+	flag.IntVar(&scenario.TokenExpiry, "token-expiry", 0, "每个 prepare token 可用的 createV2 次数")
+	flag.StringVar(&scenario.Captcha, "captcha", "", "prepare 首次要求验证码：geetest 或 phone")
+	flag.IntVar(&scenario.RiskAfter, "risk-after", 0, "累计请求超过 N 次后返回 412")
+	flag.IntVar(&scenario.SuccessOn, "success-on", 0, "第 K 次 createV2 下单成功")
+	flag.Int64Var(&scenario.OrderID, "order-id", 0, "成功时返回的订单号")
+	flag.IntVar(&scenario.Errno, "errno", 0, "createV2 始终返回该 errno")
+	flag.DurationVar(&scenario.Delay, "delay", 0, "每个请求的响应延迟")
+	flag.BoolVar(&scenario.LoggedOut, "logged-out", false, "nav 和 buyer/list 返回未登录")
+	flag.Int64Var(&scenario.Mid, "mid", 0, "登录账号的 uid")
+	flag.Func("buyers", "buyer/list 返回的购票人，格式 id:姓名,id:姓名", func(s string) error {
+		for _, item := range common.SplitList(s) {
+			id, name, _ := strings.Cut(item, ":")
+			n, err := strconv.Atoi(id)
+			if err != nil {
+				return fmt.Errorf("购票人 %q: %w", item, err)
+			}
+			scenario.Buyers = append(scenario.Buyers, fakebili.Buyer{ID: n, Name: name})
+		}
+		return nil
+	})
+	flag.Parse()
+
+	log.Printf("fakebili listening at %s, scenario: %+v", *addr, scenario)
+	if err := http.ListenAndServe(*addr, fakebili.New(scenario)); err != nil {
+		log.Fatalf("Start failed: %v", err)
+	}
+}
@@ -3,9 +3,6 @@ package main
 import (
 	"biliTickerStorm/internal/common"
 	"biliTickerStorm/internal/master"
-	"biliTickerStorm/internal/master/pb"
-	"google.golang.org/grpc"
-	"net"
 	"os"
 	"os/signal"
 	"syscall"
@@ -14,26 +11,30 @@ import (
 var log = common.GetLogger("master")
 
 func main() {
-
-	lis, err := net.Listen("tcp", ":40052")
+	if len(os.Args) > 1 && os.Args[1] == "validate" {
+		os.Exit(master.RunValidate(os.Args[2:], os.Stdout))
+	}
+	cfg, err := master.LoadConfig(os.Args[1:])
 	if err != nil {
-		log.Fatalf("listening failed: %v", err)
+		log.Fatalf("%v", err)
 	}
-	masterServer := master.NewServer()
-	if err := masterServer.LoadTasksFromDir(master.Cfg.Configpath); err != nil {
-		log.Fatalf("Read configs failed: %v", err)
+	app, err := master.New(cfg)
+	if err != nil {
+		log.Fatalf("Init failed: %v", err)
 	}
-	s := grpc.NewServer()
-	pb.RegisterTicketMasterServer(s, masterServer)
-	log.Println("listening at 40052")
-	if err := s.Serve(lis); err != nil {
+	if err := app.Start(); err != nil {
 		log.Fatalf("Start failed: %v", err)
 	}
+	go func() {
+		if err := app.ListenAndServe(); err != nil {
+			log.Fatalf("Start failed: %v", err)
+		}
+	}()
 
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt, syscall.SIGTERM)
 	<-c
 	log.Println("Closing...")
-	s.GracefulStop()
+	app.Stop()
 	log.Println("Closed")
 }
@@ -0,0 +1,238 @@
+// Package clustertest 在同一个进程里通过 bufconn 启动 master 和若干 worker，
+// 抢票请求发往 fakebili，用于测试调度、重新分配、风控冷却和任务完成。
+package clustertest
+
+import (
+	"biliTickerStorm/internal/fakebili"
+	"biliTickerStorm/internal/master"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"biliTickerStorm/internal/worker"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// Options 集群参数，零值字段使用适合测试的短周期
+type Options struct {
+	Workers           int
+	HeartbeatInterval time.Duration // worker 心跳周期
+	HeartbeatTimeout  time.Duration
+	TaskTimeout       time.Duration
+	BanTimeout        time.Duration
+	CheckInterval     time.Duration // master 心跳检查和任务巡检周期
+	Scheduler         master.Scheduler
+	Scenario          fakebili.Scenario
+}
+
+func (o *Options) setDefaults() {
+	if o.HeartbeatInterval == 0 {
+		o.HeartbeatInterval = 100 * time.Millisecond
+	}
+	if o.HeartbeatTimeout == 0 {
+		o.HeartbeatTimeout = time.Second
+	}
+	if o.TaskTimeout == 0 {
+		o.TaskTimeout = 3 * time.Second
+	}
+	if o.BanTimeout == 0 {
+		o.BanTimeout = time.Second
+	}
+	if o.CheckInterval == 0 {
+		o.CheckInterval = 50 * time.Millisecond
+	}
+}
+
+// Node 集群中的一个 worker
+type Node struct {
+	ID       string
+	App      *worker.App
+	Register *worker.Register
+	Worker   *worker.Worker
+	stopOnce sync.Once
+}
+
+func (n *Node) stop() {
+	n.stopOnce.Do(n.Register.Stop)
+}
+
+type Cluster struct {
+	t       *testing.T
+	opts    Options
+	Master  *master.Server
+	Admin   masterpb.TicketAdminClient
+	Fake    *fakebili.Server
+	fakeURL string
+	lis     *bufconn.Listener
+	admin   *bufconn.Listener
+
+	mu    sync.Mutex
+	nodes []*Node
+}
+
+// Start 启动 master、fakebili 和 opts.Workers 个 worker，测试结束时自动关闭
+func Start(t *testing.T, opts Options) *Cluster {
+	t.Helper()
+	opts.setDefaults()
+	fake, fakeSrv := fakebili.NewTestServer(opts.Scenario)
+	t.Cleanup(fakeSrv.Close)
+
+	cfg := master.DefaultConfig()
+	cfg.HTTPAddr = ""
+	cfg.NTPServers = nil
+	cfg.HeartbeatTimeout, cfg.TaskTimeout, cfg.BanTimeout = opts.HeartbeatTimeout, opts.TaskTimeout, opts.BanTimeout
+	cfg.CheckInterval = opts.CheckInterval
+	var masterOpts []master.Option
+	if opts.Scheduler != nil {
+		masterOpts = append(masterOpts, master.WithScheduler(opts.Scheduler))
+	}
+	app, err := master.New(cfg, masterOpts...)
+	if err != nil {
+		t.Fatalf("new master: %v", err)
+	}
+	if err := app.Start(); err != nil {
+		t.Fatalf("start master: %v", err)
+	}
+	c := &Cluster{
+		t:       t,
+		opts:    opts,
+		Master:  app.Server,
+		Fake:    fake,
+		fakeURL: fakeSrv.URL,
+		lis:     bufconn.Listen(1 << 20),
+		admin:   bufconn.Listen(1 << 20),
+	}
+	go func() { _ = app.Serve(c.lis) }()
+	go func() { _ = app.ServeAdmin(c.admin) }()
+
+	conn, err := grpc.NewClient("passthrough:///admin",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return c.admin.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("dial master: %v", err)
+	}
+	c.Admin = masterpb.NewTicketAdminClient(conn)
+
+	t.Cleanup(func() {
+		c.mu.Lock()
+		for _, n := range c.nodes {
+			n.stop()
+		}
+		c.mu.Unlock()
+		conn.Close()
+		app.Stop()
+	})
+	for i := 0; i < opts.Workers; i++ {
+		c.AddWorker()
+	}
+	return c
+}
+
+func (c *Cluster) dialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return c.lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	}
+}
+
+// AddWorker 启动一个新 worker 并注册到 master
+func (c *Cluster) AddWorker() *Node {
+	c.t.Helper()
+	c.mu.Lock()
+	id := fmt.Sprintf("worker-%d", len(c.nodes)+1)
+	c.mu.Unlock()
+	// 每个 worker 都指向同一个 fakebili，不监听端口，只通过长连接接收任务
+	cfg := worker.DefaultConfig()
+	cfg.MasterServerAddr = "passthrough:///master"
+	cfg.ShowBaseURL, cfg.APIBaseURL, cfg.GTBaseURL = c.fakeURL, c.fakeURL, c.fakeURL
+	cfg.Interval = 10
+	cfg.ListenAddr, cfg.HTTPAddr = "", ""
+	cfg.HeartbeatInterval = c.opts.HeartbeatInterval
+	cfg.RegisterRetries = 1
+	cfg.NTPServers = nil
+	app, err := worker.New(cfg, worker.WithWorkerID(id), worker.WithDialOptions(c.dialOptions()...))
+	if err != nil {
+		c.t.Fatalf("new worker %s: %v", id, err)
+	}
+	if err := app.Start(); err != nil {
+		c.t.Fatalf("start %s: %v", id, err)
+	}
+	node := &Node{ID: id, App: app, Register: app.Register, Worker: app.Worker}
+	c.mu.Lock()
+	c.nodes = append(c.nodes, node)
+	c.mu.Unlock()
+	return node
+}
+
+// Kill 模拟 worker 进程崩溃：断开连接、停止心跳并中止正在执行的任务，不通知 master
+func (c *Cluster) Kill(n *Node) {
+	n.stop()
+	if taskID := n.Worker.CurrentTask(); taskID != "" {
+		_ = n.Worker.StopTask(taskID, "killed")
+	}
+}
+
+// CreateTask 通过管理接口创建任务，返回任务 ID
+func (c *Cluster) CreateTask(name string, priority int) string {
+	c.t.Helper()
+	config, _ := json.Marshal(map[string]any{"username": name, "project_id": 1, "count": 1, "priority": priority})
+	task, err := c.Admin.CreateTask(context.Background(), &masterpb.CreateTaskRequest{TaskName: name, TickerConfig: string(config)})
+	if err != nil {
+		c.t.Fatalf("CreateTask %s: %v", name, err)
+	}
+	return task.TaskId
+}
+
+func (c *Cluster) Task(id string) *masterpb.TaskDetail {
+	c.t.Helper()
+	task, err := c.Admin.GetTask(context.Background(), &masterpb.TaskIdRequest{TaskId: id})
+	if err != nil {
+		c.t.Fatalf("GetTask %s: %v", id, err)
+	}
+	return task
+}
+
+// Worker 返回 master 记录的 worker 状态，已被移除时返回 nil
+func (c *Cluster) Worker(id string) *masterpb.WorkerDetail {
+	c.t.Helper()
+	reply, err := c.Admin.ListWorkers(context.Background(), &masterpb.ListWorkersRequest{})
+	if err != nil {
+		c.t.Fatalf("ListWorkers: %v", err)
+	}
+	for _, w := range reply.Workers {
+		if w.WorkerId == id {
+			return w
+		}
+	}
+	return nil
+}
+
+// WaitFor 轮询直到 cond 成立，超时则测试失败
+func (c *Cluster) WaitFor(desc string, timeout time.Duration, cond func() bool) {
+	c.t.Helper()
+	deadline := time.Now().Add(timeout)
+	for !cond() {
+		if time.Now().After(deadline) {
+			c.t.Fatalf("timeout waiting for %s", desc)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+// WaitTaskStatus 等待任务进入指定状态，返回最新的任务详情
+func (c *Cluster) WaitTaskStatus(id, status string, timeout time.Duration) *masterpb.TaskDetail {
+	c.t.Helper()
+	var task *masterpb.TaskDetail
+	c.WaitFor(fmt.Sprintf("task %s to be %s", id, status), timeout, func() bool {
+		task = c.Task(id)
+		return task.Status == status
+	})
+	return task
+}
@@ -0,0 +1,166 @@
+package clustertest
+
+import (
+	. "biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/fakebili"
+	"biliTickerStorm/internal/master"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+const waitTimeout = 10 * time.Second
+
+func TestSchedulingByPriority(t *testing.T) {
+	scheduler, _ := master.NewScheduler(master.PolicyPriority)
+	c := Start(t, Options{Scheduler: scheduler})
+	low := c.CreateTask("low", 1)
+	high := c.CreateTask("high", 5)
+	mid := c.CreateTask("mid", 3)
+
+	c.AddWorker()
+	c.AddWorker()
+	highTask := c.WaitTaskStatus(high, string(TaskStatusDoing), waitTimeout)
+	midTask := c.WaitTaskStatus(mid, string(TaskStatusDoing), waitTimeout)
+	if highTask.AssignedTo == midTask.AssignedTo {
+		t.Fatalf("tasks share worker %s", highTask.AssignedTo)
+	}
+	if got := c.Task(low).Status; got != string(TaskStatusPending) {
+		t.Fatalf("lowest priority task should wait, got %s", got)
+	}
+}
+
+func TestReassignAfterWorkerDies(t *testing.T) {
+	c := Start(t, Options{Workers: 2})
+	id := c.CreateTask("a", 0)
+	first := c.WaitTaskStatus(id, string(TaskStatusDoing), waitTimeout)
+
+	for _, n := range c.nodes {
+		if n.ID == first.AssignedTo {
+			c.Kill(n)
+		}
+	}
+	c.WaitFor("task reassigned", waitTimeout, func() bool {
+		task := c.Task(id)
+		return task.Status == string(TaskStatusDoing) && task.AssignedTo != first.AssignedTo
+	})
+	if w := c.Worker(first.AssignedTo); w != nil {
+		t.Fatalf("dead worker should be removed, got %+v", w)
+	}
+	if task := c.Task(id); task.RetryCount != 1 {
+		t.Fatalf("retry count = %d, want 1", task.RetryCount)
+	}
+}
+
+func TestRiskControlCooldown(t *testing.T) {
+	banTimeout := 800 * time.Millisecond
+	c := Start(t, Options{Workers: 1, BanTimeout: banTimeout, Scenario: fakebili.Scenario{RiskAfter: 2}})
+	id := c.CreateTask("a", 0)
+
+	var riskedAt time.Time
+	c.WaitFor("worker risking", waitTimeout, func() bool {
+		w := c.Worker("worker-1")
+		if w != nil && w.Status == Risking.String() {
+			riskedAt = time.Now()
+			return true
+		}
+		return false
+	})
+	if task := c.Task(id); task.Status != string(TaskStatusPending) {
+		t.Fatalf("task should be requeued after 412, got %s", task.Status)
+	}
+	c.Fake.Reset(fakebili.Scenario{SuccessOn: 1})
+
+	c.WaitTaskStatus(id, string(TaskStatusSucceeded), waitTimeout)
+	if elapsed := time.Since(riskedAt); elapsed < banTimeout/2 {
+		t.Fatalf("task resumed after %v, before the %v cooldown", elapsed, banTimeout)
+	}
+}
+
+func TestTasksComplete(t *testing.T) {
+	c := Start(t, Options{Workers: 2, Scenario: fakebili.Scenario{SuccessOn: 3, OrderID: 99}})
+	a := c.CreateTask("a", 0)
+	b := c.CreateTask("b", 0)
+
+	taskA := c.WaitTaskStatus(a, string(TaskStatusSucceeded), waitTimeout)
+	taskB := c.WaitTaskStatus(b, string(TaskStatusSucceeded), waitTimeout)
+	// 第一个下单成功的拿到订单号，另一个收到 100048 已有订单
+	if taskA.Result.GetOrderId()+taskB.Result.GetOrderId() != 99 {
+		t.Fatalf("unexpected results: %+v / %+v", taskA.Result, taskB.Result)
+	}
+	c.WaitFor("workers idle", waitTimeout, func() bool {
+		return c.Worker("worker-1").GetStatus() == Idle.String() && c.Worker("worker-2").GetStatus() == Idle.String()
+	})
+}
+
+func TestSaleWindowEnds(t *testing.T) {
+	c := Start(t, Options{Workers: 1})
+	end := time.Now().Add(500 * time.Millisecond)
+	config := `{"username":"a","project_id":1,"count":1}`
+	created, err := c.Admin.CreateTask(context.Background(), &masterpb.CreateTaskRequest{
+		TaskName:     "a",
+		TickerConfig: config,
+		Schedule:     &masterpb.ScheduleInfo{EndAt: end.UnixMilli(), IntervalMs: 5},
+	})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if created.Schedule.GetEndAt() != end.UnixMilli() {
+		t.Fatalf("schedule not stored: %+v", created.Schedule)
+	}
+
+	task := c.WaitTaskStatus(created.TaskId, string(TaskStatusFailed), waitTimeout)
+	if !strings.Contains(task.Result.GetMessage(), "售票窗口已结束") {
+		t.Fatalf("unexpected result: %+v", task.Result)
+	}
+	if time.Now().Before(end) {
+		t.Fatal("task stopped before the sale window ended")
+	}
+	// 按任务自己的 5ms 间隔重试，远多于默认配置能发出的请求数
+	if calls := c.Fake.Calls(fakebili.PathCreateV2); calls < 20 {
+		t.Fatalf("createV2 calls = %d, per-task interval not applied", calls)
+	}
+}
+
+func TestSynchronizedStart(t *testing.T) {
+	c := Start(t, Options{Workers: 1, Scenario: fakebili.Scenario{SuccessOn: 1}})
+	c.WaitFor("clock measured", waitTimeout, func() bool {
+		return c.Worker("worker-1").GetClockRttUs() > 0
+	})
+	startAt := time.Now().Add(time.Second)
+	created, err := c.Admin.CreateTask(context.Background(), &masterpb.CreateTaskRequest{
+		TaskName:     "a",
+		TickerConfig: `{"username":"a","project_id":1,"count":1}`,
+		Schedule:     &masterpb.ScheduleInfo{StartAt: startAt.UnixMilli()},
+	})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	// 开抢前就分配出去，等待开始信号
+	c.WaitTaskStatus(created.TaskId, string(TaskStatusDoing), waitTimeout)
+	if c.Fake.Calls(fakebili.PathPrepare) != 0 {
+		t.Fatal("prepare sent before T0")
+	}
+
+	c.WaitFor("start reported", waitTimeout, func() bool { return c.Task(created.TaskId).StartReported })
+	// 没有收到信号时 worker 要到 T0 之后 500ms 才开始
+	if skew := time.Duration(c.Task(created.TaskId).StartSkewUs) * time.Microsecond; skew.Abs() > 100*time.Millisecond {
+		t.Fatalf("first prepare left %v from T0", skew)
+	}
+	c.WaitTaskStatus(created.TaskId, string(TaskStatusSucceeded), waitTimeout)
+}
+
+func TestPurchaseStateInHeartbeat(t *testing.T) {
+	c := Start(t, Options{Workers: 1, Scenario: fakebili.Scenario{Captcha: "geetest"}})
+	id := c.CreateTask("a", 0)
+
+	// 默认场景一直返回前方拥堵，worker 停留在 Creating
+	c.WaitFor("purchase state reported", waitTimeout, func() bool {
+		return c.Worker("worker-1").GetPurchaseState() == "Creating" && c.Task(id).PurchaseState == "Creating"
+	})
+	c.Fake.Reset(fakebili.Scenario{SuccessOn: 1})
+	c.WaitTaskStatus(id, string(TaskStatusSucceeded), waitTimeout)
+	c.WaitFor("final state reported", waitTimeout, func() bool { return c.Task(id).PurchaseState == "Succeeded" })
+}
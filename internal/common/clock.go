@@ -0,0 +1,266 @@
+package common
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/beevik/ntp"
+)
+
+// Clock 时间来源。抢票相关的等待都通过 Clock 进行，测试中可以换成 FakeClock
+type Clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) Timer
+}
+
+// Timer 与 time.Timer 相同，但可以由 FakeClock 触发
+type Timer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+type systemTimer struct{ *time.Timer }
+
+func (t systemTimer) C() <-chan time.Time { return t.Timer.C }
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                 { return time.Now() }
+func (systemClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+// SystemClock 本地时钟
+func SystemClock() Clock { return systemClock{} }
+
+// SplitList 把逗号分隔的参数拆成列表，忽略空项
+func SplitList(v string) []string {
+	var items []string
+	for _, item := range strings.Split(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// DefaultNTPServers 未配置 NTP_SERVERS 时使用的服务器
+var DefaultNTPServers = []string{
+	"ntp.aliyun.com",
+	"cn.pool.ntp.org",
+	"time.google.com",
+	"time.windows.com",
+	"pool.ntp.org",
+}
+
+const (
+	DefaultNTPRefresh = 10 * time.Minute
+	ntpTimeout        = 2 * time.Second
+)
+
+// NTPClock 本地时钟加上缓存的 NTP 偏差。查询总是在后台进行，同一时间只有一个，Now 不会阻塞：
+// 第一次同步完成前偏差为 0，之后偏差过期时在后台刷新，所有服务器都不可用时沿用上一次的偏差
+type NTPClock struct {
+	servers []string
+	refresh time.Duration
+	query   func(server string) (time.Duration, error)
+
+	mu         sync.Mutex
+	offset     time.Duration
+	syncedAt   time.Time
+	refreshing bool
+}
+
+func NewNTPClock(servers []string, refresh time.Duration) *NTPClock {
+	if refresh <= 0 {
+		refresh = DefaultNTPRefresh
+	}
+	return &NTPClock{servers: servers, refresh: refresh, query: queryNTP}
+}
+
+func queryNTP(server string) (time.Duration, error) {
+	resp, err := ntp.QueryWithOptions(server, ntp.QueryOptions{Timeout: ntpTimeout})
+	if err != nil {
+		return 0, err
+	}
+	if err := resp.Validate(); err != nil {
+		return 0, err
+	}
+	return resp.ClockOffset, nil
+}
+
+func (c *NTPClock) Now() time.Time {
+	return time.Now().Add(c.Offset())
+}
+
+func (c *NTPClock) NewTimer(d time.Duration) Timer { return systemTimer{time.NewTimer(d)} }
+
+// Start 在后台开始第一次同步，启动时调用，避免到开抢前才去查询
+func (c *NTPClock) Start() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.refreshLocked()
+}
+
+// Offset 返回缓存的偏差，从未同步或已过期时在后台触发同步
+func (c *NTPClock) Offset() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.syncedAt.IsZero() || time.Since(c.syncedAt) > c.refresh {
+		c.refreshLocked()
+	}
+	return c.offset
+}
+
+// refreshLocked 没有正在进行的同步时启动一个
+func (c *NTPClock) refreshLocked() {
+	if c.refreshing {
+		return
+	}
+	c.refreshing = true
+	go c.Sync()
+}
+
+// Sync 依次查询 NTP 服务器，使用第一个成功的结果
+func (c *NTPClock) Sync() time.Duration {
+	offset, ok := time.Duration(0), false
+	for _, server := range c.servers {
+		d, err := c.query(server)
+		if err != nil {
+			log.Warningf("ntp %s 无法使用: %v", server, err)
+			continue
+		}
+		log.Infof("使用ntp %s,时间偏差 %s", server, d.String())
+		offset, ok = d, true
+		break
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ok {
+		c.offset = offset
+	} else if len(c.servers) > 0 {
+		log.Errorf("所有 NTP 服务器都无法访问，沿用上次的时间偏差 %s", c.offset)
+	}
+	// 失败也记录时间，避免每次 Now 都重新查询
+	c.syncedAt = time.Now()
+	c.refreshing = false
+	return c.offset
+}
+
+// spinWindow WaitUntil 最后一段改为自旋，避免定时器的调度误差
+const spinWindow = 2 * time.Millisecond
+
+// WaitUntil 等到 clock 上的时刻 t：先用定时器睡到 t 之前 spinWindow，再自旋检查。
+// ctx 被取消时返回 context.Cause(ctx)
+func WaitUntil(ctx context.Context, clock Clock, t time.Time) error {
+	for {
+		d := t.Sub(clock.Now())
+		if d <= 0 {
+			return nil
+		}
+		if d > spinWindow {
+			if err := SleepCtx(ctx, clock, d-spinWindow); err != nil {
+				return err
+			}
+			continue
+		}
+		if ctx.Err() != nil {
+			return context.Cause(ctx)
+		}
+		runtime.Gosched()
+	}
+}
+
+// SleepCtx 睡眠 d，ctx 被取消时提前返回 context.Cause(ctx)
+func SleepCtx(ctx context.Context, clock Clock, d time.Duration) error {
+	if ctx.Err() != nil {
+		return context.Cause(ctx)
+	}
+	if d <= 0 {
+		return nil
+	}
+	timer := clock.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C():
+		return nil
+	case <-ctx.Done():
+		return context.Cause(ctx)
+	}
+}
+
+// FakeClock 只在 Advance 时前进的时钟，用于测试
+type FakeClock struct {
+	mu     sync.Mutex
+	now    time.Time
+	timers []*fakeTimer
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	c        chan time.Time
+}
+
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := &fakeTimer{clock: c, deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+		return t
+	}
+	c.timers = append(c.timers, t)
+	return t
+}
+
+// Advance 前进 d 并触发到期的定时器
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+	sort.Slice(c.timers, func(i, j int) bool { return c.timers[i].deadline.Before(c.timers[j].deadline) })
+	remaining := c.timers[:0]
+	for _, t := range c.timers {
+		if t.deadline.After(c.now) {
+			remaining = append(remaining, t)
+			continue
+		}
+		t.c <- c.now
+	}
+	c.timers = remaining
+}
+
+// Timers 返回还没有触发的定时器数量，测试用它等待被测代码进入睡眠
+func (c *FakeClock) Timers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.timers)
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	c := t.clock
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for i, other := range c.timers {
+		if other == t {
+			c.timers = append(c.timers[:i], c.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,133 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitTimers(t *testing.T, clock *FakeClock, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for clock.Timers() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("timers = %d, want %d", clock.Timers(), n)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestSleepCtx(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	done := make(chan error, 1)
+	go func() { done <- SleepCtx(context.Background(), clock, time.Minute) }()
+	waitTimers(t, clock, 1)
+	clock.Advance(59 * time.Second)
+	select {
+	case <-done:
+		t.Fatal("SleepCtx returned early")
+	case <-time.After(10 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+	if err := <-done; err != nil {
+		t.Fatalf("SleepCtx: %v", err)
+	}
+
+	stopped := errors.New("stopped")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	go func() { done <- SleepCtx(ctx, clock, time.Hour) }()
+	waitTimers(t, clock, 1)
+	cancel(stopped)
+	if err := <-done; !errors.Is(err, stopped) {
+		t.Fatalf("expected cancel cause, got %v", err)
+	}
+	if clock.Timers() != 0 {
+		t.Fatal("timer should be stopped after cancel")
+	}
+}
+
+func TestWaitUntil(t *testing.T) {
+	clock := NewFakeClock(time.Unix(0, 0))
+	target := clock.Now().Add(time.Second)
+	done := make(chan error, 1)
+	go func() { done <- WaitUntil(context.Background(), clock, target) }()
+	// 先睡到 target 前 spinWindow，之后自旋
+	waitTimers(t, clock, 1)
+	clock.Advance(time.Second - spinWindow)
+	waitTimers(t, clock, 0)
+	clock.Advance(spinWindow)
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("WaitUntil: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitUntil did not return after target")
+	}
+
+	// 本地时钟下的精度
+	target = time.Now().Add(20 * time.Millisecond)
+	if err := WaitUntil(context.Background(), SystemClock(), target); err != nil {
+		t.Fatal(err)
+	}
+	if late := time.Since(target); late < 0 || late > 5*time.Millisecond {
+		t.Errorf("WaitUntil returned %v after target", late)
+	}
+}
+
+func TestNTPClock(t *testing.T) {
+	var queries atomic.Int32
+	clock := NewNTPClock([]string{"bad", "good"}, time.Hour)
+	clock.query = func(server string) (time.Duration, error) {
+		queries.Add(1)
+		if server == "bad" {
+			return 0, errors.New("timeout")
+		}
+		return time.Second, nil
+	}
+	// 第一次 Now 不等待查询，后台同步完成后才生效
+	deadline := time.Now().Add(time.Second)
+	for clock.Offset() != time.Second {
+		if time.Now().After(deadline) {
+			t.Fatal("offset not applied")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if d := clock.Now().Sub(time.Now()); d < 900*time.Millisecond {
+		t.Fatalf("offset not applied: %v", d)
+	}
+	clock.Now()
+	if got := queries.Load(); got != 2 {
+		t.Fatalf("offset should be cached, queries = %d", got)
+	}
+
+	// 全部失败时沿用上一次的偏差
+	clock.query = func(string) (time.Duration, error) { return 0, errors.New("down") }
+	if d := clock.Sync(); d != time.Second {
+		t.Fatalf("offset after failure = %v", d)
+	}
+}
+
+func TestNTPClockDoesNotBlock(t *testing.T) {
+	release := make(chan struct{})
+	var queries atomic.Int32
+	clock := NewNTPClock([]string{"slow"}, time.Hour)
+	clock.query = func(string) (time.Duration, error) {
+		queries.Add(1)
+		<-release
+		return time.Second, nil
+	}
+	defer close(release)
+	// 查询卡住时 Now 直接使用偏差 0，并发调用也只会发起一次查询
+	for i := 0; i < 10; i++ {
+		if d := clock.Offset(); d != 0 {
+			t.Fatalf("offset before first sync = %v", d)
+		}
+	}
+	time.Sleep(10 * time.Millisecond)
+	if got := queries.Load(); got != 1 {
+		t.Fatalf("queries = %d, want 1", got)
+	}
+}
@@ -0,0 +1,49 @@
+package common
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/caarlos0/env/v10"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+)
+
+// LoadLayeredConfig 按 默认值 < YAML 文件 < 环境变量 < 命令行参数 的优先级生成配置。
+// YAML 文件由 -config 参数或 CONFIG_FILE 环境变量指定；bind 把配置字段绑定为命令行参数。
+func LoadLayeredConfig[T any](name string, args []string, defaults func() *T, bind func(*flag.FlagSet, *T)) (*T, error) {
+	// 第一遍只为了拿到 -config 并提前发现未知参数
+	probe := flag.NewFlagSet(name, flag.ContinueOnError)
+	file := probe.String("config", os.Getenv("CONFIG_FILE"), "YAML 配置文件")
+	bind(probe, defaults())
+	if err := probe.Parse(args); err != nil {
+		return nil, err
+	}
+
+	cfg := defaults()
+	if *file != "" {
+		data, err := os.ReadFile(*file)
+		if err != nil {
+			return nil, fmt.Errorf("读取配置文件失败: %w", err)
+		}
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) { // 空文件不算错误
+			return nil, fmt.Errorf("解析配置文件 %s 失败: %w", *file, err)
+		}
+	}
+	if err := env.Parse(cfg); err != nil {
+		return nil, fmt.Errorf("环境变量解析失败: %w", err)
+	}
+
+	// 第二遍以当前值作为默认值，只有显式传入的参数会覆盖
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.String("config", *file, "YAML 配置文件")
+	bind(fs, cfg)
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
@@ -15,9 +15,31 @@ type TaskStatus string
 const (
 	TaskStatusPending TaskStatus = "Pending" //需要重新分配
 	TaskStatusDoing   TaskStatus = "Doing"
-	TaskStatusDone    TaskStatus = "Done"
+	TaskStatusDone    TaskStatus = "Done"   //已结束但没有上报结果
+	TaskStatusPaused  TaskStatus = "Paused" //运维暂停，不参与调度
+
+	TaskStatusSucceeded TaskStatus = "Succeeded" //抢到票（或已有未支付订单）
+	TaskStatusFailed    TaskStatus = "Failed"    //出错结束
+	TaskStatusCancelled TaskStatus = "Cancelled" //被 master 停止
 )
 
+// Finished 任务是否已经结束，不再需要调度
+func (s TaskStatus) Finished() bool {
+	switch s {
+	case TaskStatusDone, TaskStatusSucceeded, TaskStatusFailed, TaskStatusCancelled:
+		return true
+	}
+	return false
+}
+
 func (s WorkerStatus) String() string {
 	return [...]string{"Idle", "Working", "Risking", "Down"}[s]
 }
+
+// 任务类型
+type TaskKind string
+
+const (
+	TaskKindPurchase     TaskKind = ""              // 抢票
+	TaskKindSessionCheck TaskKind = "session_check" // 开抢前检查账号登录状态和购票人
+)
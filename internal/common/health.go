@@ -0,0 +1,96 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ReadyCheck 返回 nil 表示该依赖已就绪
+type ReadyCheck func(ctx context.Context) error
+
+type namedCheck struct {
+	name  string
+	check ReadyCheck
+}
+
+// Health 汇总就绪检查，同时驱动 gRPC health 服务和 HTTP /healthz、/readyz
+type Health struct {
+	grpc   *health.Server
+	mu     sync.Mutex
+	checks []namedCheck
+}
+
+func NewHealth() *Health {
+	h := &Health{grpc: health.NewServer()}
+	h.grpc.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	return h
+}
+
+func (h *Health) AddCheck(name string, check ReadyCheck) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// GRPCServer 注册到 grpc.Server 的标准健康检查服务
+func (h *Health) GRPCServer() healthpb.HealthServer {
+	return h.grpc
+}
+
+// Ready 依次执行所有检查，并同步 gRPC 的服务状态
+func (h *Health) Ready(ctx context.Context) error {
+	h.mu.Lock()
+	checks := append([]namedCheck(nil), h.checks...)
+	h.mu.Unlock()
+	var err error
+	for _, c := range checks {
+		if e := c.check(ctx); e != nil {
+			err = fmt.Errorf("%s: %w", c.name, e)
+			break
+		}
+	}
+	if err != nil {
+		h.grpc.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	} else {
+		h.grpc.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	}
+	return err
+}
+
+// Run 定期刷新 gRPC 健康状态，直到 stop 关闭
+func (h *Health) Run(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), interval)
+		_ = h.Ready(ctx)
+		cancel()
+		select {
+		case <-ticker.C:
+		case <-stop:
+			h.grpc.Shutdown()
+			return
+		}
+	}
+}
+
+// Register 在 mux 上挂载 /healthz（进程存活）和 /readyz（依赖就绪）
+func (h *Health) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), 3*time.Second)
+		defer cancel()
+		if err := h.Ready(ctx); err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte("ok"))
+	})
+}
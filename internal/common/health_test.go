@@ -0,0 +1,59 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestHealthReadiness(t *testing.T) {
+	h := NewHealth()
+	ready := false
+	h.AddCheck("loaded", func(ctx context.Context) error {
+		if !ready {
+			return errors.New("not yet")
+		}
+		return nil
+	})
+	mux := http.NewServeMux()
+	h.Register(mux)
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	get := func(path string) int {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			t.Fatalf("GET %s: %v", path, err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	grpcStatus := func() healthpb.HealthCheckResponse_ServingStatus {
+		resp, err := h.GRPCServer().Check(context.Background(), &healthpb.HealthCheckRequest{})
+		if err != nil {
+			t.Fatalf("Check: %v", err)
+		}
+		return resp.Status
+	}
+
+	if code := get("/healthz"); code != http.StatusOK {
+		t.Fatalf("/healthz = %d", code)
+	}
+	if code := get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Fatalf("/readyz before ready = %d", code)
+	}
+	if s := grpcStatus(); s != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Fatalf("grpc status before ready = %s", s)
+	}
+	ready = true
+	if code := get("/readyz"); code != http.StatusOK {
+		t.Fatalf("/readyz after ready = %d", code)
+	}
+	if s := grpcStatus(); s != healthpb.HealthCheckResponse_SERVING {
+		t.Fatalf("grpc status after ready = %s", s)
+	}
+}
@@ -0,0 +1,47 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+	"time"
+)
+
+// NewOpsMux 运维用的 HTTP 路由，/metrics 输出默认 registry 和额外的 gatherers
+func NewOpsMux(gatherers ...prometheus.Gatherer) *http.ServeMux {
+	mux := http.NewServeMux()
+	all := append(prometheus.Gatherers{prometheus.DefaultGatherer}, gatherers...)
+	mux.Handle("/metrics", promhttp.HandlerFor(all, promhttp.HandlerOpts{}))
+	return mux
+}
+
+// OpsServer 运维 HTTP 服务，与 gRPC 端口分开
+type OpsServer struct {
+	srv *http.Server
+}
+
+// ServeOps 在后台监听 addr，addr 为空时不启动
+func ServeOps(addr string, handler http.Handler) *OpsServer {
+	if addr == "" {
+		return &OpsServer{}
+	}
+	srv := &http.Server{Addr: addr, Handler: handler, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		log.Printf("ops http listening at %s", addr)
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Errorf("ops http failed: %v", err)
+		}
+	}()
+	return &OpsServer{srv: srv}
+}
+
+func (o *OpsServer) Shutdown() {
+	if o.srv == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = o.srv.Shutdown(ctx)
+}
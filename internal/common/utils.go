@@ -1,12 +1,13 @@
 package common
 
 import (
+	"context"
 	formatter "github.com/DaRealFreak/colored-nested-formatter"
-	"github.com/beevik/ntp"
 	"github.com/sirupsen/logrus"
 	"os"
 	"sync"
 	"time"
+	_ "time/tzdata"
 )
 
 var (
@@ -49,37 +50,34 @@ type customFormatter struct {
 	prefix string
 }
 
+// defaultClock GetAccurateTime 和 SleepUntilAccurate 共用，NTP 偏差只查询一次并定期刷新
+var defaultClock = NewNTPClock(DefaultNTPServers, DefaultNTPRefresh)
+
+// GetAccurateTime 返回 NTP 校准后的时间
+//
+// Deprecated: 使用 NTPClock.Now
 func GetAccurateTime() time.Time {
-	var ntpServers = []string{
-		"ntp.aliyun.com",
-		"cn.pool.ntp.org",
-		"time.google.com",
-		"time.windows.com",
-		"pool.ntp.org",
-	}
-	for _, server := range ntpServers {
-		resp, err := ntp.Query(server)
-		if err != nil {
-			log.Warningf("ntp %s 无法使用", server)
-			continue
-		}
-		accurate := time.Now().Add(resp.ClockOffset)
-		log.Infof("使用ntp %s,时间偏差 %s", server, resp.ClockOffset.String())
-		return accurate
-	}
-	// 所有 NTP 失败，降级使用本地时间
-	log.Errorf("所有 NTP 服务器都无法访问，使用本地时间。")
-	return time.Now()
+	return defaultClock.Now()
 }
+
+// SleepUntilAccurate 按 NTP 校准后的时间等到 target
+//
+// Deprecated: 使用 WaitUntil，它可以被取消
 func SleepUntilAccurate(target time.Time) error {
-	now := GetAccurateTime()
-	if now.After(target) || now.Equal(target) {
-		return nil
+	return WaitUntil(context.Background(), defaultClock, target)
+}
+
+// TicketTimeLayout 开抢时间的配置格式，按北京时间解析
+const TicketTimeLayout = "2006-01-02T15:04"
+
+// ParseTicketTime 解析北京时间 2006-01-02T15:04，也接受带时区的 RFC3339
+func ParseTicketTime(s string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, nil
 	}
-	delta := target.Sub(now)
-	cycleTimerChan := time.Tick(delta)
-	select {
-	case <-cycleTimerChan:
-		return nil
+	loc, err := time.LoadLocation("Asia/Shanghai")
+	if err != nil {
+		return time.Time{}, err
 	}
+	return time.ParseInLocation(TicketTimeLayout, s, loc)
 }
@@ -0,0 +1,229 @@
+// Package fakebili 模拟 B 站会员购下单、账号信息接口和验证码服务，用于不依赖真实账号的端到端测试。
+// 同一个 Server 同时充当 show.bilibili.com、api.bilibili.com 和 GT_BASE_URL。
+package fakebili
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+)
+
+const (
+	PathPrepare   = "/api/ticket/order/prepare"
+	PathCreateV2  = "/api/ticket/order/createV2"
+	PathRegister  = "/x/gaia-vgate/v1/register"
+	PathValidate  = "/x/gaia-vgate/v1/validate"
+	PathGeetest   = "/validate/geetest"
+	PathNav       = "/x/web-interface/nav"
+	PathBuyerList = "/api/ticket/buyer/list"
+)
+
+// Buyer 账号下的一个购票人
+type Buyer struct {
+	ID   int
+	Name string
+}
+
+// Scenario 描述假服务器的行为，零值表示：无验证码、不风控、createV2 一直返回前方拥堵，账号已登录但没有购票人
+type Scenario struct {
+	SoldOut     bool    // createV2 始终返回 100009 库存不足
+	Price       int     // 非 0 时 createV2 的 pay_money 必须等于该值，否则返回 100034 和新票价
+	TokenExpiry int     // 每个 prepare token 只能用于 N 次 createV2，之后返回 100051；token 不匹配时同样返回 100051
+	Captcha     string  // prepare 首次返回 -401，验证码类型为 geetest 或 phone
+	RiskAfter   int     // 累计请求超过 N 次后所有接口返回 HTTP 412
+	SuccessOn   int     // 第 K 次 createV2 下单成功，之后返回 100048 已有订单
+	OrderID     int64   // 成功时返回的订单号，默认 1
+	Errno       int     // 非 0 时 createV2 在 token 有效的情况下始终返回该 errno
+	LoggedOut   bool    // nav 和 buyer/list 返回未登录
+	Mid         int64   // 登录账号的 uid，默认 1
+	Buyers      []Buyer // buyer/list 返回的购票人
+	// Delay 每个请求在响应前等待的时间，客户端断开时提前结束
+	Delay time.Duration
+}
+
+// Server 实现 http.Handler，记录每个接口的调用次数
+type Server struct {
+	mu       sync.Mutex
+	scenario Scenario
+	calls    map[string]int
+	total    int
+	verified bool // 已通过验证码
+	token    string
+	tokenUse int
+	tokenSeq int
+	creates  int
+	ordered  bool
+}
+
+func New(scenario Scenario) *Server {
+	return &Server{scenario: scenario, calls: make(map[string]int)}
+}
+
+// NewTestServer 在本进程内启动假服务器，返回的 URL 用作所有 base URL
+func NewTestServer(scenario Scenario) (*Server, *httptest.Server) {
+	s := New(scenario)
+	return s, httptest.NewServer(s)
+}
+
+// Reset 替换场景并清空计数
+func (s *Server) Reset(scenario Scenario) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.scenario = scenario
+	s.calls = make(map[string]int)
+	s.total, s.creates, s.tokenUse = 0, 0, 0
+	s.verified, s.ordered = false, false
+	s.token = ""
+}
+
+// Calls 返回某个接口被调用的次数
+func (s *Server) Calls(path string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[path]
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	s.calls[r.URL.Path]++
+	s.total++
+	delay := s.scenario.Delay
+	s.mu.Unlock()
+	if delay > 0 {
+		select {
+		case <-time.After(delay):
+		case <-r.Context().Done():
+			return
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.scenario.RiskAfter > 0 && s.total > s.scenario.RiskAfter {
+		http.Error(w, "precondition failed", http.StatusPreconditionFailed)
+		return
+	}
+	switch r.URL.Path {
+	case PathPrepare:
+		s.prepare(w)
+	case PathCreateV2:
+		s.createV2(w, r)
+	case PathRegister:
+		s.register(w)
+	case PathValidate:
+		s.verified = true
+		writeJSON(w, map[string]any{"code": 0, "message": "0"})
+	case PathNav:
+		s.nav(w)
+	case PathBuyerList:
+		s.buyerList(w)
+	case PathGeetest:
+		writeJSON(w, map[string]any{"validate": "fake-validate", "seccode": "fake-validate|jordan"})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (s *Server) prepare(w http.ResponseWriter) {
+	if s.scenario.Captcha != "" && !s.verified {
+		writeJSON(w, map[string]any{
+			"errno": -401,
+			"msg":   "需要验证",
+			"data":  map[string]any{"ga_data": map[string]any{"riskParams": map[string]any{"v_voucher": "fake-voucher"}}},
+		})
+		return
+	}
+	s.tokenSeq++
+	s.token = fmt.Sprintf("fake-token-%d", s.tokenSeq)
+	s.tokenUse = 0
+	writeJSON(w, map[string]any{"errno": 0, "data": map[string]any{"token": s.token}})
+}
+
+func (s *Server) createV2(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		PayMoney int    `json:"pay_money"`
+		Token    string `json:"token"`
+	}
+	_ = json.NewDecoder(r.Body).Decode(&body)
+	s.creates++
+	s.tokenUse++
+	switch {
+	case s.ordered:
+		writeErrno(w, 100048, nil)
+	case body.Token == "" || body.Token != s.token,
+		s.scenario.TokenExpiry > 0 && s.tokenUse > s.scenario.TokenExpiry:
+		writeErrno(w, 100051, nil)
+	case s.scenario.Errno != 0:
+		writeErrno(w, s.scenario.Errno, nil)
+	case s.scenario.SoldOut:
+		writeErrno(w, 100009, nil)
+	case s.scenario.Price != 0 && body.PayMoney != s.scenario.Price:
+		writeErrno(w, 100034, map[string]any{"pay_money": s.scenario.Price})
+	case s.scenario.SuccessOn > 0 && s.creates >= s.scenario.SuccessOn:
+		s.ordered = true
+		orderID := s.scenario.OrderID
+		if orderID == 0 {
+			orderID = 1
+		}
+		writeErrno(w, 0, map[string]any{"orderId": orderID})
+	default:
+		writeErrno(w, 100001, nil)
+	}
+}
+
+func (s *Server) register(w http.ResponseWriter) {
+	captchaType := s.scenario.Captcha
+	if captchaType == "" {
+		captchaType = "geetest"
+	}
+	writeJSON(w, map[string]any{
+		"code": 0,
+		"data": map[string]any{
+			"token":   "fake-captcha-token",
+			"type":    captchaType,
+			"geetest": map[string]any{"gt": "fake-gt", "challenge": "fake-challenge"},
+		},
+	})
+}
+
+func (s *Server) nav(w http.ResponseWriter) {
+	if s.scenario.LoggedOut {
+		writeJSON(w, map[string]any{"code": -101, "message": "账号未登录", "data": map[string]any{"isLogin": false}})
+		return
+	}
+	writeJSON(w, map[string]any{
+		"code":    0,
+		"message": "0",
+		"data":    map[string]any{"isLogin": true, "mid": s.mid(), "uname": "fake-user"},
+	})
+}
+
+func (s *Server) buyerList(w http.ResponseWriter) {
+	if s.scenario.LoggedOut {
+		writeJSON(w, map[string]any{"errno": 83000004, "msg": "请先登录"})
+		return
+	}
+	list := make([]map[string]any, 0, len(s.scenario.Buyers))
+	for _, b := range s.scenario.Buyers {
+		list = append(list, map[string]any{"id": b.ID, "uid": s.mid(), "name": b.Name, "id_type": 0})
+	}
+	writeErrno(w, 0, map[string]any{"list": list})
+}
+
+func (s *Server) mid() int64 {
+	if s.scenario.Mid == 0 {
+		return 1
+	}
+	return s.scenario.Mid
+}
+
+func writeErrno(w http.ResponseWriter, errno int, data map[string]any) {
+	writeJSON(w, map[string]any{"errno": errno, "data": data})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
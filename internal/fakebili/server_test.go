@@ -0,0 +1,52 @@
+package fakebili
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"testing"
+)
+
+func post(t *testing.T, url string, body any) (int, map[string]any) {
+	t.Helper()
+	data, _ := json.Marshal(body)
+	resp, err := http.Post(url, "application/json", bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("POST %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	var ret map[string]any
+	_ = json.NewDecoder(resp.Body).Decode(&ret)
+	return resp.StatusCode, ret
+}
+
+func TestScenarios(t *testing.T) {
+	fake, srv := NewTestServer(Scenario{SoldOut: true, RiskAfter: 3})
+	defer srv.Close()
+
+	_, prepared := post(t, srv.URL+PathPrepare, nil)
+	token := prepared["data"].(map[string]any)["token"]
+	if _, ret := post(t, srv.URL+PathCreateV2, map[string]any{"token": token}); ret["errno"] != float64(100009) {
+		t.Fatalf("sold out: %v", ret)
+	}
+	if _, ret := post(t, srv.URL+PathCreateV2, map[string]any{"token": "stale"}); ret["errno"] != float64(100051) {
+		t.Fatalf("stale token: %v", ret)
+	}
+	if code, _ := post(t, srv.URL+PathPrepare, nil); code != http.StatusPreconditionFailed {
+		t.Fatalf("expected 412 after 3 calls, got %d", code)
+	}
+
+	fake.Reset(Scenario{SuccessOn: 2, OrderID: 7})
+	_, prepared = post(t, srv.URL+PathPrepare, nil)
+	token = prepared["data"].(map[string]any)["token"]
+	wantErrno := []float64{100001, 0, 100048}
+	for i, want := range wantErrno {
+		_, ret := post(t, srv.URL+PathCreateV2, map[string]any{"token": token})
+		if ret["errno"] != want {
+			t.Fatalf("createV2 #%d: errno %v, want %v", i+1, ret["errno"], want)
+		}
+	}
+	if fake.Calls(PathCreateV2) != 3 {
+		t.Fatalf("calls = %d", fake.Calls(PathCreateV2))
+	}
+}
@@ -0,0 +1,372 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"sort"
+	"time"
+)
+
+// AdminServer 运维接口，直接操作 Server 的 tasks/workers
+type AdminServer struct {
+	masterpb.UnimplementedTicketAdminServer
+	s *Server
+}
+
+func NewAdminServer(s *Server) *AdminServer {
+	return &AdminServer{s: s}
+}
+
+// AdminAuth 校验请求 metadata 中的 authorization: Bearer <token>，token 为空时不校验
+func AdminAuth(token string) grpc.UnaryServerInterceptor {
+	want := []byte("Bearer " + token)
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if token == "" {
+			return handler(ctx, req)
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		values := md.Get("authorization")
+		if len(values) != 1 || subtle.ConstantTimeCompare([]byte(values[0]), want) != 1 {
+			return nil, status.Error(codes.Unauthenticated, "invalid admin token")
+		}
+		return handler(ctx, req)
+	}
+}
+
+func (a *AdminServer) CreateTask(ctx context.Context, req *masterpb.CreateTaskRequest) (*masterpb.TaskDetail, error) {
+	if req.TaskName == "" {
+		return nil, status.Error(codes.InvalidArgument, "task_name is required")
+	}
+	if !json.Valid([]byte(req.TickerConfig)) {
+		return nil, status.Error(codes.InvalidArgument, "ticker_config is not valid JSON")
+	}
+	schedule := scheduleFromPB(req.Schedule)
+	if err := schedule.validate(); err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "schedule: %v", err)
+	}
+	// 检查重名和创建在同一把锁内，避免并发创建同名任务
+	a.s.tasksMux.Lock()
+	defer a.s.tasksMux.Unlock()
+	for _, task := range a.s.tasks {
+		if task.TaskName == req.TaskName {
+			return nil, status.Errorf(codes.AlreadyExists, "task name <%s> already used by %s", req.TaskName, task.ID)
+		}
+	}
+	taskID := fmt.Sprintf("task-%d", time.Now().UnixNano())
+	task := a.s.createTaskLocked(taskID, req.TaskName, req.TickerConfig, schedule.content(), "", contentHash(req.TickerConfig))
+	a.s.triggerSchedule()
+	return toTaskDetail(task, true), nil
+}
+
+func (a *AdminServer) ListTasks(ctx context.Context, req *masterpb.ListTasksRequest) (*masterpb.ListTasksReply, error) {
+	a.s.tasksMux.RLock()
+	defer a.s.tasksMux.RUnlock()
+	tasks := make([]*TaskInfo, 0, len(a.s.tasks))
+	for _, task := range a.s.tasks {
+		if req.Status != "" && string(task.Status) != req.Status {
+			continue
+		}
+		tasks = append(tasks, task)
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		if tasks[i].CreatedAt.Equal(tasks[j].CreatedAt) {
+			return tasks[i].ID < tasks[j].ID
+		}
+		return tasks[i].CreatedAt.Before(tasks[j].CreatedAt)
+	})
+	reply := &masterpb.ListTasksReply{}
+	for _, task := range tasks {
+		reply.Tasks = append(reply.Tasks, toTaskDetail(task, false))
+	}
+	return reply, nil
+}
+
+func (a *AdminServer) GetTask(ctx context.Context, req *masterpb.TaskIdRequest) (*masterpb.TaskDetail, error) {
+	a.s.tasksMux.RLock()
+	defer a.s.tasksMux.RUnlock()
+	task, exists := a.s.tasks[req.TaskId]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "<%s> not found", req.TaskId)
+	}
+	return toTaskDetail(task, true), nil
+}
+
+func (a *AdminServer) DeleteTask(ctx context.Context, req *masterpb.TaskIdRequest) (*masterpb.AdminReply, error) {
+	if err := a.s.DeleteTask(req.TaskId); err != nil {
+		return nil, err
+	}
+	return &masterpb.AdminReply{Success: true, Message: fmt.Sprintf("<%s> deleted", req.TaskId)}, nil
+}
+
+func (a *AdminServer) PauseTask(ctx context.Context, req *masterpb.TaskIdRequest) (*masterpb.AdminReply, error) {
+	if err := a.s.PauseTask(req.TaskId); err != nil {
+		return nil, err
+	}
+	return &masterpb.AdminReply{Success: true, Message: fmt.Sprintf("<%s> paused", req.TaskId)}, nil
+}
+
+func (a *AdminServer) ResumeTask(ctx context.Context, req *masterpb.TaskIdRequest) (*masterpb.AdminReply, error) {
+	if err := a.s.ResumeTask(req.TaskId); err != nil {
+		return nil, err
+	}
+	return &masterpb.AdminReply{Success: true, Message: fmt.Sprintf("<%s> resumed", req.TaskId)}, nil
+}
+
+func (a *AdminServer) ListWorkers(ctx context.Context, req *masterpb.ListWorkersRequest) (*masterpb.ListWorkersReply, error) {
+	a.s.workersMux.RLock()
+	defer a.s.workersMux.RUnlock()
+	workers := make([]*Worker, 0, len(a.s.workers))
+	for _, worker := range a.s.workers {
+		workers = append(workers, worker)
+	}
+	sort.Slice(workers, func(i, j int) bool { return workers[i].WorkerID < workers[j].WorkerID })
+	reply := &masterpb.ListWorkersReply{}
+	for _, worker := range workers {
+		clock := a.s.clockOf(worker.WorkerID)
+		reply.Workers = append(reply.Workers, &masterpb.WorkerDetail{
+			WorkerId:      worker.WorkerID,
+			Address:       worker.Address,
+			Status:        worker.Status.String(),
+			TaskAssigned:  worker.TaskAssigned,
+			UpdateTime:    unixMilli(worker.UpdateTime),
+			BanTime:       unixMilli(worker.BanTime),
+			ClockOffsetUs: clock.offset.Microseconds(),
+			ClockRttUs:    clock.rtt.Microseconds(),
+			PurchaseState: worker.PurchaseState,
+		})
+	}
+	return reply, nil
+}
+
+// ListDeadLetters 返回所有 Failed 任务，包括超过重试次数的和 worker 上报失败的
+func (a *AdminServer) ListDeadLetters(ctx context.Context, req *masterpb.ListDeadLettersRequest) (*masterpb.ListTasksReply, error) {
+	return a.ListTasks(ctx, &masterpb.ListTasksRequest{Status: string(TaskStatusFailed)})
+}
+
+func (a *AdminServer) RequeueTask(ctx context.Context, req *masterpb.TaskIdRequest) (*masterpb.AdminReply, error) {
+	if err := a.s.RequeueTask(req.TaskId); err != nil {
+		return nil, err
+	}
+	return &masterpb.AdminReply{Success: true, Message: fmt.Sprintf("<%s> requeued", req.TaskId)}, nil
+}
+
+func (a *AdminServer) CheckSession(ctx context.Context, req *masterpb.TaskIdRequest) (*masterpb.TaskDetail, error) {
+	task, err := a.s.CheckSession(req.TaskId)
+	if err != nil {
+		return nil, err
+	}
+	a.s.tasksMux.RLock()
+	defer a.s.tasksMux.RUnlock()
+	return toTaskDetail(task, false), nil
+}
+
+func (a *AdminServer) ConfirmReload(ctx context.Context, req *masterpb.TaskIdRequest) (*masterpb.AdminReply, error) {
+	if err := a.s.ConfirmReload(req.TaskId); err != nil {
+		return nil, err
+	}
+	return &masterpb.AdminReply{Success: true, Message: fmt.Sprintf("<%s> reloaded", req.TaskId)}, nil
+}
+
+// DeleteTask 删除任务，正在执行的任务会通知 worker 停止
+func (s *Server) DeleteTask(taskID string) error {
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return status.Errorf(codes.NotFound, "<%s> not found", taskID)
+	}
+	delete(s.tasks, taskID)
+	if task.Status == TaskStatusDoing && task.AssignedTo != "" {
+		go s.stopTaskOnWorker(task.AssignedTo, taskID, "deleted")
+	}
+	if err := s.store.DeleteTask(taskID); err != nil {
+		log.Errorf("[Store] 删除任务 %s 失败: %v", taskID, err)
+	}
+	log.Printf("[Delete] Task <%s> (%s) deleted", task.TaskName, task.Status)
+	return nil
+}
+
+// PauseTask 暂停任务，Pending/Doing 的任务变为 Paused，不再参与调度
+func (s *Server) PauseTask(taskID string) error {
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return status.Errorf(codes.NotFound, "<%s> not found", taskID)
+	}
+	if task.Status != TaskStatusPending && task.Status != TaskStatusDoing {
+		return status.Errorf(codes.FailedPrecondition, "<%s> is %s, cannot pause", taskID, task.Status)
+	}
+	if task.Status == TaskStatusDoing && task.AssignedTo != "" {
+		go s.stopTaskOnWorker(task.AssignedTo, taskID, "paused")
+	}
+	task.Status = TaskStatusPaused
+	task.AssignedTo = ""
+	task.UpdatedAt = time.Now()
+	s.persistTask(task)
+	log.Printf("[Pause] Task <%s> paused", task.TaskName)
+	return nil
+}
+
+// ResumeTask 恢复暂停的任务，重新进入调度
+func (s *Server) ResumeTask(taskID string) error {
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return status.Errorf(codes.NotFound, "<%s> not found", taskID)
+	}
+	if task.Status != TaskStatusPaused {
+		return status.Errorf(codes.FailedPrecondition, "<%s> is %s, not paused", taskID, task.Status)
+	}
+	task.Status = TaskStatusPending
+	task.UpdatedAt = time.Now()
+	s.persistTask(task)
+	s.triggerSchedule()
+	log.Printf("[Resume] Task <%s> resumed", task.TaskName)
+	return nil
+}
+
+// RequeueTask 把 Failed/Cancelled 任务重新放回调度队列，重试次数清零
+func (s *Server) RequeueTask(taskID string) error {
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return status.Errorf(codes.NotFound, "<%s> not found", taskID)
+	}
+	if task.Status != TaskStatusFailed && task.Status != TaskStatusCancelled {
+		return status.Errorf(codes.FailedPrecondition, "<%s> is %s, only Failed/Cancelled can be requeued", taskID, task.Status)
+	}
+	task.Status = TaskStatusPending
+	task.RetryCount = 0
+	task.AssignedTo = ""
+	task.PurchaseState = ""
+	task.Result = nil
+	task.UpdatedAt = time.Now()
+	s.persistTask(task)
+	s.triggerSchedule()
+	log.Printf("[Requeue] Task <%s> requeued, last error: %s", task.TaskName, task.LastError)
+	return nil
+}
+
+// sessionCheckSuffix 账号检查任务的名称为原任务名加上该后缀
+const sessionCheckSuffix = "#session"
+
+// CheckSession 复制任务的配置创建一个账号检查任务，worker 收到后立即检查 cookie 和购票人，
+// 不等待开抢时间。同一个任务同时只能有一个未结束的检查
+func (s *Server) CheckSession(taskID string) (*TaskInfo, error) {
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return nil, status.Errorf(codes.NotFound, "<%s> not found", taskID)
+	}
+	if task.Kind != TaskKindPurchase {
+		return nil, status.Errorf(codes.FailedPrecondition, "<%s> is a %s task", taskID, task.Kind)
+	}
+	name := task.TaskName + sessionCheckSuffix
+	for _, other := range s.tasks {
+		if other.TaskName == name && !other.Status.Finished() {
+			return nil, status.Errorf(codes.AlreadyExists, "session check %s for <%s> is %s", other.ID, taskID, other.Status)
+		}
+	}
+	now := time.Now()
+	check := &TaskInfo{
+		ID:                  fmt.Sprintf("task-%d", now.UnixNano()),
+		Kind:                TaskKindSessionCheck,
+		Status:              TaskStatusPending,
+		CreatedAt:           now,
+		UpdatedAt:           now,
+		TaskName:            name,
+		TickerConfigContent: task.TickerConfigContent,
+		ScheduleContent:     task.ScheduleContent, // 检查结果通知任务自己的目标
+		ContentHash:         task.ContentHash,
+	}
+	s.addTaskLocked(check)
+	s.triggerSchedule()
+	return check, nil
+}
+
+func toTaskDetail(task *TaskInfo, withConfig bool) *masterpb.TaskDetail {
+	detail := &masterpb.TaskDetail{
+		TaskId:        task.ID,
+		TaskName:      task.TaskName,
+		Kind:          string(task.Kind),
+		Status:        string(task.Status),
+		AssignedTo:    task.AssignedTo,
+		CreatedAt:     unixMilli(task.CreatedAt),
+		UpdatedAt:     unixMilli(task.UpdatedAt),
+		RetryCount:    int32(task.RetryCount),
+		LastError:     task.LastError,
+		Source:        task.Source,
+		ReloadPending: task.PendingHash != "",
+		Schedule:      task.Schedule.toPB(),
+		StartSkewUs:   task.StartSkew.Microseconds(),
+		StartReported: task.StartReported,
+		PurchaseState: task.PurchaseState,
+	}
+	if withConfig {
+		detail.TickerConfig = redactCookies(task.TickerConfigContent)
+	}
+	if r := task.Result; r != nil {
+		detail.Result = &masterpb.TaskResultInfo{
+			TaskId:     task.ID,
+			WorkerId:   r.WorkerID,
+			Status:     string(task.Status),
+			Errno:      int32(r.Errno),
+			Message:    r.Message,
+			OrderId:    r.OrderId,
+			PayMoney:   int32(r.PayMoney),
+			Attempts:   int32(r.Attempts),
+			DurationMs: r.Duration.Milliseconds(),
+		}
+	}
+	return detail
+}
+
+// redactedValue 替换 GetTask 返回的 cookie 值
+const redactedValue = "<redacted>"
+
+// redactCookies 把配置中 cookies 的 value 替换掉，其余字段原样返回。无法解析时整个配置都不返回
+func redactCookies(content string) string {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &fields); err != nil {
+		return ""
+	}
+	raw, ok := fields["cookies"]
+	if !ok {
+		return content
+	}
+	var cookies []map[string]any
+	if err := json.Unmarshal(raw, &cookies); err != nil {
+		delete(fields, "cookies")
+	} else {
+		for _, cookie := range cookies {
+			if _, ok := cookie["value"]; ok {
+				cookie["value"] = redactedValue
+			}
+		}
+		fields["cookies"], _ = json.Marshal(cookies)
+	}
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func unixMilli(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixMilli()
+}
@@ -0,0 +1,191 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdminTaskLifecycle(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+	defer s.Stop()
+	admin := NewAdminServer(s)
+	ctx := context.Background()
+
+	if _, err := admin.CreateTask(ctx, &masterpb.CreateTaskRequest{TaskName: "bad", TickerConfig: "{"}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("invalid JSON should be rejected, got %v", err)
+	}
+	created, err := admin.CreateTask(ctx, &masterpb.CreateTaskRequest{TaskName: "a", TickerConfig: `{"username":"u"}`})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	if _, err := admin.CreateTask(ctx, &masterpb.CreateTaskRequest{TaskName: "a", TickerConfig: "{}"}); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("duplicate name should be rejected, got %v", err)
+	}
+
+	if _, err := admin.PauseTask(ctx, &masterpb.TaskIdRequest{TaskId: created.TaskId}); err != nil {
+		t.Fatalf("PauseTask: %v", err)
+	}
+	got, err := admin.GetTask(ctx, &masterpb.TaskIdRequest{TaskId: created.TaskId})
+	if err != nil || got.Status != string(TaskStatusPaused) || got.TickerConfig != `{"username":"u"}` {
+		t.Fatalf("GetTask after pause: %+v %v", got, err)
+	}
+	if _, err := admin.PauseTask(ctx, &masterpb.TaskIdRequest{TaskId: created.TaskId}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("pausing a paused task should fail, got %v", err)
+	}
+	if _, err := admin.ResumeTask(ctx, &masterpb.TaskIdRequest{TaskId: created.TaskId}); err != nil {
+		t.Fatalf("ResumeTask: %v", err)
+	}
+
+	list, err := admin.ListTasks(ctx, &masterpb.ListTasksRequest{Status: string(TaskStatusPending)})
+	if err != nil || len(list.Tasks) != 1 || list.Tasks[0].TickerConfig != "" {
+		t.Fatalf("ListTasks: %+v %v", list, err)
+	}
+
+	if _, err := admin.DeleteTask(ctx, &masterpb.TaskIdRequest{TaskId: created.TaskId}); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	if _, err := admin.GetTask(ctx, &masterpb.TaskIdRequest{TaskId: created.TaskId}); status.Code(err) != codes.NotFound {
+		t.Fatalf("deleted task should be gone, got %v", err)
+	}
+}
+
+func TestAdminCreateTaskUniqueName(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+	defer s.Stop()
+	admin := NewAdminServer(s)
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	created := 0
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := admin.CreateTask(context.Background(), &masterpb.CreateTaskRequest{TaskName: "a", TickerConfig: "{}"}); err == nil {
+				mu.Lock()
+				created++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	if created != 1 || len(s.tasks) != 1 {
+		t.Fatalf("concurrent CreateTask with the same name created %d tasks", created)
+	}
+}
+
+func TestAdminAuth(t *testing.T) {
+	auth := AdminAuth("secret")
+	handler := func(ctx context.Context, req any) (any, error) { return "ok", nil }
+	for header, want := range map[string]codes.Code{"": codes.Unauthenticated, "Bearer wrong": codes.Unauthenticated, "Bearer secret": codes.OK} {
+		ctx := context.Background()
+		if header != "" {
+			ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", header))
+		}
+		if _, err := auth(ctx, nil, &grpc.UnaryServerInfo{}, handler); status.Code(err) != want {
+			t.Errorf("authorization %q: got %v, want %s", header, err, want)
+		}
+	}
+}
+
+func TestAdminGetTaskRedactsCookies(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+	defer s.Stop()
+	admin := NewAdminServer(s)
+	ctx := context.Background()
+
+	created, err := admin.CreateTask(ctx, &masterpb.CreateTaskRequest{TaskName: "a", TickerConfig: `{"username":"u","cookies":[{"name":"SESSDATA","value":"s3cret"}]}`})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	got, err := admin.GetTask(ctx, &masterpb.TaskIdRequest{TaskId: created.TaskId})
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if strings.Contains(got.TickerConfig, "s3cret") || !strings.Contains(got.TickerConfig, `"name":"SESSDATA"`) {
+		t.Fatalf("cookie values should be redacted: %s", got.TickerConfig)
+	}
+	if strings.Contains(created.TickerConfig, "s3cret") {
+		t.Fatalf("CreateTask should not echo cookies: %s", created.TickerConfig)
+	}
+}
+
+func TestAdminCheckSession(t *testing.T) {
+	events := make(chan Event, 4)
+	s := NewServer(NewMemoryStore(), WithEventSink(func(_ context.Context, e Event) error {
+		events <- e
+		return nil
+	}))
+	defer s.Stop()
+	// 带通知目标的事件发送到任务自己的目标
+	s.events.targetSink = func(_ context.Context, e Event) error {
+		events <- e
+		return nil
+	}
+	admin := NewAdminServer(s)
+	ctx := context.Background()
+
+	created, err := admin.CreateTask(ctx, &masterpb.CreateTaskRequest{TaskName: "a", TickerConfig: `{"username":"u"}`,
+		Schedule: &masterpb.ScheduleInfo{PushplusToken: "tok", NotifyTargets: []string{"bark://key@api.day.app"}}})
+	if err != nil {
+		t.Fatalf("CreateTask: %v", err)
+	}
+	check, err := admin.CheckSession(ctx, &masterpb.TaskIdRequest{TaskId: created.TaskId})
+	if err != nil || check.Kind != string(TaskKindSessionCheck) || check.TaskName != "a"+sessionCheckSuffix {
+		t.Fatalf("CheckSession: %+v %v", check, err)
+	}
+	if check.Schedule.GetPushplusToken() != "tok" {
+		t.Fatalf("check should keep the task's notify targets: %+v", check.Schedule)
+	}
+	if _, err := admin.CheckSession(ctx, &masterpb.TaskIdRequest{TaskId: created.TaskId}); status.Code(err) != codes.AlreadyExists {
+		t.Fatalf("unfinished check should block another one, got %v", err)
+	}
+	if _, err := admin.CheckSession(ctx, &masterpb.TaskIdRequest{TaskId: check.TaskId}); status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("checking a check task should fail, got %v", err)
+	}
+
+	// worker 上报检查没有通过时告警
+	s.tasksMux.Lock()
+	s.tasks[check.TaskId].Status = TaskStatusDoing
+	s.tasks[check.TaskId].AssignedTo = "w-1"
+	s.tasksMux.Unlock()
+	if _, err := s.ReportResult(ctx, &masterpb.TaskResultInfo{TaskId: check.TaskId, WorkerId: "w-1", Status: string(TaskStatusFailed), Message: "cookie 已失效"}); err != nil {
+		t.Fatalf("ReportResult: %v", err)
+	}
+	select {
+	case e := <-events:
+		if e.Kind != EventSessionInvalid || e.Subject != check.TaskId || !slices.Equal(e.Targets, []string{"bark://key@api.day.app", "pushplus://tok"}) {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no session_invalid event")
+	}
+	if _, err := admin.CheckSession(ctx, &masterpb.TaskIdRequest{TaskId: created.TaskId}); err != nil {
+		t.Fatalf("a finished check should not block a new one: %v", err)
+	}
+
+	// 抢票任务结束即全部结束，失败的检查不计入
+	s.tasksMux.Lock()
+	s.tasks[created.TaskId].Status = TaskStatusSucceeded
+	s.tasksMux.Unlock()
+	s.monitorTasks()
+	select {
+	case e := <-events:
+		if e.Kind != EventAllTasksDone || !strings.Contains(e.Detail, "1 个任务全部结束，其中失败 0 个") {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no all_tasks_done event")
+	}
+}
@@ -0,0 +1,123 @@
+package master
+
+import (
+	"biliTickerStorm/internal/common"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"net"
+	"time"
+)
+
+// App 组装 Server、gRPC 服务、配置目录热加载和运维 HTTP 接口
+type App struct {
+	cfg    *Config
+	Server *Server
+	grpc   *grpc.Server
+	admin  *grpc.Server // TicketAdmin 单独监听，不对 worker 开放
+	health *common.Health
+	ops    *common.OpsServer
+	stop   chan struct{}
+}
+
+// New 按 cfg 创建 master，opts 在配置之后应用，可以覆盖配置中的超时参数
+func New(cfg *Config, opts ...Option) (*App, error) {
+	store := NewMemoryStore()
+	if cfg.StorePath != "" {
+		fileStore, err := OpenFileStore(cfg.StorePath)
+		if err != nil {
+			return nil, fmt.Errorf("open store: %w", err)
+		}
+		store = fileStore
+	}
+	scheduler, err := NewScheduler(cfg.SchedulerPolicy)
+	if err != nil {
+		return nil, err
+	}
+	sink, err := cfg.eventSink()
+	if err != nil {
+		return nil, err
+	}
+	opts = append(append(cfg.options(), WithScheduler(scheduler), WithEventSink(sink)), opts...)
+	server := NewServer(store, opts...)
+
+	app := &App{
+		cfg:    cfg,
+		Server: server,
+		grpc:   grpc.NewServer(),
+		admin:  grpc.NewServer(grpc.UnaryInterceptor(AdminAuth(cfg.AdminToken))),
+		health: common.NewHealth(),
+		stop:   make(chan struct{}),
+	}
+	app.health.AddCheck("tasks", server.Ready)
+	masterpb.RegisterTicketMasterServer(app.grpc, server)
+	masterpb.RegisterTicketAdminServer(app.admin, NewAdminServer(server))
+	healthpb.RegisterHealthServer(app.grpc, app.health.GRPCServer())
+	return app, nil
+}
+
+// Start 加载任务目录并启动热加载和运维 HTTP，不监听 gRPC 端口
+func (a *App) Start() error {
+	if a.cfg.Configpath != "" {
+		if err := a.Server.LoadTasksFromDir(a.cfg.Configpath); err != nil {
+			return fmt.Errorf("read configs: %w", err)
+		}
+		if a.cfg.WatchInterval > 0 {
+			go a.Server.WatchConfigDir(a.cfg.Configpath, a.cfg.WatchInterval, a.cfg.ReloadRestartRunning)
+		}
+	} else {
+		a.Server.markTasksLoaded()
+	}
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewMetricsCollector(a.Server))
+	mux := common.NewOpsMux(registry)
+	a.health.Register(mux)
+	a.ops = common.ServeOps(a.cfg.HTTPAddr, mux)
+	go a.health.Run(5*time.Second, a.stop)
+	return nil
+}
+
+// Serve 在 lis 上提供 gRPC 服务，直到 Stop
+func (a *App) Serve(lis net.Listener) error {
+	return a.grpc.Serve(lis)
+}
+
+// ServeAdmin 在 lis 上提供 TicketAdmin 服务，直到 Stop
+func (a *App) ServeAdmin(lis net.Listener) error {
+	return a.admin.Serve(lis)
+}
+
+// ListenAndServe 监听配置中的 ListenAddr 和 AdminAddr
+func (a *App) ListenAndServe() error {
+	if a.cfg.AdminAddr != "" {
+		adminLis, err := net.Listen("tcp", a.cfg.AdminAddr)
+		if err != nil {
+			return err
+		}
+		log.Printf("admin listening at %s", a.cfg.AdminAddr)
+		go func() {
+			if err := a.ServeAdmin(adminLis); err != nil {
+				log.Errorf("admin serve: %v", err)
+			}
+		}()
+	}
+	lis, err := net.Listen("tcp", a.cfg.ListenAddr)
+	if err != nil {
+		return err
+	}
+	log.Printf("listening at %s", a.cfg.ListenAddr)
+	return a.Serve(lis)
+}
+
+func (a *App) Stop() {
+	close(a.stop)
+	a.admin.GracefulStop()
+	a.Server.CloseStreams()
+	a.grpc.GracefulStop()
+	if a.ops != nil {
+		a.ops.Shutdown()
+	}
+	a.Server.Stop()
+}
@@ -0,0 +1,169 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"context"
+	"sync"
+	"time"
+)
+
+// clockSamples 每个 worker 保留的测量次数
+const clockSamples = 8
+
+// clockSample 一次 ClockPing/ClockPong 往返的测量结果
+type clockSample struct {
+	offset time.Duration // worker 时钟减 master 时钟
+	rtt    time.Duration
+}
+
+// sampleFromPong 按 NTP 的方式计算偏差：t1 master 发出，t2 worker 收到，t3 worker 回复，t4 master 收到
+func sampleFromPong(pong *masterpb.ClockPong, recv time.Time) clockSample {
+	t1, t2, t3, t4 := pong.MasterSendNs, pong.WorkerRecvNs, pong.WorkerSendNs, recv.UnixNano()
+	return clockSample{
+		offset: time.Duration(((t2 - t1) + (t3 - t4)) / 2),
+		rtt:    time.Duration((t4 - t1) - (t3 - t2)),
+	}
+}
+
+// clockEstimator 保留最近几次测量，取往返时间最短的一次，它受排队延迟的影响最小
+type clockEstimator struct {
+	mu      sync.Mutex
+	samples []clockSample
+	seq     int64
+}
+
+func (e *clockEstimator) nextSeq() int64 {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.seq++
+	return e.seq
+}
+
+func (e *clockEstimator) add(sample clockSample) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if sample.rtt < 0 {
+		return // 时钟在测量期间被调整过
+	}
+	e.samples = append(e.samples, sample)
+	if len(e.samples) > clockSamples {
+		e.samples = e.samples[len(e.samples)-clockSamples:]
+	}
+}
+
+// best 返回往返时间最短的测量，还没有测量时 ok 为 false
+func (e *clockEstimator) best() (sample clockSample, ok bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for i, s := range e.samples {
+		if i == 0 || s.rtt < sample.rtt {
+			sample = s
+		}
+	}
+	return sample, len(e.samples) > 0
+}
+
+func (ws *workerSession) ping(now time.Time) error {
+	return ws.send(&masterpb.MasterMessage{Payload: &masterpb.MasterMessage_ClockPing{
+		ClockPing: &masterpb.ClockPing{Seq: ws.clock.nextSeq(), MasterSendNs: now.UnixNano()},
+	}})
+}
+
+func (ws *workerSession) start(taskID string) error {
+	return ws.send(&masterpb.MasterMessage{Payload: &masterpb.MasterMessage_Start{
+		Start: &masterpb.StartSignal{TaskId: taskID},
+	}})
+}
+
+// probeClock 连接建立后先连续测几次，之后按 clockProbeInterval 定期测量，直到连接断开
+func (s *Server) probeClock(session *workerSession) {
+	done := session.stream.Context().Done()
+	for i := 0; i < 3; i++ {
+		if err := session.ping(s.clock.Now()); err != nil {
+			return
+		}
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-done:
+			return
+		}
+	}
+	ticker := time.NewTicker(s.clockProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := session.ping(s.clock.Now()); err != nil {
+				log.Warningf("[Clock] Worker %s: %v", session.workerID, err)
+			}
+		case <-done:
+			return
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// clockOf 返回 worker 的时钟偏差和往返时间，没有长连接或还没测量时返回零值
+func (s *Server) clockOf(workerID string) clockSample {
+	session := s.getSession(workerID)
+	if session == nil {
+		return clockSample{}
+	}
+	sample, _ := session.clock.best()
+	return sample
+}
+
+// armStartSignal 在开抢时刻给任务当前所在的 worker 发送开始信号，提前半个往返时间发出
+func (s *Server) armStartSignal(taskID string, startAt time.Time, rtt time.Duration) {
+	go func() {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go func() {
+			select {
+			case <-s.stopChan:
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		// 定时器加最后几毫秒自旋，比 time.AfterFunc 准
+		if err := WaitUntil(ctx, s.clock, startAt.Add(-rtt/2)); err != nil {
+			return
+		}
+		s.tasksMux.RLock()
+		workerID := ""
+		if task, exists := s.tasks[taskID]; exists && task.Status == TaskStatusDoing {
+			workerID = task.AssignedTo
+		}
+		s.tasksMux.RUnlock()
+		session := s.getSession(workerID)
+		if session == nil {
+			log.Warningf("[Start] Task <%s> has no connected worker at T0, worker falls back to its local clock", taskID)
+			return
+		}
+		if err := session.start(taskID); err != nil {
+			log.Warningf("[Start] Task <%s> -> Worker %s: %v", taskID, workerID, err)
+		}
+	}()
+}
+
+// recordStart 把 worker 上报的第一个 prepare 时刻换算到 master 时钟，记录与开抢时间的偏差
+func (s *Server) recordStart(session *workerSession, report *masterpb.StartReport) error {
+	sample, _ := session.clock.best()
+	sentAt := time.Unix(0, report.SentAtNs).Add(-sample.offset)
+
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	task, exists := s.tasks[report.TaskId]
+	if !exists || task.Schedule.StartAt.IsZero() {
+		return nil
+	}
+	task.StartSkew = sentAt.Sub(task.Schedule.StartAt)
+	task.StartReported = true
+	s.persistTask(task)
+	startSkew.Observe(task.StartSkew.Abs().Seconds())
+	log.Infof("[Start] Task <%s> first prepare left %v from T0 (worker %s, offset %v, rtt %v, by signal %v)",
+		task.TaskName, task.StartSkew, session.workerID, sample.offset, sample.rtt, report.BySignal)
+	return nil
+}
@@ -0,0 +1,42 @@
+package master
+
+import (
+	masterpb "biliTickerStorm/internal/master/pb"
+	"testing"
+	"time"
+)
+
+func TestClockEstimator(t *testing.T) {
+	base := time.Unix(1700000000, 0)
+	// worker 时钟快 200ms，去程 10ms，回程 30ms，worker 处理 1ms
+	pong := func(out, back time.Duration) (*masterpb.ClockPong, time.Time) {
+		t1 := base
+		t2 := t1.Add(out + 200*time.Millisecond)
+		t3 := t2.Add(time.Millisecond)
+		t4 := t3.Add(-200*time.Millisecond + back)
+		return &masterpb.ClockPong{MasterSendNs: t1.UnixNano(), WorkerRecvNs: t2.UnixNano(), WorkerSendNs: t3.UnixNano()}, t4
+	}
+
+	sample := sampleFromPong(pong(10*time.Millisecond, 30*time.Millisecond))
+	if sample.rtt != 40*time.Millisecond || sample.offset != 190*time.Millisecond {
+		t.Fatalf("unexpected sample %+v", sample)
+	}
+
+	var e clockEstimator
+	if _, ok := e.best(); ok {
+		t.Fatal("empty estimator should not report a sample")
+	}
+	e.add(sample)
+	e.add(sampleFromPong(pong(2*time.Millisecond, 2*time.Millisecond)))
+	e.add(sampleFromPong(pong(50*time.Millisecond, 5*time.Millisecond)))
+	best, _ := e.best()
+	if best.rtt != 4*time.Millisecond || best.offset != 200*time.Millisecond {
+		t.Fatalf("best sample should have the shortest rtt, got %+v", best)
+	}
+	for i := 0; i < clockSamples; i++ {
+		e.add(sample)
+	}
+	if best, _ := e.best(); best != sample {
+		t.Fatalf("old samples should be dropped, got %+v", best)
+	}
+}
@@ -1,22 +1,183 @@
 package master
 
 import (
-	"github.com/caarlos0/env/v10"
+	"biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/notify"
+	"flag"
+	"fmt"
+	"net"
+	"time"
 )
 
-var Cfg = LoadConfig()
-
+// Config master 配置，可以来自 YAML 文件、环境变量或命令行参数，见 LoadConfig
 type Config struct {
-	Configpath string `env:"CONFIG_PATH"`
+	Configpath string `env:"CONFIG_PATH" yaml:"config_path"`
+	StorePath  string `env:"STORE_PATH" yaml:"store_path"` // 任务状态持久化目录，为空则只保存在内存
+	// 配置目录扫描间隔，0 表示只在启动时加载一次
+	WatchInterval time.Duration `env:"CONFIG_WATCH_INTERVAL" yaml:"watch_interval"`
+	// 正在执行的任务配置被修改时直接重启，否则需要 ConfirmReload
+	ReloadRestartRunning bool `env:"RELOAD_RESTART_RUNNING" yaml:"reload_restart_running"`
+	// 调度策略：fifo、priority、round-robin、least-risked
+	SchedulerPolicy string `env:"SCHEDULER_POLICY" yaml:"scheduler_policy"`
+	// gRPC 监听地址，为空则不监听（测试中用 Serve 传入 listener）
+	ListenAddr string `env:"LISTEN_ADDR" yaml:"listen_addr"`
+	// TicketAdmin 监听地址，默认只监听本机，为空则不提供管理接口
+	AdminAddr string `env:"ADMIN_ADDR" yaml:"admin_addr"`
+	// 管理接口的 token，请求需带 authorization: Bearer <token>，为空则不校验
+	AdminToken string `env:"ADMIN_TOKEN" yaml:"admin_token"`
+	// /metrics、/healthz、/readyz 监听地址，为空则不启动
+	HTTPAddr string `env:"HTTP_ADDR" yaml:"http_addr"`
+
+	HeartbeatTimeout time.Duration `env:"HEARTBEAT_TIMEOUT" yaml:"heartbeat_timeout"`
+	TaskTimeout      time.Duration `env:"TASK_TIMEOUT" yaml:"task_timeout"`
+	BanTimeout       time.Duration `env:"BAN_TIMEOUT" yaml:"ban_timeout"`       // 风控冷却时间
+	CheckInterval    time.Duration `env:"CHECK_INTERVAL" yaml:"check_interval"` // 心跳检查和任务巡检周期
+	MaxRetries       int           `env:"MAX_RETRIES" yaml:"max_retries"`
+	// 开抢时间以 master 的时钟为准，按这些 NTP 服务器校准，为空则使用本地时钟
+	NTPServers []string `env:"NTP_SERVERS" yaml:"ntp_servers"`
+	// 集群事件的通知目标，格式同 worker 的 NOTIFY_TARGETS，为空则只记录日志
+	EventTargets []string `env:"EVENT_NOTIFY_TARGETS" yaml:"event_notify_targets"`
+	// 告警规则，0 关闭对应规则
+	EventNoIdleBefore      time.Duration `env:"EVENT_NO_IDLE_BEFORE" yaml:"event_no_idle_before"`
+	EventNoIdleFor         time.Duration `env:"EVENT_NO_IDLE_FOR" yaml:"event_no_idle_for"`
+	EventReassignThreshold int           `env:"EVENT_REASSIGN_THRESHOLD" yaml:"event_reassign_threshold"`
+	EventDedupWindow       time.Duration `env:"EVENT_DEDUP_WINDOW" yaml:"event_dedup_window"`
+	EventRateLimit         int           `env:"EVENT_RATE_LIMIT" yaml:"event_rate_limit"` // 每分钟最多发送的事件数
+}
+
+func DefaultConfig() *Config {
+	rules := DefaultEventRules()
+	return &Config{
+		WatchInterval:    5 * time.Second,
+		SchedulerPolicy:  DefaultPolicy,
+		ListenAddr:       ":40052",
+		AdminAddr:        "127.0.0.1:40053",
+		HTTPAddr:         ":40080",
+		HeartbeatTimeout: 10 * time.Second,
+		TaskTimeout:      30 * time.Second,
+		BanTimeout:       5 * time.Minute,
+		CheckInterval:    5 * time.Second,
+		MaxRetries:       3,
+		NTPServers:       common.DefaultNTPServers,
+
+		EventNoIdleBefore:      rules.NoIdleBefore,
+		EventNoIdleFor:         rules.NoIdleFor,
+		EventReassignThreshold: rules.ReassignThreshold,
+		EventDedupWindow:       rules.DedupWindow,
+		EventRateLimit:         rules.RateLimit,
+	}
+}
+
+func bindFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.Configpath, "config-path", cfg.Configpath, "任务配置目录（CONFIG_PATH）")
+	fs.StringVar(&cfg.StorePath, "store-path", cfg.StorePath, "任务状态持久化目录（STORE_PATH）")
+	fs.DurationVar(&cfg.WatchInterval, "watch-interval", cfg.WatchInterval, "配置目录扫描间隔，0 关闭")
+	fs.BoolVar(&cfg.ReloadRestartRunning, "reload-restart-running", cfg.ReloadRestartRunning, "配置修改时直接重启正在执行的任务")
+	fs.StringVar(&cfg.SchedulerPolicy, "scheduler", cfg.SchedulerPolicy, "调度策略")
+	fs.StringVar(&cfg.ListenAddr, "listen", cfg.ListenAddr, "gRPC 监听地址")
+	fs.StringVar(&cfg.AdminAddr, "admin-addr", cfg.AdminAddr, "管理接口监听地址")
+	fs.StringVar(&cfg.AdminToken, "admin-token", cfg.AdminToken, "管理接口 token（ADMIN_TOKEN）")
+	fs.StringVar(&cfg.HTTPAddr, "http-addr", cfg.HTTPAddr, "运维 HTTP 监听地址")
+	fs.DurationVar(&cfg.HeartbeatTimeout, "heartbeat-timeout", cfg.HeartbeatTimeout, "worker 心跳超时")
+	fs.DurationVar(&cfg.TaskTimeout, "task-timeout", cfg.TaskTimeout, "任务更新超时")
+	fs.DurationVar(&cfg.BanTimeout, "ban-timeout", cfg.BanTimeout, "风控冷却时间")
+	fs.DurationVar(&cfg.CheckInterval, "check-interval", cfg.CheckInterval, "心跳检查和任务巡检周期")
+	fs.IntVar(&cfg.MaxRetries, "max-retries", cfg.MaxRetries, "任务进入死信前的重试次数")
+	fs.Func("ntp-servers", "NTP 服务器，逗号分隔", func(v string) error {
+		cfg.NTPServers = common.SplitList(v)
+		return nil
+	})
+	fs.Func("event-notify", "集群事件通知目标，逗号分隔", func(v string) error {
+		cfg.EventTargets = common.SplitList(v)
+		return nil
+	})
+	fs.DurationVar(&cfg.EventNoIdleBefore, "event-no-idle-before", cfg.EventNoIdleBefore, "开抢前多久没有空闲 worker 时告警")
+	fs.DurationVar(&cfg.EventNoIdleFor, "event-no-idle-for", cfg.EventNoIdleFor, "没有空闲 worker 持续多久才告警")
+	fs.IntVar(&cfg.EventReassignThreshold, "event-reassign-threshold", cfg.EventReassignThreshold, "任务重新分配超过多少次时告警")
+	fs.DurationVar(&cfg.EventDedupWindow, "event-dedup-window", cfg.EventDedupWindow, "同一事件的去重窗口")
+	fs.IntVar(&cfg.EventRateLimit, "event-rate-limit", cfg.EventRateLimit, "每分钟最多发送的事件数")
 }
 
-func LoadConfig() *Config {
-	cfg := &Config{}
-	if err := env.Parse(cfg); err != nil {
-		log.Fatalf("环境变量解析失败: %v", err)
+// LoadConfig 按 默认值 < YAML 文件（-config / CONFIG_FILE）< 环境变量 < 命令行参数 读取配置
+func LoadConfig(args []string) (*Config, error) {
+	cfg, err := common.LoadLayeredConfig("master", args, DefaultConfig, bindFlags)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.StorePath == "" {
+		log.Println("⚠️ 未设置 STORE_PATH，master 重启后任务状态将丢失")
+	}
+	if cfg.AdminAddr != "" && cfg.AdminToken == "" && !isLoopbackAddr(cfg.AdminAddr) {
+		log.Println("⚠️ 管理接口监听在非本机地址且未设置 ADMIN_TOKEN，任何能访问该端口的人都可以管理任务")
+	}
+	return cfg, nil
+}
+
+// Validate 检查命令行启动时的必需项
+func (c *Config) Validate() error {
+	if c.Configpath == "" {
+		return fmt.Errorf("❌ CONFIG_PATH 是必需的配置，当前未设置")
+	}
+	if _, err := NewScheduler(c.SchedulerPolicy); err != nil {
+		return err
 	}
-	if cfg.Configpath == "" {
-		log.Fatalf("❌ CONFIG_PATH 是必需的环境变量，当前未设置")
+	if _, err := notify.ParseAll(c.EventTargets); err != nil {
+		return fmt.Errorf("EVENT_NOTIFY_TARGETS: %w", err)
+	}
+	return nil
+}
+
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// options 把配置中的超时参数转换为 Server 的 Option
+func (c *Config) options() []Option {
+	return []Option{
+		WithHeartbeatTimeout(c.HeartbeatTimeout),
+		WithTaskTimeout(c.TaskTimeout),
+		WithBanTimeout(c.BanTimeout),
+		WithCheckInterval(c.CheckInterval),
+		WithMaxRetries(c.MaxRetries),
+		WithClock(c.clock()),
+		WithEventRules(EventRules{
+			NoIdleBefore:      c.EventNoIdleBefore,
+			NoIdleFor:         c.EventNoIdleFor,
+			ReassignThreshold: c.EventReassignThreshold,
+			DedupWindow:       c.EventDedupWindow,
+			RateLimit:         c.EventRateLimit,
+		}),
+	}
+}
+
+// eventSink 按 EventTargets 发送集群事件，没有目标时返回 nil
+func (c *Config) eventSink() (EventSink, error) {
+	if len(c.EventTargets) == 0 {
+		return nil, nil
+	}
+	targets, err := notify.ParseAll(c.EventTargets)
+	if err != nil {
+		return nil, fmt.Errorf("EVENT_NOTIFY_TARGETS: %w", err)
+	}
+	return NotifySink(notify.NewDispatcher(targets, notify.DefaultAttempts)), nil
+}
+
+func (c *Config) clock() common.Clock {
+	if len(c.NTPServers) == 0 {
+		return common.SystemClock()
 	}
-	return cfg
+	clock := common.NewNTPClock(c.NTPServers, common.DefaultNTPRefresh)
+	clock.Start()
+	return clock
 }
@@ -2,16 +2,195 @@ package master
 
 import (
 	"biliTickerStorm/internal/common"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"biliTickerStorm/internal/notify"
+	workerpb "biliTickerStorm/internal/worker/pb"
+	"encoding/json"
+	"fmt"
+	"slices"
 	"time"
 )
 
 type TaskInfo struct {
 	ID                  string
 	Status              common.TaskStatus
-	AssignedTo          string // Worker ID
-	TaskName            string // Ticket config file name
-	TickerConfigContent string // Ticket config file content
+	AssignedTo          string          // Worker ID
+	TaskName            string          // Ticket config file name
+	Kind                common.TaskKind // 任务类型，为空表示抢票
+	TickerConfigContent string          // Ticket config file content
+	Source              string          // 配置文件路径，管理接口创建的任务为空
+	ContentHash         string          // TickerConfigContent 的 sha256
+	PendingContent      string          // 执行中被修改的配置，ConfirmReload 后生效
+	PendingHash         string
+	SourceRemoved       bool   // 配置文件已被删除
+	Priority            int    // 越大越先分配，来自配置的 priority 字段
+	Account             string // 购票账号，来自配置的 username 字段，用于按账号轮转
+	ScheduleContent     string // <name>.schedule.json 的内容，或由管理接口的 schedule 字段生成
+	PendingSchedule     string
+	Schedule            TaskSchedule // 由 ScheduleContent 解析
 	CreatedAt           time.Time
 	UpdatedAt           time.Time
 	RetryCount          int
+	LastError           string        // 最近一次重新分配的原因
+	Result              *TaskResult   // worker 上报的最终结果
+	StartSkew           time.Duration // 第一个 prepare 请求相对开抢时间的偏差，master 时钟
+	StartReported       bool
+	PurchaseState       string // 执行中的 worker 最近上报的购买阶段
+}
+
+// taskMeta 配置中与调度有关的字段，其余字段由 worker 解析
+type taskMeta struct {
+	Priority int    `json:"priority"`
+	Username string `json:"username"`
+}
+
+// applyMeta 从配置内容刷新调度字段，解析失败时保持零值
+func (t *TaskInfo) applyMeta() {
+	var meta taskMeta
+	_ = json.Unmarshal([]byte(t.TickerConfigContent), &meta)
+	t.Priority = meta.Priority
+	t.Account = meta.Username
+	t.Schedule, _ = parseSchedule(t.ScheduleContent)
+}
+
+// TaskSchedule 任务自己的售票窗口、重试间隔和通知目标，零值字段由 worker 使用自己的环境变量
+type TaskSchedule struct {
+	StartAt       time.Time
+	EndAt         time.Time
+	Interval      int // 毫秒
+	PushplusToken string
+	Notify        []string // 通知目标 URL，见 notify.Parse
+}
+
+// scheduleFile 任务配置旁的 <name>.schedule.json，时间格式同 TICKET_TIME_START
+type scheduleFile struct {
+	TimeStart     string   `json:"time_start,omitempty"`
+	TimeEnd       string   `json:"time_end,omitempty"`
+	Interval      int      `json:"interval,omitempty"`
+	PushplusToken string   `json:"pushplus_token,omitempty"`
+	Notify        []string `json:"notify,omitempty"`
+}
+
+func parseSchedule(content string) (TaskSchedule, error) {
+	var schedule TaskSchedule
+	if content == "" {
+		return schedule, nil
+	}
+	var file scheduleFile
+	if err := json.Unmarshal([]byte(content), &file); err != nil {
+		return schedule, err
+	}
+	var err error
+	if file.TimeStart != "" {
+		if schedule.StartAt, err = common.ParseTicketTime(file.TimeStart); err != nil {
+			return schedule, fmt.Errorf("time_start: %w", err)
+		}
+	}
+	if file.TimeEnd != "" {
+		if schedule.EndAt, err = common.ParseTicketTime(file.TimeEnd); err != nil {
+			return schedule, fmt.Errorf("time_end: %w", err)
+		}
+	}
+	schedule.Interval = file.Interval
+	schedule.PushplusToken = file.PushplusToken
+	schedule.Notify = file.Notify
+	return schedule, schedule.validate()
+}
+
+func (s TaskSchedule) validate() error {
+	if s.Interval < 0 {
+		return fmt.Errorf("interval must not be negative")
+	}
+	if !s.StartAt.IsZero() && !s.EndAt.IsZero() && !s.EndAt.After(s.StartAt) {
+		return fmt.Errorf("time_end must be after time_start")
+	}
+	if _, err := notify.ParseAll(s.Notify); err != nil {
+		return fmt.Errorf("notify: %w", err)
+	}
+	return nil
+}
+
+// notifyTargets 任务自己的通知目标，PushplusToken 转换为 pushplus:// 目标
+func (s TaskSchedule) notifyTargets() []string {
+	targets := slices.Clone(s.Notify)
+	if s.PushplusToken != "" {
+		targets = append(targets, notify.PushPlusTarget(s.PushplusToken))
+	}
+	return targets
+}
+
+func (s TaskSchedule) isZero() bool {
+	return s.StartAt.IsZero() && s.EndAt.IsZero() && s.Interval == 0 && s.PushplusToken == "" && len(s.Notify) == 0
+}
+
+// content 编码为 schedule.json 的格式，零值返回空串
+func (s TaskSchedule) content() string {
+	if s.isZero() {
+		return ""
+	}
+	file := scheduleFile{Interval: s.Interval, PushplusToken: s.PushplusToken, Notify: s.Notify}
+	if !s.StartAt.IsZero() {
+		file.TimeStart = s.StartAt.Format(time.RFC3339Nano)
+	}
+	if !s.EndAt.IsZero() {
+		file.TimeEnd = s.EndAt.Format(time.RFC3339Nano)
+	}
+	data, _ := json.Marshal(file)
+	return string(data)
+}
+
+func fromUnixMilli(ms int64) time.Time {
+	if ms == 0 {
+		return time.Time{}
+	}
+	return time.UnixMilli(ms)
+}
+
+func (s TaskSchedule) toPB() *masterpb.ScheduleInfo {
+	if s.isZero() {
+		return nil
+	}
+	return &masterpb.ScheduleInfo{
+		StartAt:       unixMilli(s.StartAt),
+		EndAt:         unixMilli(s.EndAt),
+		IntervalMs:    int32(s.Interval),
+		PushplusToken: s.PushplusToken,
+		NotifyTargets: s.Notify,
+	}
+}
+
+func (s TaskSchedule) toWorkerPB() *workerpb.TaskSchedule {
+	info := s.toPB()
+	if info == nil {
+		return nil
+	}
+	return &workerpb.TaskSchedule{
+		StartAt:       info.StartAt,
+		EndAt:         info.EndAt,
+		IntervalMs:    info.IntervalMs,
+		PushplusToken: info.PushplusToken,
+		NotifyTargets: info.NotifyTargets,
+	}
+}
+
+func scheduleFromPB(info *masterpb.ScheduleInfo) TaskSchedule {
+	return TaskSchedule{
+		StartAt:       fromUnixMilli(info.GetStartAt()),
+		EndAt:         fromUnixMilli(info.GetEndAt()),
+		Interval:      int(info.GetIntervalMs()),
+		PushplusToken: info.GetPushplusToken(),
+		Notify:        info.GetNotifyTargets(),
+	}
+}
+
+// TaskResult worker 通过 ReportResult 上报的任务结果
+type TaskResult struct {
+	WorkerID   string
+	Errno      int
+	Message    string
+	OrderId    int64
+	PayMoney   int // 分
+	Attempts   int
+	Duration   time.Duration
+	ReportedAt time.Time
 }
@@ -0,0 +1,235 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/notify"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// EventKind 集群事件类型
+type EventKind string
+
+const (
+	EventWorkerDown        EventKind = "worker_down"         // worker 心跳超时
+	EventWorkerRisking     EventKind = "worker_risking"      // worker 触发风控
+	EventAllWorkersRisking EventKind = "all_workers_risking" // 所有在线 worker 都在风控冷却中
+	EventTaskTimeout       EventKind = "task_timeout"        // 执行中的任务没有心跳，重新分配
+	EventTaskReassigned    EventKind = "task_reassigned"     // 任务重新分配次数超过阈值
+	EventNoIdleWorkers     EventKind = "no_idle_workers"     // 临近开抢仍没有空闲 worker 接手
+	EventAllTasksDone      EventKind = "all_tasks_done"      // 所有任务都已结束
+	EventConfigRejected    EventKind = "config_rejected"     // 配置目录中的文件没有通过检查
+	EventSessionInvalid    EventKind = "session_invalid"     // 账号检查任务发现 cookie 失效或购票人不存在
+)
+
+var eventTitles = map[EventKind]string{
+	EventWorkerDown:        "worker 离线",
+	EventWorkerRisking:     "worker 触发风控",
+	EventAllWorkersRisking: "所有 worker 都在风控中",
+	EventTaskTimeout:       "任务心跳超时",
+	EventTaskReassigned:    "任务多次重新分配",
+	EventNoIdleWorkers:     "临近开抢没有空闲 worker",
+	EventAllTasksDone:      "所有任务已结束",
+	EventConfigRejected:    "任务配置没有通过检查",
+	EventSessionInvalid:    "账号检查没有通过",
+}
+
+// Event 一条集群事件。Subject 是事件涉及的 worker 或任务，同类型、同 Subject 的事件会去重
+type Event struct {
+	Kind    EventKind
+	Subject string
+	Detail  string
+	At      time.Time
+	// Suppressed 在这条事件之前因为限流没有发送的事件数
+	Suppressed int
+	// Targets 任务自己的通知目标，非空时只发送到这些目标，不发送到 EVENT_NOTIFY_TARGETS
+	Targets []string
+}
+
+func (e Event) message() notify.Message {
+	content := e.Detail
+	if e.Suppressed > 0 {
+		content += fmt.Sprintf("\n（另有 %d 条事件因限流未发送）", e.Suppressed)
+	}
+	return notify.Message{Title: "[biliTickerStorm] " + eventTitles[e.Kind], Content: content}
+}
+
+// EventSink 发送事件，在后台 goroutine 中调用
+type EventSink func(ctx context.Context, e Event) error
+
+// targetSink 把事件发送到事件自己的 Targets
+func targetSink(ctx context.Context, e Event) error {
+	targets, err := notify.ParseAll(e.Targets)
+	if err != nil {
+		return err
+	}
+	return notify.NewDispatcher(targets, notify.DefaultAttempts).Send(ctx, e.message())
+}
+
+// NotifySink 把事件发送到 notify 的所有目标
+func NotifySink(d *notify.Dispatcher) EventSink {
+	return func(ctx context.Context, e Event) error {
+		return d.Send(ctx, e.message())
+	}
+}
+
+// EventRules 告警规则和发送限制，阈值为 0 表示关闭对应规则
+type EventRules struct {
+	NoIdleBefore      time.Duration // 开抢前这段时间内任务仍在等待且没有空闲 worker 时告警
+	NoIdleFor         time.Duration // 没有空闲 worker 持续这么久才告警，忽略短暂的占满
+	ReassignThreshold int           // 任务重新分配超过该次数时告警
+	DedupWindow       time.Duration // 同一事件在窗口内只发送一次
+	RateLimit         int           // 每分钟最多发送的事件数
+}
+
+func DefaultEventRules() EventRules {
+	return EventRules{
+		NoIdleBefore:      10 * time.Minute,
+		NoIdleFor:         30 * time.Second,
+		ReassignThreshold: 2,
+		DedupWindow:       10 * time.Minute,
+		RateLimit:         10,
+	}
+}
+
+const (
+	eventQueueSize   = 64
+	eventSendTimeout = time.Minute
+	eventRateWindow  = time.Minute
+)
+
+// eventBus 去重、限流后把事件交给后台 goroutine 发送，emit 不阻塞，可以在持有 Server 锁时调用
+type eventBus struct {
+	rules      EventRules
+	sink       EventSink
+	targetSink EventSink // 发送带 Targets 的事件
+	queue      chan Event
+	now        func() time.Time
+
+	mu          sync.Mutex
+	lastSent    map[string]time.Time // Kind/Subject -> 上次发送时间
+	windowStart time.Time
+	windowCount int
+	suppressed  int
+}
+
+func newEventBus(rules EventRules, sink EventSink) *eventBus {
+	return &eventBus{
+		rules:      rules,
+		sink:       sink,
+		targetSink: targetSink,
+		queue:      make(chan Event, eventQueueSize),
+		now:        time.Now,
+		lastSent:   make(map[string]time.Time),
+	}
+}
+
+func (b *eventBus) emit(kind EventKind, subject, format string, args ...any) {
+	b.emitTo(nil, kind, subject, format, args...)
+}
+
+// emitTo 事件发送到任务自己的通知目标，targets 为空时同 emit
+func (b *eventBus) emitTo(targets []string, kind EventKind, subject, format string, args ...any) {
+	e := Event{Kind: kind, Subject: subject, Detail: fmt.Sprintf(format, args...), At: b.now(), Targets: targets}
+	if !b.admit(&e) {
+		return
+	}
+	log.Warningf("[Event] %s %s: %s", e.Kind, e.Subject, e.Detail)
+	if b.sink == nil && len(e.Targets) == 0 {
+		return
+	}
+	select {
+	case b.queue <- e:
+	default:
+		eventsTotal.WithLabelValues(string(kind), "dropped").Inc()
+		log.Errorf("[Event] 发送队列已满，丢弃 %s %s", e.Kind, e.Subject)
+	}
+}
+
+// admit 判断事件是否需要发送，通过时附上之前被限流的事件数
+func (b *eventBus) admit(e *Event) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	key := string(e.Kind) + "/" + e.Subject
+	if last, ok := b.lastSent[key]; ok && b.rules.DedupWindow > 0 && e.At.Sub(last) < b.rules.DedupWindow {
+		eventsTotal.WithLabelValues(string(e.Kind), "deduplicated").Inc()
+		return false
+	}
+	if e.At.Sub(b.windowStart) >= eventRateWindow {
+		b.windowStart, b.windowCount = e.At, 0
+	}
+	if b.rules.RateLimit > 0 && b.windowCount >= b.rules.RateLimit {
+		b.suppressed++
+		eventsTotal.WithLabelValues(string(e.Kind), "rate_limited").Inc()
+		return false
+	}
+	b.windowCount++
+	b.lastSent[key] = e.At
+	e.Suppressed, b.suppressed = b.suppressed, 0
+	return true
+}
+
+func (b *eventBus) run(stop <-chan struct{}) {
+	for {
+		select {
+		case e := <-b.queue:
+			send := b.sink
+			if len(e.Targets) > 0 {
+				send = b.targetSink
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), eventSendTimeout)
+			if err := send(ctx, e); err != nil {
+				eventsTotal.WithLabelValues(string(e.Kind), "failed").Inc()
+				log.Errorf("[Event] 发送 %s 失败: %v", e.Kind, err)
+			} else {
+				eventsTotal.WithLabelValues(string(e.Kind), "sent").Inc()
+			}
+			cancel()
+		case <-stop:
+			return
+		}
+	}
+}
+
+// checkStartReadiness 临近开抢仍在等待分配的任务，在持续 NoIdleFor 没有空闲 worker 时告警
+func (s *Server) checkStartReadiness() {
+	if s.events.rules.NoIdleBefore <= 0 {
+		return
+	}
+	// 先统计 worker 再读任务，不同时持有两把锁
+	idle := 0
+	s.workersMux.RLock()
+	online := len(s.workers)
+	for _, worker := range s.workers {
+		if worker.Status == Idle {
+			idle++
+		}
+	}
+	s.workersMux.RUnlock()
+
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	now := s.clock.Now()
+	if idle > 0 {
+		s.noIdleSince = time.Time{}
+		return
+	}
+	if s.noIdleSince.IsZero() {
+		s.noIdleSince = now
+	}
+	if now.Sub(s.noIdleSince) < s.events.rules.NoIdleFor {
+		return
+	}
+	for _, task := range s.tasks {
+		startAt := task.Schedule.StartAt
+		if task.Kind != TaskKindPurchase || task.Status != TaskStatusPending || startAt.IsZero() || !startAt.After(now) {
+			continue
+		}
+		if left := startAt.Sub(now); left <= s.events.rules.NoIdleBefore {
+			s.events.emit(EventNoIdleWorkers, task.ID, "任务 <%s> 将在 %s 后开抢，仍没有空闲 worker（在线 %d 个）",
+				task.TaskName, left.Round(time.Second), online)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"context"
+	"testing"
+	"time"
+)
+
+// drain 取出已经进入发送队列的事件
+func drain(b *eventBus) []Event {
+	var events []Event
+	for {
+		select {
+		case e := <-b.queue:
+			events = append(events, e)
+		default:
+			return events
+		}
+	}
+}
+
+func TestEventBusDedupAndRateLimit(t *testing.T) {
+	now := time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC)
+	b := newEventBus(EventRules{DedupWindow: 10 * time.Minute, RateLimit: 2}, func(context.Context, Event) error { return nil })
+	b.now = func() time.Time { return now }
+
+	// 同一个 worker 反复掉线只发送一次
+	b.emit(EventWorkerDown, "w-1", "down")
+	b.emit(EventWorkerDown, "w-1", "down")
+	b.emit(EventWorkerDown, "w-2", "down")
+	b.emit(EventWorkerDown, "w-3", "down") // 超过每分钟 2 条
+	if got := drain(b); len(got) != 2 || got[0].Subject != "w-1" || got[1].Subject != "w-2" {
+		t.Fatalf("unexpected events: %+v", got)
+	}
+
+	now = now.Add(time.Minute)
+	b.emit(EventWorkerDown, "w-1", "down") // 仍在去重窗口内
+	b.emit(EventWorkerDown, "w-3", "down")
+	got := drain(b)
+	if len(got) != 1 || got[0].Subject != "w-3" || got[0].Suppressed != 1 {
+		t.Fatalf("unexpected events: %+v", got)
+	}
+	if msg := got[0].message(); msg.Title == "" || msg.Content == "down" {
+		t.Fatalf("suppressed count should be reported: %+v", msg)
+	}
+
+	now = now.Add(10 * time.Minute)
+	b.emit(EventWorkerDown, "w-1", "down")
+	if got := drain(b); len(got) != 1 {
+		t.Fatalf("event should be sent again after the dedup window: %+v", got)
+	}
+}
+
+func TestServerEmitsEvents(t *testing.T) {
+	events := make(chan Event, 16)
+	sink := func(_ context.Context, e Event) error {
+		events <- e
+		return nil
+	}
+	s := NewServer(NewMemoryStore(), WithEventSink(sink), WithEventRules(EventRules{
+		NoIdleBefore: 10 * time.Minute, ReassignThreshold: 1, DedupWindow: time.Minute,
+	}))
+	t.Cleanup(s.Stop)
+	expect := func(kind EventKind, subject string) {
+		t.Helper()
+		select {
+		case e := <-events:
+			if e.Kind != kind || e.Subject != subject {
+				t.Fatalf("got %s %s, want %s %s", e.Kind, e.Subject, kind, subject)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatalf("no %s event", kind)
+		}
+	}
+
+	// 临近开抢的任务没有空闲 worker
+	task := s.CreateScheduledTask("a", "{}", TaskSchedule{StartAt: time.Now().Add(5 * time.Minute)})
+	s.checkStartReadiness()
+	expect(EventNoIdleWorkers, task.ID)
+
+	// 唯一的 worker 触发风控
+	s.tasksMux.Lock()
+	task.Status = TaskStatusDoing
+	task.AssignedTo = "w-1"
+	s.tasksMux.Unlock()
+	s.workersMux.Lock()
+	s.workers["w-1"] = &Worker{WorkerID: "w-1", Status: Working, TaskAssigned: task.ID, UpdateTime: time.Now()}
+	s.workersMux.Unlock()
+	if _, err := s.CancelTask(context.Background(), &masterpb.CancelTaskInfo{CancelTaskId: task.ID, WorkerId: "w-1", WorkStatus: int32(Risking)}); err != nil {
+		t.Fatalf("CancelTask: %v", err)
+	}
+	expect(EventWorkerRisking, "w-1")
+	s.checkWorkerHeartbeats()
+	expect(EventAllWorkersRisking, "")
+
+	// 第二次重新分配超过阈值
+	s.tasksMux.Lock()
+	s.clearAndPendingTask(task, "risk control")
+	s.tasksMux.Unlock()
+	expect(EventTaskReassigned, task.ID)
+}
+
+func TestNoIdleWorkersMustBeSustained(t *testing.T) {
+	events := make(chan Event, 16)
+	sink := func(_ context.Context, e Event) error {
+		events <- e
+		return nil
+	}
+	clock := NewFakeClock(time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC))
+	s := NewServer(NewMemoryStore(), WithClock(clock), WithEventSink(sink), WithEventRules(EventRules{
+		NoIdleBefore: 10 * time.Minute, NoIdleFor: 30 * time.Second, DedupWindow: time.Minute,
+	}))
+	t.Cleanup(s.Stop)
+	task := s.CreateScheduledTask("a", "{}", TaskSchedule{StartAt: clock.Now().Add(5 * time.Minute)})
+	noEvent := func() {
+		t.Helper()
+		select {
+		case e := <-events:
+			t.Fatalf("unexpected event: %+v", e)
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+
+	// 刚开始没有空闲 worker 不告警
+	s.checkStartReadiness()
+	noEvent()
+
+	// 中途出现空闲 worker，计时重新开始
+	clock.Advance(20 * time.Second)
+	s.workersMux.Lock()
+	s.workers["w-1"] = &Worker{WorkerID: "w-1", Status: Idle, UpdateTime: time.Now()}
+	s.workersMux.Unlock()
+	s.checkStartReadiness()
+	s.workersMux.Lock()
+	s.workers["w-1"].Status = Working
+	s.workersMux.Unlock()
+	s.checkStartReadiness()
+	clock.Advance(20 * time.Second)
+	s.checkStartReadiness()
+	noEvent()
+
+	clock.Advance(15 * time.Second)
+	s.checkStartReadiness()
+	select {
+	case e := <-events:
+		if e.Kind != EventNoIdleWorkers || e.Subject != task.ID {
+			t.Fatalf("unexpected event: %+v", e)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("no no_idle_workers event after the condition held")
+	}
+}
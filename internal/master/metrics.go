@@ -0,0 +1,79 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	assignFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bili_master_assign_failures_total",
+		Help: "下发任务失败次数，reason 为 unreachable（worker 不可达）或 rejected（worker 拒绝）",
+	}, []string{"reason"})
+	heartbeatInterval = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "bili_master_heartbeat_interval_seconds",
+		Help:    "同一个 worker 相邻两次心跳的间隔，超过 heartbeatTimeout 会被标记为 Down",
+		Buckets: []float64{0.5, 1, 2, 3, 4, 5, 7.5, 10, 15, 30},
+	})
+	eventsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bili_master_events_total",
+		Help: "集群事件数，result 为 sent、failed、deduplicated、rate_limited 或 dropped（队列已满）",
+	}, []string{"kind", "result"})
+	startSkew = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "bili_master_start_skew_seconds",
+		Help:    "定时任务第一个 prepare 请求与开抢时间的偏差（绝对值，master 时钟）",
+		Buckets: prometheus.ExponentialBuckets(0.001, 2, 12),
+	})
+
+	workersDesc = prometheus.NewDesc("bili_master_workers", "各状态的 worker 数量", []string{"status"}, nil)
+	tasksDesc   = prometheus.NewDesc("bili_master_tasks", "各状态的任务数量", []string{"status"}, nil)
+)
+
+var (
+	allWorkerStatuses = []WorkerStatus{Idle, Working, Risking, Down}
+	allTaskStatuses   = []TaskStatus{
+		TaskStatusPending, TaskStatusDoing, TaskStatusDone, TaskStatusPaused,
+		TaskStatusSucceeded, TaskStatusFailed, TaskStatusCancelled,
+	}
+)
+
+// stateCollector 抓取时直接统计 Server 的内存状态，不需要在每个状态变化处维护 gauge
+type stateCollector struct {
+	s *Server
+}
+
+// NewMetricsCollector 返回统计 worker/任务数量的 Collector，需注册到 prometheus
+func NewMetricsCollector(s *Server) prometheus.Collector {
+	return stateCollector{s: s}
+}
+
+func (c stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- workersDesc
+	ch <- tasksDesc
+}
+
+func (c stateCollector) Collect(ch chan<- prometheus.Metric) {
+	workers := make(map[WorkerStatus]int)
+	c.s.workersMux.RLock()
+	for _, worker := range c.s.workers {
+		workers[worker.Status]++
+	}
+	c.s.workersMux.RUnlock()
+	tasks := make(map[TaskStatus]int)
+	c.s.tasksMux.RLock()
+	for _, task := range c.s.tasks {
+		if task.Kind == TaskKindSessionCheck {
+			continue // 账号检查不计入任务数量
+		}
+		tasks[task.Status]++
+	}
+	c.s.tasksMux.RUnlock()
+
+	for _, status := range allWorkerStatuses {
+		ch <- prometheus.MustNewConstMetric(workersDesc, prometheus.GaugeValue, float64(workers[status]), status.String())
+	}
+	for _, status := range allTaskStatuses {
+		ch <- prometheus.MustNewConstMetric(tasksDesc, prometheus.GaugeValue, float64(tasks[status]), string(status))
+	}
+}
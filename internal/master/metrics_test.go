@@ -0,0 +1,37 @@
+package master
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestMetricsCollectorCountsByStatus(t *testing.T) {
+	s, _ := newTestServerWithTask(t)
+	s.CreateTask("b", "{}")
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewMetricsCollector(s))
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+	got := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			got[family.GetName()+"/"+metric.GetLabel()[0].GetValue()] = metric.GetGauge().GetValue()
+		}
+	}
+	want := map[string]float64{
+		"bili_master_workers/Working": 1,
+		"bili_master_workers/Idle":    0,
+		"bili_master_tasks/Doing":     1,
+		"bili_master_tasks/Pending":   1,
+		"bili_master_tasks/Failed":    0,
+	}
+	for key, value := range want {
+		if v, ok := got[key]; !ok || v != value {
+			t.Errorf("%s = %v (present=%v), want %v", key, v, ok, value)
+		}
+	}
+}
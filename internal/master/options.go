@@ -0,0 +1,59 @@
+package master
+
+import (
+	"biliTickerStorm/internal/common"
+	"time"
+)
+
+// Option 调整 Server 的超时和检查周期，默认值适合生产环境，测试中可以缩短
+type Option func(*Server)
+
+// WithHeartbeatTimeout worker 超过该时间没有心跳会被标记为 Down
+func WithHeartbeatTimeout(d time.Duration) Option {
+	return func(s *Server) { s.heartbeatTimeout = d }
+}
+
+// WithTaskTimeout Doing 的任务超过该时间没有更新会被重新分配
+func WithTaskTimeout(d time.Duration) Option {
+	return func(s *Server) { s.taskTimeout = d }
+}
+
+// WithBanTimeout worker 触发风控后的冷却时间
+func WithBanTimeout(d time.Duration) Option {
+	return func(s *Server) { s.banTimeout = d }
+}
+
+// WithCheckInterval 心跳检查和任务巡检的周期
+func WithCheckInterval(d time.Duration) Option {
+	return func(s *Server) { s.checkInterval = d }
+}
+
+// WithMaxRetries 任务进入死信前允许重新分配的次数
+func WithMaxRetries(n int) Option {
+	return func(s *Server) { s.maxRetries = n }
+}
+
+// WithClockProbeInterval 通过长连接测量 worker 时钟偏差的周期
+func WithClockProbeInterval(d time.Duration) Option {
+	return func(s *Server) { s.clockProbeInterval = d }
+}
+
+// WithClock 开抢时间使用的时钟，默认本地时钟
+func WithClock(clock common.Clock) Option {
+	return func(s *Server) { s.clock = clock }
+}
+
+// WithEventRules 集群事件的告警规则、去重窗口和限流
+func WithEventRules(rules EventRules) Option {
+	return func(s *Server) { s.eventRules = rules }
+}
+
+// WithEventSink 集群事件的发送目标，默认只记录日志
+func WithEventSink(sink EventSink) Option {
+	return func(s *Server) { s.eventSink = sink }
+}
+
+// WithScheduler 指定调度策略，默认 FIFO
+func WithScheduler(scheduler Scheduler) Option {
+	return func(s *Server) { s.scheduler = scheduler }
+}
@@ -28,6 +28,7 @@ type WorkerInfo struct {
 	WorkStatus    int32                  `protobuf:"varint,3,opt,name=workStatus,proto3" json:"workStatus,omitempty"`    // "Idle", "Working", "Risking"
 	TaskAssigned  string                 `protobuf:"bytes,4,opt,name=TaskAssigned,proto3" json:"TaskAssigned,omitempty"` //Task id
 	TaskStatus    string                 `protobuf:"bytes,5,opt,name=taskStatus,proto3" json:"taskStatus,omitempty"`
+	PurchaseState string                 `protobuf:"bytes,6,opt,name=purchase_state,json=purchaseState,proto3" json:"purchase_state,omitempty"` // 当前（或最近一个）任务的购买阶段：Waiting、Preparing、Captcha、Creating、Succeeded…
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -97,6 +98,13 @@ func (x *WorkerInfo) GetTaskStatus() string {
 	return ""
 }
 
+func (x *WorkerInfo) GetPurchaseState() string {
+	if x != nil {
+		return x.PurchaseState
+	}
+	return ""
+}
+
 type RegisterReply struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
@@ -261,74 +269,1875 @@ func (x *CancelReply) GetMessage() string {
 	return ""
 }
 
-var File_proto_master_proto protoreflect.FileDescriptor
+// 任务最终结果，worker 在 Buy 结束后上报
+type TaskResultInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	WorkerId      string                 `protobuf:"bytes,2,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"` // "Succeeded", "Failed", "Cancelled"
+	Errno         int32                  `protobuf:"varint,4,opt,name=errno,proto3" json:"errno,omitempty"`  // 最后一次 createV2 的 errno
+	Message       string                 `protobuf:"bytes,5,opt,name=message,proto3" json:"message,omitempty"`
+	OrderId       int64                  `protobuf:"varint,6,opt,name=order_id,json=orderId,proto3" json:"order_id,omitempty"`
+	PayMoney      int32                  `protobuf:"varint,7,opt,name=pay_money,json=payMoney,proto3" json:"pay_money,omitempty"` // 分
+	Attempts      int32                  `protobuf:"varint,8,opt,name=attempts,proto3" json:"attempts,omitempty"`                 // createV2 请求次数
+	DurationMs    int64                  `protobuf:"varint,9,opt,name=duration_ms,json=durationMs,proto3" json:"duration_ms,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
 
-const file_proto_master_proto_rawDesc = "" +
-	"\n" +
-	"\x12proto/master.proto\x12\x06worker\"\xa7\x01\n" +
-	"\n" +
-	"WorkerInfo\x12\x1b\n" +
-	"\tworker_id\x18\x01 \x01(\tR\bworkerId\x12\x18\n" +
-	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x1e\n" +
-	"\n" +
-	"workStatus\x18\x03 \x01(\x05R\n" +
-	"workStatus\x12\"\n" +
-	"\fTaskAssigned\x18\x04 \x01(\tR\fTaskAssigned\x12\x1e\n" +
-	"\n" +
-	"taskStatus\x18\x05 \x01(\tR\n" +
-	"taskStatus\"C\n" +
-	"\rRegisterReply\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\"p\n" +
-	"\x0eCancelTaskInfo\x12\"\n" +
-	"\fcancelTaskId\x18\x01 \x01(\tR\fcancelTaskId\x12\x1a\n" +
-	"\bworkerId\x18\x02 \x01(\tR\bworkerId\x12\x1e\n" +
-	"\n" +
-	"workStatus\x18\x03 \x01(\x05R\n" +
-	"workStatus\"A\n" +
-	"\vCancelReply\x12\x18\n" +
-	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2\x86\x01\n" +
-	"\fTicketMaster\x12;\n" +
-	"\x0eRegisterWorker\x12\x12.worker.WorkerInfo\x1a\x15.worker.RegisterReply\x129\n" +
-	"\n" +
-	"CancelTask\x12\x16.worker.CancelTaskInfo\x1a\x13.worker.CancelReplyB\x17Z\x15internal/master/pb;pbb\x06proto3"
+func (x *TaskResultInfo) Reset() {
+	*x = TaskResultInfo{}
+	mi := &file_proto_master_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
 
-var (
-	file_proto_master_proto_rawDescOnce sync.Once
-	file_proto_master_proto_rawDescData []byte
-)
+func (x *TaskResultInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
 
-func file_proto_master_proto_rawDescGZIP() []byte {
-	file_proto_master_proto_rawDescOnce.Do(func() {
-		file_proto_master_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_master_proto_rawDesc), len(file_proto_master_proto_rawDesc)))
-	})
-	return file_proto_master_proto_rawDescData
+func (*TaskResultInfo) ProtoMessage() {}
+
+func (x *TaskResultInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
 }
 
-var file_proto_master_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
-var file_proto_master_proto_goTypes = []any{
-	(*WorkerInfo)(nil),     // 0: worker.WorkerInfo
-	(*RegisterReply)(nil),  // 1: worker.RegisterReply
-	(*CancelTaskInfo)(nil), // 2: worker.CancelTaskInfo
-	(*CancelReply)(nil),    // 3: worker.CancelReply
+// Deprecated: Use TaskResultInfo.ProtoReflect.Descriptor instead.
+func (*TaskResultInfo) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{4}
 }
-var file_proto_master_proto_depIdxs = []int32{
-	0, // 0: worker.TicketMaster.RegisterWorker:input_type -> worker.WorkerInfo
-	2, // 1: worker.TicketMaster.CancelTask:input_type -> worker.CancelTaskInfo
-	1, // 2: worker.TicketMaster.RegisterWorker:output_type -> worker.RegisterReply
-	3, // 3: worker.TicketMaster.CancelTask:output_type -> worker.CancelReply
-	2, // [2:4] is the sub-list for method output_type
-	0, // [0:2] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+
+func (x *TaskResultInfo) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
 }
 
-func init() { file_proto_master_proto_init() }
-func file_proto_master_proto_init() {
-	if File_proto_master_proto != nil {
-		return
+func (x *TaskResultInfo) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+func (x *TaskResultInfo) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *TaskResultInfo) GetErrno() int32 {
+	if x != nil {
+		return x.Errno
+	}
+	return 0
+}
+
+func (x *TaskResultInfo) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TaskResultInfo) GetOrderId() int64 {
+	if x != nil {
+		return x.OrderId
+	}
+	return 0
+}
+
+func (x *TaskResultInfo) GetPayMoney() int32 {
+	if x != nil {
+		return x.PayMoney
+	}
+	return 0
+}
+
+func (x *TaskResultInfo) GetAttempts() int32 {
+	if x != nil {
+		return x.Attempts
+	}
+	return 0
+}
+
+func (x *TaskResultInfo) GetDurationMs() int64 {
+	if x != nil {
+		return x.DurationMs
+	}
+	return 0
+}
+
+type ResultReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ResultReply) Reset() {
+	*x = ResultReply{}
+	mi := &file_proto_master_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ResultReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ResultReply) ProtoMessage() {}
+
+func (x *ResultReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ResultReply.ProtoReflect.Descriptor instead.
+func (*ResultReply) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *ResultReply) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *ResultReply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type WorkerMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*WorkerMessage_Heartbeat
+	//	*WorkerMessage_CancelTask
+	//	*WorkerMessage_Result
+	//	*WorkerMessage_TaskAck
+	//	*WorkerMessage_ClockPong
+	//	*WorkerMessage_StartReport
+	Payload       isWorkerMessage_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkerMessage) Reset() {
+	*x = WorkerMessage{}
+	mi := &file_proto_master_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkerMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkerMessage) ProtoMessage() {}
+
+func (x *WorkerMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkerMessage.ProtoReflect.Descriptor instead.
+func (*WorkerMessage) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *WorkerMessage) GetPayload() isWorkerMessage_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *WorkerMessage) GetHeartbeat() *WorkerInfo {
+	if x != nil {
+		if x, ok := x.Payload.(*WorkerMessage_Heartbeat); ok {
+			return x.Heartbeat
+		}
+	}
+	return nil
+}
+
+func (x *WorkerMessage) GetCancelTask() *CancelTaskInfo {
+	if x != nil {
+		if x, ok := x.Payload.(*WorkerMessage_CancelTask); ok {
+			return x.CancelTask
+		}
+	}
+	return nil
+}
+
+func (x *WorkerMessage) GetResult() *TaskResultInfo {
+	if x != nil {
+		if x, ok := x.Payload.(*WorkerMessage_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+func (x *WorkerMessage) GetTaskAck() *TaskAck {
+	if x != nil {
+		if x, ok := x.Payload.(*WorkerMessage_TaskAck); ok {
+			return x.TaskAck
+		}
+	}
+	return nil
+}
+
+func (x *WorkerMessage) GetClockPong() *ClockPong {
+	if x != nil {
+		if x, ok := x.Payload.(*WorkerMessage_ClockPong); ok {
+			return x.ClockPong
+		}
+	}
+	return nil
+}
+
+func (x *WorkerMessage) GetStartReport() *StartReport {
+	if x != nil {
+		if x, ok := x.Payload.(*WorkerMessage_StartReport); ok {
+			return x.StartReport
+		}
+	}
+	return nil
+}
+
+type isWorkerMessage_Payload interface {
+	isWorkerMessage_Payload()
+}
+
+type WorkerMessage_Heartbeat struct {
+	Heartbeat *WorkerInfo `protobuf:"bytes,1,opt,name=heartbeat,proto3,oneof"`
+}
+
+type WorkerMessage_CancelTask struct {
+	CancelTask *CancelTaskInfo `protobuf:"bytes,2,opt,name=cancel_task,json=cancelTask,proto3,oneof"`
+}
+
+type WorkerMessage_Result struct {
+	Result *TaskResultInfo `protobuf:"bytes,3,opt,name=result,proto3,oneof"`
+}
+
+type WorkerMessage_TaskAck struct {
+	TaskAck *TaskAck `protobuf:"bytes,4,opt,name=task_ack,json=taskAck,proto3,oneof"`
+}
+
+type WorkerMessage_ClockPong struct {
+	ClockPong *ClockPong `protobuf:"bytes,5,opt,name=clock_pong,json=clockPong,proto3,oneof"`
+}
+
+type WorkerMessage_StartReport struct {
+	StartReport *StartReport `protobuf:"bytes,6,opt,name=start_report,json=startReport,proto3,oneof"`
+}
+
+func (*WorkerMessage_Heartbeat) isWorkerMessage_Payload() {}
+
+func (*WorkerMessage_CancelTask) isWorkerMessage_Payload() {}
+
+func (*WorkerMessage_Result) isWorkerMessage_Payload() {}
+
+func (*WorkerMessage_TaskAck) isWorkerMessage_Payload() {}
+
+func (*WorkerMessage_ClockPong) isWorkerMessage_Payload() {}
+
+func (*WorkerMessage_StartReport) isWorkerMessage_Payload() {}
+
+// worker 对 AssignTask 的应答
+type TaskAck struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	Success       bool                   `protobuf:"varint,2,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,3,opt,name=message,proto3" json:"message,omitempty"`
+	TaskError     bool                   `protobuf:"varint,4,opt,name=task_error,json=taskError,proto3" json:"task_error,omitempty"` // 拒绝原因在任务本身，计入重试次数
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskAck) Reset() {
+	*x = TaskAck{}
+	mi := &file_proto_master_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskAck) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskAck) ProtoMessage() {}
+
+func (x *TaskAck) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskAck.ProtoReflect.Descriptor instead.
+func (*TaskAck) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *TaskAck) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *TaskAck) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *TaskAck) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+func (x *TaskAck) GetTaskError() bool {
+	if x != nil {
+		return x.TaskError
+	}
+	return false
+}
+
+type MasterMessage struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Payload:
+	//
+	//	*MasterMessage_Assign
+	//	*MasterMessage_Stop
+	//	*MasterMessage_ClockPing
+	//	*MasterMessage_Start
+	Payload       isMasterMessage_Payload `protobuf_oneof:"payload"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MasterMessage) Reset() {
+	*x = MasterMessage{}
+	mi := &file_proto_master_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MasterMessage) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MasterMessage) ProtoMessage() {}
+
+func (x *MasterMessage) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MasterMessage.ProtoReflect.Descriptor instead.
+func (*MasterMessage) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *MasterMessage) GetPayload() isMasterMessage_Payload {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
+
+func (x *MasterMessage) GetAssign() *AssignTask {
+	if x != nil {
+		if x, ok := x.Payload.(*MasterMessage_Assign); ok {
+			return x.Assign
+		}
+	}
+	return nil
+}
+
+func (x *MasterMessage) GetStop() *StopTaskCommand {
+	if x != nil {
+		if x, ok := x.Payload.(*MasterMessage_Stop); ok {
+			return x.Stop
+		}
+	}
+	return nil
+}
+
+func (x *MasterMessage) GetClockPing() *ClockPing {
+	if x != nil {
+		if x, ok := x.Payload.(*MasterMessage_ClockPing); ok {
+			return x.ClockPing
+		}
+	}
+	return nil
+}
+
+func (x *MasterMessage) GetStart() *StartSignal {
+	if x != nil {
+		if x, ok := x.Payload.(*MasterMessage_Start); ok {
+			return x.Start
+		}
+	}
+	return nil
+}
+
+type isMasterMessage_Payload interface {
+	isMasterMessage_Payload()
+}
+
+type MasterMessage_Assign struct {
+	Assign *AssignTask `protobuf:"bytes,1,opt,name=assign,proto3,oneof"`
+}
+
+type MasterMessage_Stop struct {
+	Stop *StopTaskCommand `protobuf:"bytes,2,opt,name=stop,proto3,oneof"`
+}
+
+type MasterMessage_ClockPing struct {
+	ClockPing *ClockPing `protobuf:"bytes,3,opt,name=clock_ping,json=clockPing,proto3,oneof"`
+}
+
+type MasterMessage_Start struct {
+	Start *StartSignal `protobuf:"bytes,4,opt,name=start,proto3,oneof"`
+}
+
+func (*MasterMessage_Assign) isMasterMessage_Payload() {}
+
+func (*MasterMessage_Stop) isMasterMessage_Payload() {}
+
+func (*MasterMessage_ClockPing) isMasterMessage_Payload() {}
+
+func (*MasterMessage_Start) isMasterMessage_Payload() {}
+
+// master 通过长连接测量 worker 的时钟偏差，四个时间戳的用法与 NTP 相同
+type ClockPing struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           int64                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	MasterSendNs  int64                  `protobuf:"varint,2,opt,name=master_send_ns,json=masterSendNs,proto3" json:"master_send_ns,omitempty"` // unix 纳秒，master 时钟
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClockPing) Reset() {
+	*x = ClockPing{}
+	mi := &file_proto_master_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClockPing) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClockPing) ProtoMessage() {}
+
+func (x *ClockPing) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClockPing.ProtoReflect.Descriptor instead.
+func (*ClockPing) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *ClockPing) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ClockPing) GetMasterSendNs() int64 {
+	if x != nil {
+		return x.MasterSendNs
+	}
+	return 0
+}
+
+type ClockPong struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Seq           int64                  `protobuf:"varint,1,opt,name=seq,proto3" json:"seq,omitempty"`
+	MasterSendNs  int64                  `protobuf:"varint,2,opt,name=master_send_ns,json=masterSendNs,proto3" json:"master_send_ns,omitempty"` // 原样带回
+	WorkerRecvNs  int64                  `protobuf:"varint,3,opt,name=worker_recv_ns,json=workerRecvNs,proto3" json:"worker_recv_ns,omitempty"` // unix 纳秒，worker 时钟
+	WorkerSendNs  int64                  `protobuf:"varint,4,opt,name=worker_send_ns,json=workerSendNs,proto3" json:"worker_send_ns,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ClockPong) Reset() {
+	*x = ClockPong{}
+	mi := &file_proto_master_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ClockPong) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ClockPong) ProtoMessage() {}
+
+func (x *ClockPong) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ClockPong.ProtoReflect.Descriptor instead.
+func (*ClockPong) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{10}
+}
+
+func (x *ClockPong) GetSeq() int64 {
+	if x != nil {
+		return x.Seq
+	}
+	return 0
+}
+
+func (x *ClockPong) GetMasterSendNs() int64 {
+	if x != nil {
+		return x.MasterSendNs
+	}
+	return 0
+}
+
+func (x *ClockPong) GetWorkerRecvNs() int64 {
+	if x != nil {
+		return x.WorkerRecvNs
+	}
+	return 0
+}
+
+func (x *ClockPong) GetWorkerSendNs() int64 {
+	if x != nil {
+		return x.WorkerSendNs
+	}
+	return 0
+}
+
+// master 在开抢时刻下发的开始信号
+type StartSignal struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartSignal) Reset() {
+	*x = StartSignal{}
+	mi := &file_proto_master_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartSignal) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartSignal) ProtoMessage() {}
+
+func (x *StartSignal) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartSignal.ProtoReflect.Descriptor instead.
+func (*StartSignal) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *StartSignal) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+// worker 发出第一个 prepare 请求的时刻，master 据此计算与开抢时间的偏差
+type StartReport struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	SentAtNs      int64                  `protobuf:"varint,2,opt,name=sent_at_ns,json=sentAtNs,proto3" json:"sent_at_ns,omitempty"` // unix 纳秒，worker 时钟
+	BySignal      bool                   `protobuf:"varint,3,opt,name=by_signal,json=bySignal,proto3" json:"by_signal,omitempty"`   // false 表示没有等到开始信号，按本地时钟兜底
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StartReport) Reset() {
+	*x = StartReport{}
+	mi := &file_proto_master_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StartReport) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StartReport) ProtoMessage() {}
+
+func (x *StartReport) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StartReport.ProtoReflect.Descriptor instead.
+func (*StartReport) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *StartReport) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *StartReport) GetSentAtNs() int64 {
+	if x != nil {
+		return x.SentAtNs
+	}
+	return 0
+}
+
+func (x *StartReport) GetBySignal() bool {
+	if x != nil {
+		return x.BySignal
+	}
+	return false
+}
+
+type AssignTask struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	TicketsInfo   string                 `protobuf:"bytes,2,opt,name=tickets_info,json=ticketsInfo,proto3" json:"tickets_info,omitempty"`
+	Schedule      *ScheduleInfo          `protobuf:"bytes,3,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	SyncStart     bool                   `protobuf:"varint,4,opt,name=sync_start,json=syncStart,proto3" json:"sync_start,omitempty"`               // 收到 StartSignal 后才开始，开抢时间只作为兜底
+	ClockOffsetNs int64                  `protobuf:"varint,5,opt,name=clock_offset_ns,json=clockOffsetNs,proto3" json:"clock_offset_ns,omitempty"` // master 测得的 worker 时钟偏差（worker - master）
+	TaskName      string                 `protobuf:"bytes,6,opt,name=task_name,json=taskName,proto3" json:"task_name,omitempty"`                   // 配置文件名，用于通知模板
+	Kind          string                 `protobuf:"bytes,7,opt,name=kind,proto3" json:"kind,omitempty"`                                           // 任务类型，为空表示抢票，session_check 为开抢前的账号检查
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AssignTask) Reset() {
+	*x = AssignTask{}
+	mi := &file_proto_master_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AssignTask) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AssignTask) ProtoMessage() {}
+
+func (x *AssignTask) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AssignTask.ProtoReflect.Descriptor instead.
+func (*AssignTask) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *AssignTask) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *AssignTask) GetTicketsInfo() string {
+	if x != nil {
+		return x.TicketsInfo
+	}
+	return ""
+}
+
+func (x *AssignTask) GetSchedule() *ScheduleInfo {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+func (x *AssignTask) GetSyncStart() bool {
+	if x != nil {
+		return x.SyncStart
+	}
+	return false
+}
+
+func (x *AssignTask) GetClockOffsetNs() int64 {
+	if x != nil {
+		return x.ClockOffsetNs
+	}
+	return 0
+}
+
+func (x *AssignTask) GetTaskName() string {
+	if x != nil {
+		return x.TaskName
+	}
+	return ""
+}
+
+func (x *AssignTask) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+// 任务自己的售票窗口、重试间隔和通知目标，字段为 0 或空时使用 worker 的环境变量
+type ScheduleInfo struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartAt       int64                  `protobuf:"varint,1,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`          // unix 毫秒，开抢时间
+	EndAt         int64                  `protobuf:"varint,2,opt,name=end_at,json=endAt,proto3" json:"end_at,omitempty"`                // unix 毫秒，售票窗口结束后停止抢票
+	IntervalMs    int32                  `protobuf:"varint,3,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"` // createV2 重试间隔
+	PushplusToken string                 `protobuf:"bytes,4,opt,name=pushplus_token,json=pushplusToken,proto3" json:"pushplus_token,omitempty"`
+	NotifyTargets []string               `protobuf:"bytes,5,rep,name=notify_targets,json=notifyTargets,proto3" json:"notify_targets,omitempty"` // 通知目标 URL，见 internal/notify
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ScheduleInfo) Reset() {
+	*x = ScheduleInfo{}
+	mi := &file_proto_master_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ScheduleInfo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ScheduleInfo) ProtoMessage() {}
+
+func (x *ScheduleInfo) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ScheduleInfo.ProtoReflect.Descriptor instead.
+func (*ScheduleInfo) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ScheduleInfo) GetStartAt() int64 {
+	if x != nil {
+		return x.StartAt
+	}
+	return 0
+}
+
+func (x *ScheduleInfo) GetEndAt() int64 {
+	if x != nil {
+		return x.EndAt
+	}
+	return 0
+}
+
+func (x *ScheduleInfo) GetIntervalMs() int32 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+func (x *ScheduleInfo) GetPushplusToken() string {
+	if x != nil {
+		return x.PushplusToken
+	}
+	return ""
+}
+
+func (x *ScheduleInfo) GetNotifyTargets() []string {
+	if x != nil {
+		return x.NotifyTargets
+	}
+	return nil
+}
+
+type StopTaskCommand struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopTaskCommand) Reset() {
+	*x = StopTaskCommand{}
+	mi := &file_proto_master_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopTaskCommand) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopTaskCommand) ProtoMessage() {}
+
+func (x *StopTaskCommand) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopTaskCommand.ProtoReflect.Descriptor instead.
+func (*StopTaskCommand) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *StopTaskCommand) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *StopTaskCommand) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
+type CreateTaskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskName      string                 `protobuf:"bytes,1,opt,name=task_name,json=taskName,proto3" json:"task_name,omitempty"`
+	TickerConfig  string                 `protobuf:"bytes,2,opt,name=ticker_config,json=tickerConfig,proto3" json:"ticker_config,omitempty"` // BiliTickerBuyConfig JSON
+	Schedule      *ScheduleInfo          `protobuf:"bytes,3,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTaskRequest) Reset() {
+	*x = CreateTaskRequest{}
+	mi := &file_proto_master_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTaskRequest) ProtoMessage() {}
+
+func (x *CreateTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTaskRequest.ProtoReflect.Descriptor instead.
+func (*CreateTaskRequest) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *CreateTaskRequest) GetTaskName() string {
+	if x != nil {
+		return x.TaskName
+	}
+	return ""
+}
+
+func (x *CreateTaskRequest) GetTickerConfig() string {
+	if x != nil {
+		return x.TickerConfig
+	}
+	return ""
+}
+
+func (x *CreateTaskRequest) GetSchedule() *ScheduleInfo {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+type TaskIdRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskIdRequest) Reset() {
+	*x = TaskIdRequest{}
+	mi := &file_proto_master_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskIdRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskIdRequest) ProtoMessage() {}
+
+func (x *TaskIdRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskIdRequest.ProtoReflect.Descriptor instead.
+func (*TaskIdRequest) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *TaskIdRequest) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+type ListTasksRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Status        string                 `protobuf:"bytes,1,opt,name=status,proto3" json:"status,omitempty"` // 为空返回全部
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTasksRequest) Reset() {
+	*x = ListTasksRequest{}
+	mi := &file_proto_master_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTasksRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTasksRequest) ProtoMessage() {}
+
+func (x *ListTasksRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTasksRequest.ProtoReflect.Descriptor instead.
+func (*ListTasksRequest) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *ListTasksRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type TaskDetail struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	TaskName      string                 `protobuf:"bytes,2,opt,name=task_name,json=taskName,proto3" json:"task_name,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	AssignedTo    string                 `protobuf:"bytes,4,opt,name=assigned_to,json=assignedTo,proto3" json:"assigned_to,omitempty"`
+	CreatedAt     int64                  `protobuf:"varint,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"` // unix 毫秒
+	UpdatedAt     int64                  `protobuf:"varint,6,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"` // unix 毫秒
+	RetryCount    int32                  `protobuf:"varint,7,opt,name=retry_count,json=retryCount,proto3" json:"retry_count,omitempty"`
+	TickerConfig  string                 `protobuf:"bytes,8,opt,name=ticker_config,json=tickerConfig,proto3" json:"ticker_config,omitempty"`      // 仅 GetTask/CreateTask 返回
+	Result        *TaskResultInfo        `protobuf:"bytes,9,opt,name=result,proto3" json:"result,omitempty"`                                      // 任务结束后 worker 上报的结果
+	LastError     string                 `protobuf:"bytes,10,opt,name=last_error,json=lastError,proto3" json:"last_error,omitempty"`              // 最近一次重新分配的原因
+	Source        string                 `protobuf:"bytes,11,opt,name=source,proto3" json:"source,omitempty"`                                     // 配置文件路径
+	ReloadPending bool                   `protobuf:"varint,12,opt,name=reload_pending,json=reloadPending,proto3" json:"reload_pending,omitempty"` // 配置文件已修改，等待 ConfirmReload
+	Schedule      *ScheduleInfo          `protobuf:"bytes,13,opt,name=schedule,proto3" json:"schedule,omitempty"`
+	StartSkewUs   int64                  `protobuf:"varint,14,opt,name=start_skew_us,json=startSkewUs,proto3" json:"start_skew_us,omitempty"` // 第一个 prepare 请求相对开抢时间的偏差，master 时钟
+	StartReported bool                   `protobuf:"varint,15,opt,name=start_reported,json=startReported,proto3" json:"start_reported,omitempty"`
+	PurchaseState string                 `protobuf:"bytes,16,opt,name=purchase_state,json=purchaseState,proto3" json:"purchase_state,omitempty"` // 执行中的 worker 最近上报的购买阶段
+	Kind          string                 `protobuf:"bytes,17,opt,name=kind,proto3" json:"kind,omitempty"`                                        // 任务类型，为空表示抢票
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskDetail) Reset() {
+	*x = TaskDetail{}
+	mi := &file_proto_master_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskDetail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskDetail) ProtoMessage() {}
+
+func (x *TaskDetail) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskDetail.ProtoReflect.Descriptor instead.
+func (*TaskDetail) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *TaskDetail) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *TaskDetail) GetTaskName() string {
+	if x != nil {
+		return x.TaskName
+	}
+	return ""
+}
+
+func (x *TaskDetail) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *TaskDetail) GetAssignedTo() string {
+	if x != nil {
+		return x.AssignedTo
+	}
+	return ""
+}
+
+func (x *TaskDetail) GetCreatedAt() int64 {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return 0
+}
+
+func (x *TaskDetail) GetUpdatedAt() int64 {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return 0
+}
+
+func (x *TaskDetail) GetRetryCount() int32 {
+	if x != nil {
+		return x.RetryCount
+	}
+	return 0
+}
+
+func (x *TaskDetail) GetTickerConfig() string {
+	if x != nil {
+		return x.TickerConfig
+	}
+	return ""
+}
+
+func (x *TaskDetail) GetResult() *TaskResultInfo {
+	if x != nil {
+		return x.Result
+	}
+	return nil
+}
+
+func (x *TaskDetail) GetLastError() string {
+	if x != nil {
+		return x.LastError
+	}
+	return ""
+}
+
+func (x *TaskDetail) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *TaskDetail) GetReloadPending() bool {
+	if x != nil {
+		return x.ReloadPending
+	}
+	return false
+}
+
+func (x *TaskDetail) GetSchedule() *ScheduleInfo {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+func (x *TaskDetail) GetStartSkewUs() int64 {
+	if x != nil {
+		return x.StartSkewUs
+	}
+	return 0
+}
+
+func (x *TaskDetail) GetStartReported() bool {
+	if x != nil {
+		return x.StartReported
+	}
+	return false
+}
+
+func (x *TaskDetail) GetPurchaseState() string {
+	if x != nil {
+		return x.PurchaseState
+	}
+	return ""
+}
+
+func (x *TaskDetail) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+type ListTasksReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Tasks         []*TaskDetail          `protobuf:"bytes,1,rep,name=tasks,proto3" json:"tasks,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTasksReply) Reset() {
+	*x = ListTasksReply{}
+	mi := &file_proto_master_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTasksReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTasksReply) ProtoMessage() {}
+
+func (x *ListTasksReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTasksReply.ProtoReflect.Descriptor instead.
+func (*ListTasksReply) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *ListTasksReply) GetTasks() []*TaskDetail {
+	if x != nil {
+		return x.Tasks
+	}
+	return nil
+}
+
+type AdminReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
+	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *AdminReply) Reset() {
+	*x = AdminReply{}
+	mi := &file_proto_master_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *AdminReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*AdminReply) ProtoMessage() {}
+
+func (x *AdminReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use AdminReply.ProtoReflect.Descriptor instead.
+func (*AdminReply) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *AdminReply) GetSuccess() bool {
+	if x != nil {
+		return x.Success
+	}
+	return false
+}
+
+func (x *AdminReply) GetMessage() string {
+	if x != nil {
+		return x.Message
+	}
+	return ""
+}
+
+type ListDeadLettersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListDeadLettersRequest) Reset() {
+	*x = ListDeadLettersRequest{}
+	mi := &file_proto_master_proto_msgTypes[22]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDeadLettersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDeadLettersRequest) ProtoMessage() {}
+
+func (x *ListDeadLettersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[22]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDeadLettersRequest.ProtoReflect.Descriptor instead.
+func (*ListDeadLettersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{22}
+}
+
+type ListWorkersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkersRequest) Reset() {
+	*x = ListWorkersRequest{}
+	mi := &file_proto_master_proto_msgTypes[23]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkersRequest) ProtoMessage() {}
+
+func (x *ListWorkersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[23]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkersRequest.ProtoReflect.Descriptor instead.
+func (*ListWorkersRequest) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{23}
+}
+
+type WorkerDetail struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	WorkerId      string                 `protobuf:"bytes,1,opt,name=worker_id,json=workerId,proto3" json:"worker_id,omitempty"`
+	Address       string                 `protobuf:"bytes,2,opt,name=address,proto3" json:"address,omitempty"`
+	Status        string                 `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	TaskAssigned  string                 `protobuf:"bytes,4,opt,name=task_assigned,json=taskAssigned,proto3" json:"task_assigned,omitempty"`
+	UpdateTime    int64                  `protobuf:"varint,5,opt,name=update_time,json=updateTime,proto3" json:"update_time,omitempty"`            // unix 毫秒
+	BanTime       int64                  `protobuf:"varint,6,opt,name=ban_time,json=banTime,proto3" json:"ban_time,omitempty"`                     // unix 毫秒
+	ClockOffsetUs int64                  `protobuf:"varint,7,opt,name=clock_offset_us,json=clockOffsetUs,proto3" json:"clock_offset_us,omitempty"` // worker 时钟减 master 时钟，未测量时为 0
+	ClockRttUs    int64                  `protobuf:"varint,8,opt,name=clock_rtt_us,json=clockRttUs,proto3" json:"clock_rtt_us,omitempty"`
+	PurchaseState string                 `protobuf:"bytes,9,opt,name=purchase_state,json=purchaseState,proto3" json:"purchase_state,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WorkerDetail) Reset() {
+	*x = WorkerDetail{}
+	mi := &file_proto_master_proto_msgTypes[24]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WorkerDetail) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WorkerDetail) ProtoMessage() {}
+
+func (x *WorkerDetail) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[24]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WorkerDetail.ProtoReflect.Descriptor instead.
+func (*WorkerDetail) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{24}
+}
+
+func (x *WorkerDetail) GetWorkerId() string {
+	if x != nil {
+		return x.WorkerId
+	}
+	return ""
+}
+
+func (x *WorkerDetail) GetAddress() string {
+	if x != nil {
+		return x.Address
+	}
+	return ""
+}
+
+func (x *WorkerDetail) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *WorkerDetail) GetTaskAssigned() string {
+	if x != nil {
+		return x.TaskAssigned
+	}
+	return ""
+}
+
+func (x *WorkerDetail) GetUpdateTime() int64 {
+	if x != nil {
+		return x.UpdateTime
+	}
+	return 0
+}
+
+func (x *WorkerDetail) GetBanTime() int64 {
+	if x != nil {
+		return x.BanTime
+	}
+	return 0
+}
+
+func (x *WorkerDetail) GetClockOffsetUs() int64 {
+	if x != nil {
+		return x.ClockOffsetUs
+	}
+	return 0
+}
+
+func (x *WorkerDetail) GetClockRttUs() int64 {
+	if x != nil {
+		return x.ClockRttUs
+	}
+	return 0
+}
+
+func (x *WorkerDetail) GetPurchaseState() string {
+	if x != nil {
+		return x.PurchaseState
+	}
+	return ""
+}
+
+type ListWorkersReply struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Workers       []*WorkerDetail        `protobuf:"bytes,1,rep,name=workers,proto3" json:"workers,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListWorkersReply) Reset() {
+	*x = ListWorkersReply{}
+	mi := &file_proto_master_proto_msgTypes[25]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListWorkersReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListWorkersReply) ProtoMessage() {}
+
+func (x *ListWorkersReply) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_master_proto_msgTypes[25]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListWorkersReply.ProtoReflect.Descriptor instead.
+func (*ListWorkersReply) Descriptor() ([]byte, []int) {
+	return file_proto_master_proto_rawDescGZIP(), []int{25}
+}
+
+func (x *ListWorkersReply) GetWorkers() []*WorkerDetail {
+	if x != nil {
+		return x.Workers
+	}
+	return nil
+}
+
+var File_proto_master_proto protoreflect.FileDescriptor
+
+const file_proto_master_proto_rawDesc = "" +
+	"\n" +
+	"\x12proto/master.proto\x12\x06worker\"\xce\x01\n" +
+	"\n" +
+	"WorkerInfo\x12\x1b\n" +
+	"\tworker_id\x18\x01 \x01(\tR\bworkerId\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x1e\n" +
+	"\n" +
+	"workStatus\x18\x03 \x01(\x05R\n" +
+	"workStatus\x12\"\n" +
+	"\fTaskAssigned\x18\x04 \x01(\tR\fTaskAssigned\x12\x1e\n" +
+	"\n" +
+	"taskStatus\x18\x05 \x01(\tR\n" +
+	"taskStatus\x12%\n" +
+	"\x0epurchase_state\x18\x06 \x01(\tR\rpurchaseState\"C\n" +
+	"\rRegisterReply\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"p\n" +
+	"\x0eCancelTaskInfo\x12\"\n" +
+	"\fcancelTaskId\x18\x01 \x01(\tR\fcancelTaskId\x12\x1a\n" +
+	"\bworkerId\x18\x02 \x01(\tR\bworkerId\x12\x1e\n" +
+	"\n" +
+	"workStatus\x18\x03 \x01(\x05R\n" +
+	"workStatus\"A\n" +
+	"\vCancelReply\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x83\x02\n" +
+	"\x0eTaskResultInfo\x12\x17\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\x12\x1b\n" +
+	"\tworker_id\x18\x02 \x01(\tR\bworkerId\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x14\n" +
+	"\x05errno\x18\x04 \x01(\x05R\x05errno\x12\x18\n" +
+	"\amessage\x18\x05 \x01(\tR\amessage\x12\x19\n" +
+	"\border_id\x18\x06 \x01(\x03R\aorderId\x12\x1b\n" +
+	"\tpay_money\x18\a \x01(\x05R\bpayMoney\x12\x1a\n" +
+	"\battempts\x18\b \x01(\x05R\battempts\x12\x1f\n" +
+	"\vduration_ms\x18\t \x01(\x03R\n" +
+	"durationMs\"A\n" +
+	"\vResultReply\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\xd7\x02\n" +
+	"\rWorkerMessage\x122\n" +
+	"\theartbeat\x18\x01 \x01(\v2\x12.worker.WorkerInfoH\x00R\theartbeat\x129\n" +
+	"\vcancel_task\x18\x02 \x01(\v2\x16.worker.CancelTaskInfoH\x00R\n" +
+	"cancelTask\x120\n" +
+	"\x06result\x18\x03 \x01(\v2\x16.worker.TaskResultInfoH\x00R\x06result\x12,\n" +
+	"\btask_ack\x18\x04 \x01(\v2\x0f.worker.TaskAckH\x00R\ataskAck\x122\n" +
+	"\n" +
+	"clock_pong\x18\x05 \x01(\v2\x11.worker.ClockPongH\x00R\tclockPong\x128\n" +
+	"\fstart_report\x18\x06 \x01(\v2\x13.worker.StartReportH\x00R\vstartReportB\t\n" +
+	"\apayload\"u\n" +
+	"\aTaskAck\x12\x17\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\x12\x18\n" +
+	"\asuccess\x18\x02 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x03 \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"task_error\x18\x04 \x01(\bR\ttaskError\"\xd8\x01\n" +
+	"\rMasterMessage\x12,\n" +
+	"\x06assign\x18\x01 \x01(\v2\x12.worker.AssignTaskH\x00R\x06assign\x12-\n" +
+	"\x04stop\x18\x02 \x01(\v2\x17.worker.StopTaskCommandH\x00R\x04stop\x122\n" +
+	"\n" +
+	"clock_ping\x18\x03 \x01(\v2\x11.worker.ClockPingH\x00R\tclockPing\x12+\n" +
+	"\x05start\x18\x04 \x01(\v2\x13.worker.StartSignalH\x00R\x05startB\t\n" +
+	"\apayload\"C\n" +
+	"\tClockPing\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x03R\x03seq\x12$\n" +
+	"\x0emaster_send_ns\x18\x02 \x01(\x03R\fmasterSendNs\"\x8f\x01\n" +
+	"\tClockPong\x12\x10\n" +
+	"\x03seq\x18\x01 \x01(\x03R\x03seq\x12$\n" +
+	"\x0emaster_send_ns\x18\x02 \x01(\x03R\fmasterSendNs\x12$\n" +
+	"\x0eworker_recv_ns\x18\x03 \x01(\x03R\fworkerRecvNs\x12$\n" +
+	"\x0eworker_send_ns\x18\x04 \x01(\x03R\fworkerSendNs\"&\n" +
+	"\vStartSignal\x12\x17\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\"a\n" +
+	"\vStartReport\x12\x17\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\x12\x1c\n" +
+	"\n" +
+	"sent_at_ns\x18\x02 \x01(\x03R\bsentAtNs\x12\x1b\n" +
+	"\tby_signal\x18\x03 \x01(\bR\bbySignal\"\xf2\x01\n" +
+	"\n" +
+	"AssignTask\x12\x17\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\x12!\n" +
+	"\ftickets_info\x18\x02 \x01(\tR\vticketsInfo\x120\n" +
+	"\bschedule\x18\x03 \x01(\v2\x14.worker.ScheduleInfoR\bschedule\x12\x1d\n" +
+	"\n" +
+	"sync_start\x18\x04 \x01(\bR\tsyncStart\x12&\n" +
+	"\x0fclock_offset_ns\x18\x05 \x01(\x03R\rclockOffsetNs\x12\x1b\n" +
+	"\ttask_name\x18\x06 \x01(\tR\btaskName\x12\x12\n" +
+	"\x04kind\x18\a \x01(\tR\x04kind\"\xaf\x01\n" +
+	"\fScheduleInfo\x12\x19\n" +
+	"\bstart_at\x18\x01 \x01(\x03R\astartAt\x12\x15\n" +
+	"\x06end_at\x18\x02 \x01(\x03R\x05endAt\x12\x1f\n" +
+	"\vinterval_ms\x18\x03 \x01(\x05R\n" +
+	"intervalMs\x12%\n" +
+	"\x0epushplus_token\x18\x04 \x01(\tR\rpushplusToken\x12%\n" +
+	"\x0enotify_targets\x18\x05 \x03(\tR\rnotifyTargets\"B\n" +
+	"\x0fStopTaskCommand\x12\x17\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason\"\x87\x01\n" +
+	"\x11CreateTaskRequest\x12\x1b\n" +
+	"\ttask_name\x18\x01 \x01(\tR\btaskName\x12#\n" +
+	"\rticker_config\x18\x02 \x01(\tR\ftickerConfig\x120\n" +
+	"\bschedule\x18\x03 \x01(\v2\x14.worker.ScheduleInfoR\bschedule\"(\n" +
+	"\rTaskIdRequest\x12\x17\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\"*\n" +
+	"\x10ListTasksRequest\x12\x16\n" +
+	"\x06status\x18\x01 \x01(\tR\x06status\"\xc5\x04\n" +
+	"\n" +
+	"TaskDetail\x12\x17\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\x12\x1b\n" +
+	"\ttask_name\x18\x02 \x01(\tR\btaskName\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12\x1f\n" +
+	"\vassigned_to\x18\x04 \x01(\tR\n" +
+	"assignedTo\x12\x1d\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\x03R\tcreatedAt\x12\x1d\n" +
+	"\n" +
+	"updated_at\x18\x06 \x01(\x03R\tupdatedAt\x12\x1f\n" +
+	"\vretry_count\x18\a \x01(\x05R\n" +
+	"retryCount\x12#\n" +
+	"\rticker_config\x18\b \x01(\tR\ftickerConfig\x12.\n" +
+	"\x06result\x18\t \x01(\v2\x16.worker.TaskResultInfoR\x06result\x12\x1d\n" +
+	"\n" +
+	"last_error\x18\n" +
+	" \x01(\tR\tlastError\x12\x16\n" +
+	"\x06source\x18\v \x01(\tR\x06source\x12%\n" +
+	"\x0ereload_pending\x18\f \x01(\bR\rreloadPending\x120\n" +
+	"\bschedule\x18\r \x01(\v2\x14.worker.ScheduleInfoR\bschedule\x12\"\n" +
+	"\rstart_skew_us\x18\x0e \x01(\x03R\vstartSkewUs\x12%\n" +
+	"\x0estart_reported\x18\x0f \x01(\bR\rstartReported\x12%\n" +
+	"\x0epurchase_state\x18\x10 \x01(\tR\rpurchaseState\x12\x12\n" +
+	"\x04kind\x18\x11 \x01(\tR\x04kind\":\n" +
+	"\x0eListTasksReply\x12(\n" +
+	"\x05tasks\x18\x01 \x03(\v2\x12.worker.TaskDetailR\x05tasks\"@\n" +
+	"\n" +
+	"AdminReply\x12\x18\n" +
+	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\"\x18\n" +
+	"\x16ListDeadLettersRequest\"\x14\n" +
+	"\x12ListWorkersRequest\"\xaf\x02\n" +
+	"\fWorkerDetail\x12\x1b\n" +
+	"\tworker_id\x18\x01 \x01(\tR\bworkerId\x12\x18\n" +
+	"\aaddress\x18\x02 \x01(\tR\aaddress\x12\x16\n" +
+	"\x06status\x18\x03 \x01(\tR\x06status\x12#\n" +
+	"\rtask_assigned\x18\x04 \x01(\tR\ftaskAssigned\x12\x1f\n" +
+	"\vupdate_time\x18\x05 \x01(\x03R\n" +
+	"updateTime\x12\x19\n" +
+	"\bban_time\x18\x06 \x01(\x03R\abanTime\x12&\n" +
+	"\x0fclock_offset_us\x18\a \x01(\x03R\rclockOffsetUs\x12 \n" +
+	"\fclock_rtt_us\x18\b \x01(\x03R\n" +
+	"clockRttUs\x12%\n" +
+	"\x0epurchase_state\x18\t \x01(\tR\rpurchaseState\"B\n" +
+	"\x10ListWorkersReply\x12.\n" +
+	"\aworkers\x18\x01 \x03(\v2\x14.worker.WorkerDetailR\aworkers2\x80\x02\n" +
+	"\fTicketMaster\x12;\n" +
+	"\x0eRegisterWorker\x12\x12.worker.WorkerInfo\x1a\x15.worker.RegisterReply\x129\n" +
+	"\n" +
+	"CancelTask\x12\x16.worker.CancelTaskInfo\x1a\x13.worker.CancelReply\x12;\n" +
+	"\fReportResult\x12\x16.worker.TaskResultInfo\x1a\x13.worker.ResultReply\x12;\n" +
+	"\aConnect\x12\x15.worker.WorkerMessage\x1a\x15.worker.MasterMessage(\x010\x012\xaa\x05\n" +
+	"\vTicketAdmin\x12;\n" +
+	"\n" +
+	"CreateTask\x12\x19.worker.CreateTaskRequest\x1a\x12.worker.TaskDetail\x12=\n" +
+	"\tListTasks\x12\x18.worker.ListTasksRequest\x1a\x16.worker.ListTasksReply\x124\n" +
+	"\aGetTask\x12\x15.worker.TaskIdRequest\x1a\x12.worker.TaskDetail\x127\n" +
+	"\n" +
+	"DeleteTask\x12\x15.worker.TaskIdRequest\x1a\x12.worker.AdminReply\x126\n" +
+	"\tPauseTask\x12\x15.worker.TaskIdRequest\x1a\x12.worker.AdminReply\x127\n" +
+	"\n" +
+	"ResumeTask\x12\x15.worker.TaskIdRequest\x1a\x12.worker.AdminReply\x12C\n" +
+	"\vListWorkers\x12\x1a.worker.ListWorkersRequest\x1a\x18.worker.ListWorkersReply\x12I\n" +
+	"\x0fListDeadLetters\x12\x1e.worker.ListDeadLettersRequest\x1a\x16.worker.ListTasksReply\x128\n" +
+	"\vRequeueTask\x12\x15.worker.TaskIdRequest\x1a\x12.worker.AdminReply\x129\n" +
+	"\fCheckSession\x12\x15.worker.TaskIdRequest\x1a\x12.worker.TaskDetail\x12:\n" +
+	"\rConfirmReload\x12\x15.worker.TaskIdRequest\x1a\x12.worker.AdminReplyB\x17Z\x15internal/master/pb;pbb\x06proto3"
+
+var (
+	file_proto_master_proto_rawDescOnce sync.Once
+	file_proto_master_proto_rawDescData []byte
+)
+
+func file_proto_master_proto_rawDescGZIP() []byte {
+	file_proto_master_proto_rawDescOnce.Do(func() {
+		file_proto_master_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_proto_master_proto_rawDesc), len(file_proto_master_proto_rawDesc)))
+	})
+	return file_proto_master_proto_rawDescData
+}
+
+var file_proto_master_proto_msgTypes = make([]protoimpl.MessageInfo, 26)
+var file_proto_master_proto_goTypes = []any{
+	(*WorkerInfo)(nil),             // 0: worker.WorkerInfo
+	(*RegisterReply)(nil),          // 1: worker.RegisterReply
+	(*CancelTaskInfo)(nil),         // 2: worker.CancelTaskInfo
+	(*CancelReply)(nil),            // 3: worker.CancelReply
+	(*TaskResultInfo)(nil),         // 4: worker.TaskResultInfo
+	(*ResultReply)(nil),            // 5: worker.ResultReply
+	(*WorkerMessage)(nil),          // 6: worker.WorkerMessage
+	(*TaskAck)(nil),                // 7: worker.TaskAck
+	(*MasterMessage)(nil),          // 8: worker.MasterMessage
+	(*ClockPing)(nil),              // 9: worker.ClockPing
+	(*ClockPong)(nil),              // 10: worker.ClockPong
+	(*StartSignal)(nil),            // 11: worker.StartSignal
+	(*StartReport)(nil),            // 12: worker.StartReport
+	(*AssignTask)(nil),             // 13: worker.AssignTask
+	(*ScheduleInfo)(nil),           // 14: worker.ScheduleInfo
+	(*StopTaskCommand)(nil),        // 15: worker.StopTaskCommand
+	(*CreateTaskRequest)(nil),      // 16: worker.CreateTaskRequest
+	(*TaskIdRequest)(nil),          // 17: worker.TaskIdRequest
+	(*ListTasksRequest)(nil),       // 18: worker.ListTasksRequest
+	(*TaskDetail)(nil),             // 19: worker.TaskDetail
+	(*ListTasksReply)(nil),         // 20: worker.ListTasksReply
+	(*AdminReply)(nil),             // 21: worker.AdminReply
+	(*ListDeadLettersRequest)(nil), // 22: worker.ListDeadLettersRequest
+	(*ListWorkersRequest)(nil),     // 23: worker.ListWorkersRequest
+	(*WorkerDetail)(nil),           // 24: worker.WorkerDetail
+	(*ListWorkersReply)(nil),       // 25: worker.ListWorkersReply
+}
+var file_proto_master_proto_depIdxs = []int32{
+	0,  // 0: worker.WorkerMessage.heartbeat:type_name -> worker.WorkerInfo
+	2,  // 1: worker.WorkerMessage.cancel_task:type_name -> worker.CancelTaskInfo
+	4,  // 2: worker.WorkerMessage.result:type_name -> worker.TaskResultInfo
+	7,  // 3: worker.WorkerMessage.task_ack:type_name -> worker.TaskAck
+	10, // 4: worker.WorkerMessage.clock_pong:type_name -> worker.ClockPong
+	12, // 5: worker.WorkerMessage.start_report:type_name -> worker.StartReport
+	13, // 6: worker.MasterMessage.assign:type_name -> worker.AssignTask
+	15, // 7: worker.MasterMessage.stop:type_name -> worker.StopTaskCommand
+	9,  // 8: worker.MasterMessage.clock_ping:type_name -> worker.ClockPing
+	11, // 9: worker.MasterMessage.start:type_name -> worker.StartSignal
+	14, // 10: worker.AssignTask.schedule:type_name -> worker.ScheduleInfo
+	14, // 11: worker.CreateTaskRequest.schedule:type_name -> worker.ScheduleInfo
+	4,  // 12: worker.TaskDetail.result:type_name -> worker.TaskResultInfo
+	14, // 13: worker.TaskDetail.schedule:type_name -> worker.ScheduleInfo
+	19, // 14: worker.ListTasksReply.tasks:type_name -> worker.TaskDetail
+	24, // 15: worker.ListWorkersReply.workers:type_name -> worker.WorkerDetail
+	0,  // 16: worker.TicketMaster.RegisterWorker:input_type -> worker.WorkerInfo
+	2,  // 17: worker.TicketMaster.CancelTask:input_type -> worker.CancelTaskInfo
+	4,  // 18: worker.TicketMaster.ReportResult:input_type -> worker.TaskResultInfo
+	6,  // 19: worker.TicketMaster.Connect:input_type -> worker.WorkerMessage
+	16, // 20: worker.TicketAdmin.CreateTask:input_type -> worker.CreateTaskRequest
+	18, // 21: worker.TicketAdmin.ListTasks:input_type -> worker.ListTasksRequest
+	17, // 22: worker.TicketAdmin.GetTask:input_type -> worker.TaskIdRequest
+	17, // 23: worker.TicketAdmin.DeleteTask:input_type -> worker.TaskIdRequest
+	17, // 24: worker.TicketAdmin.PauseTask:input_type -> worker.TaskIdRequest
+	17, // 25: worker.TicketAdmin.ResumeTask:input_type -> worker.TaskIdRequest
+	23, // 26: worker.TicketAdmin.ListWorkers:input_type -> worker.ListWorkersRequest
+	22, // 27: worker.TicketAdmin.ListDeadLetters:input_type -> worker.ListDeadLettersRequest
+	17, // 28: worker.TicketAdmin.RequeueTask:input_type -> worker.TaskIdRequest
+	17, // 29: worker.TicketAdmin.CheckSession:input_type -> worker.TaskIdRequest
+	17, // 30: worker.TicketAdmin.ConfirmReload:input_type -> worker.TaskIdRequest
+	1,  // 31: worker.TicketMaster.RegisterWorker:output_type -> worker.RegisterReply
+	3,  // 32: worker.TicketMaster.CancelTask:output_type -> worker.CancelReply
+	5,  // 33: worker.TicketMaster.ReportResult:output_type -> worker.ResultReply
+	8,  // 34: worker.TicketMaster.Connect:output_type -> worker.MasterMessage
+	19, // 35: worker.TicketAdmin.CreateTask:output_type -> worker.TaskDetail
+	20, // 36: worker.TicketAdmin.ListTasks:output_type -> worker.ListTasksReply
+	19, // 37: worker.TicketAdmin.GetTask:output_type -> worker.TaskDetail
+	21, // 38: worker.TicketAdmin.DeleteTask:output_type -> worker.AdminReply
+	21, // 39: worker.TicketAdmin.PauseTask:output_type -> worker.AdminReply
+	21, // 40: worker.TicketAdmin.ResumeTask:output_type -> worker.AdminReply
+	25, // 41: worker.TicketAdmin.ListWorkers:output_type -> worker.ListWorkersReply
+	20, // 42: worker.TicketAdmin.ListDeadLetters:output_type -> worker.ListTasksReply
+	21, // 43: worker.TicketAdmin.RequeueTask:output_type -> worker.AdminReply
+	19, // 44: worker.TicketAdmin.CheckSession:output_type -> worker.TaskDetail
+	21, // 45: worker.TicketAdmin.ConfirmReload:output_type -> worker.AdminReply
+	31, // [31:46] is the sub-list for method output_type
+	16, // [16:31] is the sub-list for method input_type
+	16, // [16:16] is the sub-list for extension type_name
+	16, // [16:16] is the sub-list for extension extendee
+	0,  // [0:16] is the sub-list for field type_name
+}
+
+func init() { file_proto_master_proto_init() }
+func file_proto_master_proto_init() {
+	if File_proto_master_proto != nil {
+		return
+	}
+	file_proto_master_proto_msgTypes[6].OneofWrappers = []any{
+		(*WorkerMessage_Heartbeat)(nil),
+		(*WorkerMessage_CancelTask)(nil),
+		(*WorkerMessage_Result)(nil),
+		(*WorkerMessage_TaskAck)(nil),
+		(*WorkerMessage_ClockPong)(nil),
+		(*WorkerMessage_StartReport)(nil),
+	}
+	file_proto_master_proto_msgTypes[8].OneofWrappers = []any{
+		(*MasterMessage_Assign)(nil),
+		(*MasterMessage_Stop)(nil),
+		(*MasterMessage_ClockPing)(nil),
+		(*MasterMessage_Start)(nil),
 	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
@@ -336,9 +2145,9 @@ func file_proto_master_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_master_proto_rawDesc), len(file_proto_master_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   4,
+			NumMessages:   26,
 			NumExtensions: 0,
-			NumServices:   1,
+			NumServices:   2,
 		},
 		GoTypes:           file_proto_master_proto_goTypes,
 		DependencyIndexes: file_proto_master_proto_depIdxs,
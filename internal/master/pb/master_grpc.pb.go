@@ -21,6 +21,8 @@ const _ = grpc.SupportPackageIsVersion9
 const (
 	TicketMaster_RegisterWorker_FullMethodName = "/worker.TicketMaster/RegisterWorker"
 	TicketMaster_CancelTask_FullMethodName     = "/worker.TicketMaster/CancelTask"
+	TicketMaster_ReportResult_FullMethodName   = "/worker.TicketMaster/ReportResult"
+	TicketMaster_Connect_FullMethodName        = "/worker.TicketMaster/Connect"
 )
 
 // TicketMasterClient is the client API for TicketMaster service.
@@ -31,6 +33,10 @@ const (
 type TicketMasterClient interface {
 	RegisterWorker(ctx context.Context, in *WorkerInfo, opts ...grpc.CallOption) (*RegisterReply, error)
 	CancelTask(ctx context.Context, in *CancelTaskInfo, opts ...grpc.CallOption) (*CancelReply, error)
+	ReportResult(ctx context.Context, in *TaskResultInfo, opts ...grpc.CallOption) (*ResultReply, error)
+	// worker 主动建立的长连接，承载心跳、状态变化、任务下发和停止命令。
+	// 第一条消息必须是 heartbeat，用于确定 worker_id
+	Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[WorkerMessage, MasterMessage], error)
 }
 
 type ticketMasterClient struct {
@@ -61,6 +67,29 @@ func (c *ticketMasterClient) CancelTask(ctx context.Context, in *CancelTaskInfo,
 	return out, nil
 }
 
+func (c *ticketMasterClient) ReportResult(ctx context.Context, in *TaskResultInfo, opts ...grpc.CallOption) (*ResultReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ResultReply)
+	err := c.cc.Invoke(ctx, TicketMaster_ReportResult_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketMasterClient) Connect(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[WorkerMessage, MasterMessage], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &TicketMaster_ServiceDesc.Streams[0], TicketMaster_Connect_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WorkerMessage, MasterMessage]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TicketMaster_ConnectClient = grpc.BidiStreamingClient[WorkerMessage, MasterMessage]
+
 // TicketMasterServer is the server API for TicketMaster service.
 // All implementations must embed UnimplementedTicketMasterServer
 // for forward compatibility.
@@ -69,6 +98,10 @@ func (c *ticketMasterClient) CancelTask(ctx context.Context, in *CancelTaskInfo,
 type TicketMasterServer interface {
 	RegisterWorker(context.Context, *WorkerInfo) (*RegisterReply, error)
 	CancelTask(context.Context, *CancelTaskInfo) (*CancelReply, error)
+	ReportResult(context.Context, *TaskResultInfo) (*ResultReply, error)
+	// worker 主动建立的长连接，承载心跳、状态变化、任务下发和停止命令。
+	// 第一条消息必须是 heartbeat，用于确定 worker_id
+	Connect(grpc.BidiStreamingServer[WorkerMessage, MasterMessage]) error
 	mustEmbedUnimplementedTicketMasterServer()
 }
 
@@ -85,6 +118,12 @@ func (UnimplementedTicketMasterServer) RegisterWorker(context.Context, *WorkerIn
 func (UnimplementedTicketMasterServer) CancelTask(context.Context, *CancelTaskInfo) (*CancelReply, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CancelTask not implemented")
 }
+func (UnimplementedTicketMasterServer) ReportResult(context.Context, *TaskResultInfo) (*ResultReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReportResult not implemented")
+}
+func (UnimplementedTicketMasterServer) Connect(grpc.BidiStreamingServer[WorkerMessage, MasterMessage]) error {
+	return status.Errorf(codes.Unimplemented, "method Connect not implemented")
+}
 func (UnimplementedTicketMasterServer) mustEmbedUnimplementedTicketMasterServer() {}
 func (UnimplementedTicketMasterServer) testEmbeddedByValue()                      {}
 
@@ -142,6 +181,31 @@ func _TicketMaster_CancelTask_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TicketMaster_ReportResult_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskResultInfo)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketMasterServer).ReportResult(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketMaster_ReportResult_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketMasterServer).ReportResult(ctx, req.(*TaskResultInfo))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketMaster_Connect_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(TicketMasterServer).Connect(&grpc.GenericServerStream[WorkerMessage, MasterMessage]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type TicketMaster_ConnectServer = grpc.BidiStreamingServer[WorkerMessage, MasterMessage]
+
 // TicketMaster_ServiceDesc is the grpc.ServiceDesc for TicketMaster service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -157,6 +221,507 @@ var TicketMaster_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "CancelTask",
 			Handler:    _TicketMaster_CancelTask_Handler,
 		},
+		{
+			MethodName: "ReportResult",
+			Handler:    _TicketMaster_ReportResult_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Connect",
+			Handler:       _TicketMaster_Connect_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "proto/master.proto",
+}
+
+const (
+	TicketAdmin_CreateTask_FullMethodName      = "/worker.TicketAdmin/CreateTask"
+	TicketAdmin_ListTasks_FullMethodName       = "/worker.TicketAdmin/ListTasks"
+	TicketAdmin_GetTask_FullMethodName         = "/worker.TicketAdmin/GetTask"
+	TicketAdmin_DeleteTask_FullMethodName      = "/worker.TicketAdmin/DeleteTask"
+	TicketAdmin_PauseTask_FullMethodName       = "/worker.TicketAdmin/PauseTask"
+	TicketAdmin_ResumeTask_FullMethodName      = "/worker.TicketAdmin/ResumeTask"
+	TicketAdmin_ListWorkers_FullMethodName     = "/worker.TicketAdmin/ListWorkers"
+	TicketAdmin_ListDeadLetters_FullMethodName = "/worker.TicketAdmin/ListDeadLetters"
+	TicketAdmin_RequeueTask_FullMethodName     = "/worker.TicketAdmin/RequeueTask"
+	TicketAdmin_CheckSession_FullMethodName    = "/worker.TicketAdmin/CheckSession"
+	TicketAdmin_ConfirmReload_FullMethodName   = "/worker.TicketAdmin/ConfirmReload"
+)
+
+// TicketAdminClient is the client API for TicketAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// 运维管理接口，运行时增删改查任务
+type TicketAdminClient interface {
+	CreateTask(ctx context.Context, in *CreateTaskRequest, opts ...grpc.CallOption) (*TaskDetail, error)
+	ListTasks(ctx context.Context, in *ListTasksRequest, opts ...grpc.CallOption) (*ListTasksReply, error)
+	GetTask(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*TaskDetail, error)
+	DeleteTask(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*AdminReply, error)
+	PauseTask(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*AdminReply, error)
+	ResumeTask(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*AdminReply, error)
+	ListWorkers(ctx context.Context, in *ListWorkersRequest, opts ...grpc.CallOption) (*ListWorkersReply, error)
+	ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (*ListTasksReply, error)
+	RequeueTask(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*AdminReply, error)
+	// 用任务的配置创建一个账号检查任务，开抢前确认 cookie 和购票人仍然有效
+	CheckSession(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*TaskDetail, error)
+	// 正在执行的任务配置文件被修改后，确认用新配置重启
+	ConfirmReload(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*AdminReply, error)
+}
+
+type ticketAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTicketAdminClient(cc grpc.ClientConnInterface) TicketAdminClient {
+	return &ticketAdminClient{cc}
+}
+
+func (c *ticketAdminClient) CreateTask(ctx context.Context, in *CreateTaskRequest, opts ...grpc.CallOption) (*TaskDetail, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TaskDetail)
+	err := c.cc.Invoke(ctx, TicketAdmin_CreateTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketAdminClient) ListTasks(ctx context.Context, in *ListTasksRequest, opts ...grpc.CallOption) (*ListTasksReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTasksReply)
+	err := c.cc.Invoke(ctx, TicketAdmin_ListTasks_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketAdminClient) GetTask(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*TaskDetail, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TaskDetail)
+	err := c.cc.Invoke(ctx, TicketAdmin_GetTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketAdminClient) DeleteTask(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*AdminReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminReply)
+	err := c.cc.Invoke(ctx, TicketAdmin_DeleteTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketAdminClient) PauseTask(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*AdminReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminReply)
+	err := c.cc.Invoke(ctx, TicketAdmin_PauseTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketAdminClient) ResumeTask(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*AdminReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminReply)
+	err := c.cc.Invoke(ctx, TicketAdmin_ResumeTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketAdminClient) ListWorkers(ctx context.Context, in *ListWorkersRequest, opts ...grpc.CallOption) (*ListWorkersReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWorkersReply)
+	err := c.cc.Invoke(ctx, TicketAdmin_ListWorkers_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketAdminClient) ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (*ListTasksReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListTasksReply)
+	err := c.cc.Invoke(ctx, TicketAdmin_ListDeadLetters_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketAdminClient) RequeueTask(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*AdminReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminReply)
+	err := c.cc.Invoke(ctx, TicketAdmin_RequeueTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketAdminClient) CheckSession(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*TaskDetail, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TaskDetail)
+	err := c.cc.Invoke(ctx, TicketAdmin_CheckSession_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *ticketAdminClient) ConfirmReload(ctx context.Context, in *TaskIdRequest, opts ...grpc.CallOption) (*AdminReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AdminReply)
+	err := c.cc.Invoke(ctx, TicketAdmin_ConfirmReload_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TicketAdminServer is the server API for TicketAdmin service.
+// All implementations must embed UnimplementedTicketAdminServer
+// for forward compatibility.
+//
+// 运维管理接口，运行时增删改查任务
+type TicketAdminServer interface {
+	CreateTask(context.Context, *CreateTaskRequest) (*TaskDetail, error)
+	ListTasks(context.Context, *ListTasksRequest) (*ListTasksReply, error)
+	GetTask(context.Context, *TaskIdRequest) (*TaskDetail, error)
+	DeleteTask(context.Context, *TaskIdRequest) (*AdminReply, error)
+	PauseTask(context.Context, *TaskIdRequest) (*AdminReply, error)
+	ResumeTask(context.Context, *TaskIdRequest) (*AdminReply, error)
+	ListWorkers(context.Context, *ListWorkersRequest) (*ListWorkersReply, error)
+	ListDeadLetters(context.Context, *ListDeadLettersRequest) (*ListTasksReply, error)
+	RequeueTask(context.Context, *TaskIdRequest) (*AdminReply, error)
+	// 用任务的配置创建一个账号检查任务，开抢前确认 cookie 和购票人仍然有效
+	CheckSession(context.Context, *TaskIdRequest) (*TaskDetail, error)
+	// 正在执行的任务配置文件被修改后，确认用新配置重启
+	ConfirmReload(context.Context, *TaskIdRequest) (*AdminReply, error)
+	mustEmbedUnimplementedTicketAdminServer()
+}
+
+// UnimplementedTicketAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTicketAdminServer struct{}
+
+func (UnimplementedTicketAdminServer) CreateTask(context.Context, *CreateTaskRequest) (*TaskDetail, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateTask not implemented")
+}
+func (UnimplementedTicketAdminServer) ListTasks(context.Context, *ListTasksRequest) (*ListTasksReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListTasks not implemented")
+}
+func (UnimplementedTicketAdminServer) GetTask(context.Context, *TaskIdRequest) (*TaskDetail, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetTask not implemented")
+}
+func (UnimplementedTicketAdminServer) DeleteTask(context.Context, *TaskIdRequest) (*AdminReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteTask not implemented")
+}
+func (UnimplementedTicketAdminServer) PauseTask(context.Context, *TaskIdRequest) (*AdminReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseTask not implemented")
+}
+func (UnimplementedTicketAdminServer) ResumeTask(context.Context, *TaskIdRequest) (*AdminReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeTask not implemented")
+}
+func (UnimplementedTicketAdminServer) ListWorkers(context.Context, *ListWorkersRequest) (*ListWorkersReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWorkers not implemented")
+}
+func (UnimplementedTicketAdminServer) ListDeadLetters(context.Context, *ListDeadLettersRequest) (*ListTasksReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDeadLetters not implemented")
+}
+func (UnimplementedTicketAdminServer) RequeueTask(context.Context, *TaskIdRequest) (*AdminReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RequeueTask not implemented")
+}
+func (UnimplementedTicketAdminServer) CheckSession(context.Context, *TaskIdRequest) (*TaskDetail, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckSession not implemented")
+}
+func (UnimplementedTicketAdminServer) ConfirmReload(context.Context, *TaskIdRequest) (*AdminReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ConfirmReload not implemented")
+}
+func (UnimplementedTicketAdminServer) mustEmbedUnimplementedTicketAdminServer() {}
+func (UnimplementedTicketAdminServer) testEmbeddedByValue()                     {}
+
+// UnsafeTicketAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TicketAdminServer will
+// result in compilation errors.
+type UnsafeTicketAdminServer interface {
+	mustEmbedUnimplementedTicketAdminServer()
+}
+
+func RegisterTicketAdminServer(s grpc.ServiceRegistrar, srv TicketAdminServer) {
+	// If the following call pancis, it indicates UnimplementedTicketAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TicketAdmin_ServiceDesc, srv)
+}
+
+func _TicketAdmin_CreateTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketAdminServer).CreateTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketAdmin_CreateTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketAdminServer).CreateTask(ctx, req.(*CreateTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketAdmin_ListTasks_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListTasksRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketAdminServer).ListTasks(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketAdmin_ListTasks_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketAdminServer).ListTasks(ctx, req.(*ListTasksRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketAdmin_GetTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketAdminServer).GetTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketAdmin_GetTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketAdminServer).GetTask(ctx, req.(*TaskIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketAdmin_DeleteTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketAdminServer).DeleteTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketAdmin_DeleteTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketAdminServer).DeleteTask(ctx, req.(*TaskIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketAdmin_PauseTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketAdminServer).PauseTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketAdmin_PauseTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketAdminServer).PauseTask(ctx, req.(*TaskIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketAdmin_ResumeTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketAdminServer).ResumeTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketAdmin_ResumeTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketAdminServer).ResumeTask(ctx, req.(*TaskIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketAdmin_ListWorkers_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWorkersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketAdminServer).ListWorkers(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketAdmin_ListWorkers_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketAdminServer).ListWorkers(ctx, req.(*ListWorkersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketAdmin_ListDeadLetters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDeadLettersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketAdminServer).ListDeadLetters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketAdmin_ListDeadLetters_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketAdminServer).ListDeadLetters(ctx, req.(*ListDeadLettersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketAdmin_RequeueTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketAdminServer).RequeueTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketAdmin_RequeueTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketAdminServer).RequeueTask(ctx, req.(*TaskIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketAdmin_CheckSession_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketAdminServer).CheckSession(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketAdmin_CheckSession_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketAdminServer).CheckSession(ctx, req.(*TaskIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _TicketAdmin_ConfirmReload_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TaskIdRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketAdminServer).ConfirmReload(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketAdmin_ConfirmReload_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketAdminServer).ConfirmReload(ctx, req.(*TaskIdRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TicketAdmin_ServiceDesc is the grpc.ServiceDesc for TicketAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TicketAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "worker.TicketAdmin",
+	HandlerType: (*TicketAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "CreateTask",
+			Handler:    _TicketAdmin_CreateTask_Handler,
+		},
+		{
+			MethodName: "ListTasks",
+			Handler:    _TicketAdmin_ListTasks_Handler,
+		},
+		{
+			MethodName: "GetTask",
+			Handler:    _TicketAdmin_GetTask_Handler,
+		},
+		{
+			MethodName: "DeleteTask",
+			Handler:    _TicketAdmin_DeleteTask_Handler,
+		},
+		{
+			MethodName: "PauseTask",
+			Handler:    _TicketAdmin_PauseTask_Handler,
+		},
+		{
+			MethodName: "ResumeTask",
+			Handler:    _TicketAdmin_ResumeTask_Handler,
+		},
+		{
+			MethodName: "ListWorkers",
+			Handler:    _TicketAdmin_ListWorkers_Handler,
+		},
+		{
+			MethodName: "ListDeadLetters",
+			Handler:    _TicketAdmin_ListDeadLetters_Handler,
+		},
+		{
+			MethodName: "RequeueTask",
+			Handler:    _TicketAdmin_RequeueTask_Handler,
+		},
+		{
+			MethodName: "CheckSession",
+			Handler:    _TicketAdmin_CheckSession_Handler,
+		},
+		{
+			MethodName: "ConfirmReload",
+			Handler:    _TicketAdmin_ConfirmReload_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/master.proto",
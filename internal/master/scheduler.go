@@ -0,0 +1,188 @@
+package master
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Assignment 一次调度决定的任务和 worker 配对
+type Assignment struct {
+	Task   *TaskInfo
+	Worker *Worker
+}
+
+// Scheduler 决定待分配任务和空闲 worker 的配对顺序。
+// Schedule 只读取传入的 task/worker，不修改状态，结果必须是确定的。
+type Scheduler interface {
+	Name() string
+	Schedule(pending []*TaskInfo, idle []*Worker) []Assignment
+}
+
+// AssignmentObserver 可选接口，策略需要知道哪些分配真正下发成功时实现，
+// 在 worker 确认收到任务后调用，推送失败或被拒绝的分配不会通知
+type AssignmentObserver interface {
+	Assigned(a Assignment)
+}
+
+const (
+	PolicyFIFO        = "fifo"         // 按 CreatedAt 先到先得
+	PolicyPriority    = "priority"     // 按任务 priority 从高到低，相同时 FIFO
+	PolicyRoundRobin  = "round-robin"  // 按账号轮转，避免一个账号占满所有 worker
+	PolicyLeastRisked = "least-risked" // 任务 FIFO，优先使用最久没有被风控的 worker
+	DefaultPolicy     = PolicyFIFO
+)
+
+// NewScheduler 按名字创建内置调度策略
+func NewScheduler(policy string) (Scheduler, error) {
+	switch policy {
+	case "", PolicyFIFO:
+		return fifoScheduler{}, nil
+	case PolicyPriority:
+		return priorityScheduler{}, nil
+	case PolicyRoundRobin:
+		return newRoundRobinScheduler(), nil
+	case PolicyLeastRisked:
+		return leastRiskedScheduler{}, nil
+	}
+	return nil, fmt.Errorf("unknown scheduler policy %q", policy)
+}
+
+func sortTasksFIFO(tasks []*TaskInfo) {
+	sort.SliceStable(tasks, func(i, j int) bool { return taskBefore(tasks[i], tasks[j]) })
+}
+
+func taskBefore(a, b *TaskInfo) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
+
+func sortWorkersByID(workers []*Worker) {
+	sort.SliceStable(workers, func(i, j int) bool { return workers[i].WorkerID < workers[j].WorkerID })
+}
+
+func pair(tasks []*TaskInfo, workers []*Worker) []Assignment {
+	n := min(len(tasks), len(workers))
+	assignments := make([]Assignment, 0, n)
+	for i := 0; i < n; i++ {
+		assignments = append(assignments, Assignment{Task: tasks[i], Worker: workers[i]})
+	}
+	return assignments
+}
+
+type fifoScheduler struct{}
+
+func (fifoScheduler) Name() string { return PolicyFIFO }
+
+func (fifoScheduler) Schedule(pending []*TaskInfo, idle []*Worker) []Assignment {
+	sortTasksFIFO(pending)
+	sortWorkersByID(idle)
+	return pair(pending, idle)
+}
+
+type priorityScheduler struct{}
+
+func (priorityScheduler) Name() string { return PolicyPriority }
+
+func (priorityScheduler) Schedule(pending []*TaskInfo, idle []*Worker) []Assignment {
+	sort.SliceStable(pending, func(i, j int) bool {
+		if pending[i].Priority != pending[j].Priority {
+			return pending[i].Priority > pending[j].Priority
+		}
+		return taskBefore(pending[i], pending[j])
+	})
+	sortWorkersByID(idle)
+	return pair(pending, idle)
+}
+
+// roundRobinScheduler 每轮每个账号最多拿一个 worker，最久没被服务的账号先拿
+type roundRobinScheduler struct {
+	mu         sync.Mutex
+	lastServed map[string]time.Time
+	seq        int64 // 同一次调度内区分先后
+}
+
+func newRoundRobinScheduler() *roundRobinScheduler {
+	return &roundRobinScheduler{lastServed: make(map[string]time.Time)}
+}
+
+func (*roundRobinScheduler) Name() string { return PolicyRoundRobin }
+
+func (r *roundRobinScheduler) Schedule(pending []*TaskInfo, idle []*Worker) []Assignment {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sortTasksFIFO(pending)
+	sortWorkersByID(idle)
+
+	queues := make(map[string][]*TaskInfo)
+	accounts := make([]string, 0)
+	for _, task := range pending {
+		if _, exists := queues[task.Account]; !exists {
+			accounts = append(accounts, task.Account)
+		}
+		queues[task.Account] = append(queues[task.Account], task)
+	}
+	sort.SliceStable(accounts, func(i, j int) bool {
+		ti, tj := r.lastServed[accounts[i]], r.lastServed[accounts[j]]
+		if !ti.Equal(tj) {
+			return ti.Before(tj)
+		}
+		return accounts[i] < accounts[j]
+	})
+
+	ordered := make([]*TaskInfo, 0, len(pending))
+	for len(ordered) < len(pending) {
+		for _, account := range accounts {
+			if queue := queues[account]; len(queue) > 0 {
+				ordered = append(ordered, queue[0])
+				queues[account] = queue[1:]
+			}
+		}
+	}
+	return pair(ordered, idle)
+}
+
+// Assigned 分配成功后才把账号移到轮转末尾，推送失败的账号下一轮仍然优先
+func (r *roundRobinScheduler) Assigned(a Assignment) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.seq++
+	r.lastServed[a.Task.Account] = time.Now().Add(time.Duration(r.seq))
+}
+
+// scheduleSessionChecks 账号检查任务按 FIFO 分配给抢票任务没有用到的空闲 worker，
+// 不和抢票任务争抢 worker，也不参与按账号轮转
+func scheduleSessionChecks(checks []*TaskInfo, idle []*Worker, assigned []Assignment) []Assignment {
+	used := make(map[string]bool, len(assigned))
+	for _, a := range assigned {
+		used[a.Worker.WorkerID] = true
+	}
+	free := make([]*Worker, 0, len(idle))
+	for _, worker := range idle {
+		if !used[worker.WorkerID] {
+			free = append(free, worker)
+		}
+	}
+	sortTasksFIFO(checks)
+	sortWorkersByID(free)
+	return pair(checks, free)
+}
+
+type leastRiskedScheduler struct{}
+
+func (leastRiskedScheduler) Name() string { return PolicyLeastRisked }
+
+func (leastRiskedScheduler) Schedule(pending []*TaskInfo, idle []*Worker) []Assignment {
+	sortTasksFIFO(pending)
+	sort.SliceStable(idle, func(i, j int) bool {
+		// 从未被风控的 BanTime 为零值，排在最前
+		if !idle[i].BanTime.Equal(idle[j].BanTime) {
+			return idle[i].BanTime.Before(idle[j].BanTime)
+		}
+		return idle[i].WorkerID < idle[j].WorkerID
+	})
+	return pair(pending, idle)
+}
@@ -0,0 +1,112 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	"testing"
+	"time"
+)
+
+func taskIDs(assignments []Assignment) []string {
+	ids := make([]string, 0, len(assignments))
+	for _, a := range assignments {
+		ids = append(ids, a.Task.ID+"@"+a.Worker.WorkerID)
+	}
+	return ids
+}
+
+func equalIDs(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestSchedulerPolicies(t *testing.T) {
+	base := time.Now()
+	newTasks := func() []*TaskInfo {
+		return []*TaskInfo{
+			{ID: "t3", CreatedAt: base.Add(3 * time.Second), Account: "alice", Priority: 1},
+			{ID: "t1", CreatedAt: base.Add(1 * time.Second), Account: "alice"},
+			{ID: "t2", CreatedAt: base.Add(2 * time.Second), Account: "alice"},
+			{ID: "t4", CreatedAt: base.Add(4 * time.Second), Account: "bob", Priority: 5},
+		}
+	}
+	newWorkers := func() []*Worker {
+		return []*Worker{
+			{WorkerID: "w-b", BanTime: base.Add(-time.Minute)},
+			{WorkerID: "w-c"},
+			{WorkerID: "w-a", BanTime: base.Add(-time.Hour)},
+		}
+	}
+	cases := []struct {
+		policy string
+		want   []string
+	}{
+		{PolicyFIFO, []string{"t1@w-a", "t2@w-b", "t3@w-c"}},
+		{PolicyPriority, []string{"t4@w-a", "t3@w-b", "t1@w-c"}},
+		{PolicyRoundRobin, []string{"t1@w-a", "t4@w-b", "t2@w-c"}},
+		{PolicyLeastRisked, []string{"t1@w-c", "t2@w-a", "t3@w-b"}},
+	}
+	for _, tc := range cases {
+		scheduler, err := NewScheduler(tc.policy)
+		if err != nil {
+			t.Fatalf("NewScheduler(%s): %v", tc.policy, err)
+		}
+		got := taskIDs(scheduler.Schedule(newTasks(), newWorkers()))
+		if !equalIDs(got, tc.want) {
+			t.Errorf("%s: got %v, want %v", tc.policy, got, tc.want)
+		}
+	}
+	if _, err := NewScheduler("random"); err == nil {
+		t.Fatal("unknown policy should be rejected")
+	}
+}
+
+func TestRoundRobinRotatesAccounts(t *testing.T) {
+	scheduler := newRoundRobinScheduler()
+	base := time.Now()
+	tasks := []*TaskInfo{
+		{ID: "a1", CreatedAt: base, Account: "alice"},
+		{ID: "a2", CreatedAt: base.Add(time.Second), Account: "alice"},
+		{ID: "b1", CreatedAt: base.Add(2 * time.Second), Account: "bob"},
+	}
+	first := scheduler.Schedule(tasks, []*Worker{{WorkerID: "w-1"}})
+	if got := taskIDs(first); !equalIDs(got, []string{"a1@w-1"}) {
+		t.Fatalf("first round: %v", got)
+	}
+	// 下发失败时 alice 没有被服务，仍然排在前面
+	if got := taskIDs(scheduler.Schedule(tasks, []*Worker{{WorkerID: "w-1"}})); !equalIDs(got, []string{"a1@w-1"}) {
+		t.Fatalf("failed assignment should not rotate: %v", got)
+	}
+	scheduler.Assigned(first[0])
+	// alice 刚被服务过，下一个空闲 worker 轮到 bob
+	second := scheduler.Schedule(tasks[1:], []*Worker{{WorkerID: "w-2"}})
+	if got := taskIDs(second); !equalIDs(got, []string{"b1@w-2"}) {
+		t.Fatalf("second round: %v", got)
+	}
+}
+
+func TestSessionChecksUseLeftoverWorkers(t *testing.T) {
+	base := time.Now()
+	purchases := []*TaskInfo{{ID: "t1", CreatedAt: base.Add(time.Second), Account: "alice"}}
+	checks := []*TaskInfo{
+		{ID: "c2", CreatedAt: base.Add(2 * time.Second), Kind: TaskKindSessionCheck},
+		{ID: "c1", CreatedAt: base, Kind: TaskKindSessionCheck},
+	}
+	idle := []*Worker{{WorkerID: "w-b"}, {WorkerID: "w-a"}}
+
+	// 更早创建的检查也不能抢走抢票任务的 worker
+	assigned := fifoScheduler{}.Schedule(purchases, idle)
+	got := taskIDs(scheduleSessionChecks(checks, idle, assigned))
+	if want := []string{"c1@w-b"}; !equalIDs(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	if got := scheduleSessionChecks(checks, idle[:1], fifoScheduler{}.Schedule(purchases, idle[:1])); len(got) != 0 {
+		t.Fatalf("no worker should be left for checks, got %v", taskIDs(got))
+	}
+}
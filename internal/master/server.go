@@ -7,10 +7,6 @@ import (
 	"context"
 	"fmt"
 	"google.golang.org/grpc"
-	"io/ioutil"
-	"os"
-	"path/filepath"
-	"strings"
 	"sync"
 	"time"
 )
@@ -19,12 +15,13 @@ var log = GetLogger("master")
 
 // Worker 工作节点信息
 type Worker struct {
-	WorkerID     string
-	Address      string
-	Status       WorkerStatus
-	TaskAssigned string
-	UpdateTime   time.Time //心跳
-	BanTime      time.Time //风控时间
+	WorkerID      string
+	Address       string
+	Status        WorkerStatus
+	TaskAssigned  string
+	UpdateTime    time.Time //心跳
+	BanTime       time.Time //风控时间
+	PurchaseState string    // 当前（或最近一个）任务的购买阶段
 }
 
 // Server 服务器结构
@@ -39,57 +36,137 @@ type Server struct {
 	heartbeatTimeout time.Duration
 	taskTimeout      time.Duration
 	banTimeout       time.Duration
+	checkInterval    time.Duration
+	// 测量 worker 时钟偏差的周期
+	clockProbeInterval time.Duration
+	clock              Clock // 开抢时间以它为准
 
 	maxRetries int
+	// 长连接
+	sessions    map[string]*workerSession
+	sessionsMux sync.RWMutex
+	streamsDone chan struct{} // 关闭后所有 Connect 立即返回
+	closeStream sync.Once
+	// 持久化
+	store       TaskStore
+	allDone     bool
+	tasksLoaded bool
+	// 配置目录热加载
+	autoRestartOnReload bool
+	rejectedFiles       map[string]string // 没有通过检查的配置文件名 -> 内容 hash，同一内容只报告一次
 	// 停止信号
 	stopChan        chan struct{}
 	scheduleTrigger chan struct{} // 🔔 调度触发通道
+	scheduler       Scheduler     // 在 tasksMux 内调用
+	// 集群事件
+	eventRules EventRules
+	eventSink  EventSink
+	events     *eventBus
+	// 开始没有空闲 worker 的时间，有空闲 worker 时为零值，在 tasksMux 内访问
+	noIdleSince time.Time
 }
 
-// NewServer 创建新的服务器实例
-func NewServer() *Server {
+// NewServer 创建新的服务器实例，并从 store 中恢复上次的任务和 worker 状态
+func NewServer(store TaskStore, opts ...Option) *Server {
 	server := &Server{
-		workers:          make(map[string]*Worker),
-		tasks:            make(map[string]*TaskInfo),
-		heartbeatTimeout: 10 * time.Second, //
-		taskTimeout:      30 * time.Second, //
-		banTimeout:       5 * time.Minute,  //
-		maxRetries:       3,
-		stopChan:         make(chan struct{}),
-		scheduleTrigger:  make(chan struct{}, 1),
+		workers:            make(map[string]*Worker),
+		tasks:              make(map[string]*TaskInfo),
+		heartbeatTimeout:   10 * time.Second, //
+		taskTimeout:        30 * time.Second, //
+		banTimeout:         5 * time.Minute,  //
+		checkInterval:      5 * time.Second,
+		clockProbeInterval: 10 * time.Second,
+		clock:              SystemClock(),
+		maxRetries:         3,
+		stopChan:           make(chan struct{}),
+		scheduleTrigger:    make(chan struct{}, 1),
+		store:              store,
+		sessions:           make(map[string]*workerSession),
+		streamsDone:        make(chan struct{}),
+		rejectedFiles:      make(map[string]string),
+		scheduler:          fifoScheduler{},
+		eventRules:         DefaultEventRules(),
+	}
+	for _, opt := range opts {
+		opt(server)
+	}
+	server.events = newEventBus(server.eventRules, server.eventSink)
+	log.Printf("[Schedule] policy: %s", server.scheduler.Name())
+	if err := server.recover(); err != nil {
+		log.Errorf("[Store] 恢复状态失败: %v", err)
 	}
 
 	go server.startHeartbeatChecker()
 	go server.startTaskScheduler()
 	go server.startTaskMonitor()
+	go server.events.run(server.stopChan)
 
 	return server
 
 }
 
-func (s *Server) LoadTasksFromDir(dirPath string) error {
-	files, err := ioutil.ReadDir(dirPath)
+// recover 从 store 回放任务和 worker。Doing 的任务保持分配关系，
+// 给原 worker 一个心跳周期重新上报，超时后由 monitorTasks 重新分配。
+func (s *Server) recover() error {
+	tasks, workers, err := s.store.Load()
 	if err != nil {
 		return err
 	}
-
-	for _, file := range files {
-		if file.IsDir() {
-			continue
+	now := time.Now()
+	for _, task := range tasks {
+		if task.Status == TaskStatusDoing {
+			task.UpdatedAt = now
 		}
-		if strings.HasSuffix(file.Name(), ".json") {
-			fullPath := filepath.Join(dirPath, file.Name())
-			content, err := os.ReadFile(fullPath)
-			if err != nil {
-				log.Printf("Failed to read file %s: %v", fullPath, err)
-				continue
-			}
-			taskName := strings.TrimSuffix(file.Name(), ".json")
-			tickerConfigContent := string(content)
-			_ = s.CreateTask(taskName, tickerConfigContent)
+		task.applyMeta() // 旧版本持久化的任务没有调度字段
+		s.tasks[task.ID] = task
+	}
+	for _, worker := range workers {
+		if worker.Status == Down {
+			continue
 		}
+		worker.UpdateTime = now
+		s.workers[worker.WorkerID] = worker
+	}
+	if len(tasks) > 0 || len(workers) > 0 {
+		log.Infof("[Store] 恢复任务 %d 个, worker %d 个", len(s.tasks), len(s.workers))
+	}
+	return nil
+}
+
+func (s *Server) persistTask(task *TaskInfo) {
+	if err := s.store.SaveTask(task); err != nil {
+		log.Errorf("[Store] 保存任务 %s 失败: %v", task.ID, err)
+	}
+}
+
+func (s *Server) persistWorker(worker *Worker) {
+	if err := s.store.SaveWorker(worker); err != nil {
+		log.Errorf("[Store] 保存 worker %s 失败: %v", worker.WorkerID, err)
 	}
+}
+
+// LoadTasksFromDir 按文件名加载配置目录，已存在（包括从 store 恢复）的任务不会重复创建
+func (s *Server) LoadTasksFromDir(dirPath string) error {
+	if err := s.syncConfigDir(dirPath); err != nil {
+		return err
+	}
+	s.markTasksLoaded()
+	return nil
+}
+
+func (s *Server) markTasksLoaded() {
+	s.tasksMux.Lock()
+	s.tasksLoaded = true
+	s.tasksMux.Unlock()
+}
 
+// Ready 启动时的任务加载完成后才接受就绪检查
+func (s *Server) Ready(ctx context.Context) error {
+	s.tasksMux.RLock()
+	defer s.tasksMux.RUnlock()
+	if !s.tasksLoaded {
+		return fmt.Errorf("tasks not loaded")
+	}
 	return nil
 }
 
@@ -98,21 +175,32 @@ func (s *Server) CancelTask(ctx context.Context, req *masterpb.CancelTaskInfo) (
 	s.tasksMux.Lock()
 	defer s.workersMux.Unlock()
 	defer s.tasksMux.Unlock()
+	ownWorkerId := req.WorkerId
+	if _, exists := s.workers[ownWorkerId]; !exists {
+		return nil, fmt.Errorf("worker <%s> not registered", ownWorkerId)
+	}
+	// 被 master 停止的任务可能已经删除或转交，worker 状态仍然需要更新
 	cancelTask, exists := s.tasks[req.CancelTaskId]
 	if !exists {
-		return nil, fmt.Errorf("<%s> not found", req.CancelTaskId)
+		log.Printf("Worker %s released task <%s> which no longer exists", ownWorkerId, req.CancelTaskId)
+	} else if cancelTask.AssignedTo != ownWorkerId {
+		log.Printf("Worker %s released task <%s> now owned by <%s>", ownWorkerId, cancelTask.TaskName, cancelTask.AssignedTo)
 	}
-	if cancelTask.AssignedTo != req.WorkerId {
-		return nil, fmt.Errorf("<%s> not own by <%s>", req.CancelTaskId, req.WorkerId)
+	if exists && cancelTask.AssignedTo == ownWorkerId && cancelTask.Status == TaskStatusDoing {
+		// worker 主动放弃（风控或下线），任务交给其他 worker
+		log.Printf("[Reassign] %s released task %s -> PENDING", ownWorkerId, cancelTask.TaskName)
+		s.clearAndPendingTask(cancelTask, fmt.Sprintf("released by %s (%s)", ownWorkerId, WorkerStatus(req.WorkStatus)))
+		s.triggerSchedule()
 	}
-	ownWorkerId := req.WorkerId
 	s.workers[ownWorkerId].TaskAssigned = ""
 	if s.workers[ownWorkerId].Status != Risking && WorkerStatus(req.WorkStatus) == Risking {
 		log.Printf("Worker %s 出现风控，标记为Risking", ownWorkerId)
 		s.workers[ownWorkerId].BanTime = time.Now() //设置风控时间
+		s.events.emit(EventWorkerRisking, ownWorkerId, "worker %s 触发风控，冷却 %s", ownWorkerId, s.banTimeout)
 	}
 	s.workers[ownWorkerId].Status = WorkerStatus(req.WorkStatus)
 	s.workers[ownWorkerId].UpdateTime = time.Now()
+	s.persistWorker(s.workers[ownWorkerId])
 
 	return &masterpb.CancelReply{
 		Success: true,
@@ -120,6 +208,45 @@ func (s *Server) CancelTask(ctx context.Context, req *masterpb.CancelTaskInfo) (
 	}, nil
 }
 
+// ReportResult 接收 worker 上报的任务最终结果
+func (s *Server) ReportResult(ctx context.Context, req *masterpb.TaskResultInfo) (*masterpb.ResultReply, error) {
+	result := TaskStatus(req.Status)
+	if !result.Finished() || result == TaskStatusDone {
+		return nil, fmt.Errorf("invalid result status <%s>", req.Status)
+	}
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	task, exists := s.tasks[req.TaskId]
+	if !exists {
+		return nil, fmt.Errorf("<%s> not found", req.TaskId)
+	}
+	if task.AssignedTo != req.WorkerId {
+		return nil, fmt.Errorf("<%s> not own by <%s>", req.TaskId, req.WorkerId)
+	}
+	task.Status = result
+	task.UpdatedAt = time.Now()
+	task.Result = &TaskResult{
+		WorkerID:   req.WorkerId,
+		Errno:      int(req.Errno),
+		Message:    req.Message,
+		OrderId:    req.OrderId,
+		PayMoney:   int(req.PayMoney),
+		Attempts:   int(req.Attempts),
+		Duration:   time.Duration(req.DurationMs) * time.Millisecond,
+		ReportedAt: time.Now(),
+	}
+	s.persistTask(task)
+	log.Infof("[Result] <%s> %s by %s: errno=%d msg=%s order=%d attempts=%d",
+		task.TaskName, result, req.WorkerId, req.Errno, req.Message, req.OrderId, req.Attempts)
+	if task.Kind == TaskKindSessionCheck && result == TaskStatusFailed {
+		s.events.emitTo(task.Schedule.notifyTargets(), EventSessionInvalid, task.ID, "<%s> 的账号检查没有通过（worker %s）: %s", task.TaskName, req.WorkerId, req.Message)
+	}
+	return &masterpb.ResultReply{
+		Success: true,
+		Message: fmt.Sprintf("<%s> marked %s", req.TaskId, result),
+	}, nil
+}
+
 func (s *Server) RegisterWorker(ctx context.Context, req *masterpb.WorkerInfo) (*masterpb.RegisterReply, error) {
 	s.workersMux.Lock()
 	s.tasksMux.Lock()
@@ -128,26 +255,62 @@ func (s *Server) RegisterWorker(ctx context.Context, req *masterpb.WorkerInfo) (
 	defer s.triggerSchedule()
 	existingWorker, exists := s.workers[req.WorkerId]
 	if exists {
-		existingWorker.Address = req.Address
-		if existingWorker.Status != WorkerStatus(req.WorkStatus) {
-			existingWorker.Status = WorkerStatus(req.WorkStatus)
+		if existingWorker.Status != Down {
+			heartbeatInterval.Observe(time.Since(existingWorker.UpdateTime).Seconds())
+		}
+		reported := WorkerStatus(req.WorkStatus)
+		if existingWorker.Status == Risking && reported == Idle {
+			reported = Risking // 风控冷却由 master 计时，checkWorkerHeartbeats 到期后解除
+		}
+		changed := existingWorker.Status != reported ||
+			existingWorker.TaskAssigned != req.TaskAssigned ||
+			existingWorker.Address != req.Address
+		if existingWorker.Status != reported {
+			existingWorker.Status = reported
 			s.triggerSchedule() //触发调度
 		}
+		existingWorker.Address = req.Address
 		existingWorker.TaskAssigned = req.TaskAssigned
+		existingWorker.PurchaseState = req.PurchaseState
 		existingWorker.UpdateTime = time.Now()
+		if changed {
+			s.persistWorker(existingWorker)
+		}
 
 		if req.TaskAssigned != "" {
 			task, exists := s.tasks[req.TaskAssigned]
 			if !exists {
-				return nil, fmt.Errorf("<%s> not found", req.TaskAssigned)
+				log.Warningf("Worker %s reports task <%s> which no longer exists", req.WorkerId, req.TaskAssigned)
+				if TaskStatus(req.TaskStatus) == TaskStatusDoing {
+					go s.stopTaskOnWorker(req.WorkerId, req.TaskAssigned, "deleted")
+				}
+				return &masterpb.RegisterReply{
+					Success: true,
+					Message: "Worker Update Successfully",
+				}, nil
+			}
+			if task.AssignedTo != req.WorkerId {
+				// 任务已被暂停或转交给其他 worker，忽略旧 worker 的状态上报并让它停下
+				log.Warningf("Worker %s reports task <%s> owned by <%s>, ignored", req.WorkerId, task.TaskName, task.AssignedTo)
+				if TaskStatus(req.TaskStatus) == TaskStatusDoing {
+					go s.stopTaskOnWorker(req.WorkerId, req.TaskAssigned, "superseded")
+				}
+				return &masterpb.RegisterReply{
+					Success: true,
+					Message: "Worker Update Successfully",
+				}, nil
 			}
-			if string(task.Status) != req.TaskStatus {
+			if string(task.Status) != req.TaskStatus && !task.Status.Finished() {
 				//task信息发生变化
 				oldStatus := task.Status
 				task.Status = TaskStatus(req.TaskStatus)
 				log.Printf("<%s> => <%s>: %s ", oldStatus, task.Status, task.TaskName)
+				s.persistTask(task)
 				s.triggerSchedule() //触发调度
 			}
+			if req.PurchaseState != "" {
+				task.PurchaseState = req.PurchaseState
+			}
 			task.UpdatedAt = time.Now() //心跳信息
 		}
 		return &masterpb.RegisterReply{
@@ -156,13 +319,15 @@ func (s *Server) RegisterWorker(ctx context.Context, req *masterpb.WorkerInfo) (
 		}, nil
 	}
 	newWorker := &Worker{
-		WorkerID:     req.WorkerId,
-		Address:      req.Address,
-		Status:       WorkerStatus(req.WorkStatus),
-		TaskAssigned: req.TaskAssigned,
-		UpdateTime:   time.Now(),
+		WorkerID:      req.WorkerId,
+		Address:       req.Address,
+		Status:        WorkerStatus(req.WorkStatus),
+		TaskAssigned:  req.TaskAssigned,
+		UpdateTime:    time.Now(),
+		PurchaseState: req.PurchaseState,
 	}
 	s.workers[req.WorkerId] = newWorker
+	s.persistWorker(newWorker)
 	log.Infof("Worker Register: ID=%s, Address=%s, WorkStatus=%s",
 		req.WorkerId, req.Address, WorkerStatus(req.WorkStatus).String())
 	return &masterpb.RegisterReply{
@@ -173,7 +338,7 @@ func (s *Server) RegisterWorker(ctx context.Context, req *masterpb.WorkerInfo) (
 
 // 心跳检查器
 func (s *Server) startHeartbeatChecker() {
-	ticker := time.NewTicker(5 * time.Second) // 每5秒检查一次
+	ticker := time.NewTicker(s.checkInterval)
 	defer ticker.Stop()
 	for {
 		select {
@@ -186,16 +351,29 @@ func (s *Server) startHeartbeatChecker() {
 }
 
 func (s *Server) Stop() {
+	s.CloseStreams()
 	close(s.stopChan)
+	if err := s.store.Close(); err != nil {
+		log.Errorf("[Store] 关闭失败: %v", err)
+	}
 	log.Println("Master Stopped")
 }
 
 func (s *Server) CreateTask(taskName, tickerConfigContent string) *TaskInfo {
+	return s.CreateScheduledTask(taskName, tickerConfigContent, TaskSchedule{})
+}
+
+// CreateScheduledTask 创建带有独立售票窗口、间隔和通知目标的任务
+func (s *Server) CreateScheduledTask(taskName, tickerConfigContent string, schedule TaskSchedule) *TaskInfo {
 	s.tasksMux.Lock()
 	defer s.tasksMux.Unlock()
 	defer s.triggerSchedule()
 
 	taskID := fmt.Sprintf("task-%d", time.Now().UnixNano())
+	return s.createTaskLocked(taskID, taskName, tickerConfigContent, schedule.content(), "", contentHash(tickerConfigContent))
+}
+
+func (s *Server) createTaskLocked(taskID, taskName, tickerConfigContent, scheduleContent, source, hash string) *TaskInfo {
 	task := &TaskInfo{
 		ID:                  taskID,
 		Status:              TaskStatusPending,
@@ -203,13 +381,22 @@ func (s *Server) CreateTask(taskName, tickerConfigContent string) *TaskInfo {
 		UpdatedAt:           time.Now(),
 		TaskName:            taskName,
 		TickerConfigContent: tickerConfigContent,
+		ScheduleContent:     scheduleContent,
+		Source:              source,
+		ContentHash:         hash,
 	}
-
-	s.tasks[taskID] = task
-	log.Printf("Create Task : ID=%s, name=%s", taskID, taskName)
+	s.addTaskLocked(task)
 	return task
 }
 
+// addTaskLocked 从配置中读取调度字段后加入任务表并持久化
+func (s *Server) addTaskLocked(task *TaskInfo) {
+	task.applyMeta()
+	s.tasks[task.ID] = task
+	s.persistTask(task)
+	log.Printf("Create Task : ID=%s, name=%s", task.ID, task.TaskName)
+}
+
 func (s *Server) checkWorkerHeartbeats() {
 	s.workersMux.Lock()
 	defer s.workersMux.Unlock()
@@ -225,19 +412,25 @@ func (s *Server) checkWorkerHeartbeats() {
 			log.Printf("[Offline] %s timeout (%.0fs), marked as DOWN", workerID, s.heartbeatTimeout.Seconds())
 			worker.Status = Down
 			offlineWorkers = append(offlineWorkers, workerID)
+			s.events.emit(EventWorkerDown, workerID, "worker %s（%s）%s 没有心跳，已下线", workerID, worker.Address, now.Sub(worker.UpdateTime).Round(time.Second))
 			if worker.TaskAssigned != "" {
-				log.Printf("[Reassign] %s task %s -> PENDING", workerID, worker.TaskAssigned)
 				s.tasksMux.Lock()
-				s.clearAndPendingTask(s.tasks[worker.TaskAssigned]) //重新分配
+				// TaskAssigned 可能是已经结束或删除的任务，只回收仍在执行的
+				if task, exists := s.tasks[worker.TaskAssigned]; exists && task.AssignedTo == workerID && task.Status == TaskStatusDoing {
+					log.Printf("[Reassign] %s task %s -> PENDING", workerID, worker.TaskAssigned)
+					s.clearAndPendingTask(task, fmt.Sprintf("worker %s offline", workerID))
+					s.triggerSchedule() //离线触发调度
+				}
 				s.tasksMux.Unlock()
-				s.triggerSchedule() //离线触发调度
 			}
 		} else if now.Sub(worker.BanTime) > s.banTimeout && worker.Status == Risking {
 			log.Printf("[Unban] %s rest time (%.0fs) ended, marked as IDLE", workerID, s.banTimeout.Seconds())
 			worker.Status = Idle
+			s.persistWorker(worker)
+			s.triggerSchedule()
 			ideWorkers = append(ideWorkers, workerID)
 		} else if worker.Status == Risking {
-			offlineWorkers = append(offlineWorkers, workerID)
+			riskingWorkers = append(riskingWorkers, workerID)
 		} else if worker.Status == Working {
 			workingWorkers = append(workingWorkers, workerID)
 		} else if worker.Status == Idle {
@@ -245,9 +438,15 @@ func (s *Server) checkWorkerHeartbeats() {
 		}
 	}
 	log.Printf("[Worker] Banned: %d, Idle: %d, Working: %d", len(riskingWorkers), len(ideWorkers), len(workingWorkers))
+	if len(riskingWorkers) > 0 && len(ideWorkers) == 0 && len(workingWorkers) == 0 {
+		s.events.emit(EventAllWorkersRisking, "", "%d 个在线 worker 都在风控冷却中，任务暂时无法执行", len(riskingWorkers))
+	}
 	// 清理离线worker
 	for _, workerID := range offlineWorkers {
 		delete(s.workers, workerID)
+		if err := s.store.DeleteWorker(workerID); err != nil {
+			log.Errorf("[Store] 删除 worker %s 失败: %v", workerID, err)
+		}
 	}
 }
 func (s *Server) triggerSchedule() {
@@ -281,34 +480,44 @@ func (s *Server) scheduleTasks() {
 	}
 
 	pendingTasks := make([]*TaskInfo, 0) //需要分配的task
+	pendingChecks := make([]*TaskInfo, 0)
 	for _, task := range s.tasks {
-		if task.Status == TaskStatusPending { //过滤一下，保证s.taskQueue 里面都是pendingTasks
+		if task.Status != TaskStatusPending { //过滤一下，保证s.taskQueue 里面都是pendingTasks
+			continue
+		}
+		if task.Kind == TaskKindSessionCheck {
+			pendingChecks = append(pendingChecks, task)
+		} else {
 			pendingTasks = append(pendingTasks, task)
 		}
 	}
+	// 在锁内排序，策略读取的字段可能被热加载修改
+	assignments := s.scheduler.Schedule(pendingTasks, idleWorkers)
+	// 账号检查只使用抢票任务分配后剩下的 worker，不经过调度策略
+	checks := scheduleSessionChecks(pendingChecks, idleWorkers, assignments)
 	s.workersMux.RUnlock()
 	s.tasksMux.Unlock()
 
-	assigned := 0
-	for i, task := range pendingTasks {
-		if i >= len(idleWorkers) {
-			break // not enough
-		}
-		worker := idleWorkers[i]
-		if s.assignTaskToWorker(task, worker) {
-			assigned++
+	observer, _ := s.scheduler.(AssignmentObserver)
+	for _, a := range assignments {
+		if s.assignTaskToWorker(a.Task, a.Worker) && observer != nil {
+			observer.Assigned(a)
 		}
 	}
+	for _, a := range checks {
+		s.assignTaskToWorker(a.Task, a.Worker)
+	}
 }
 
 // 整理需要重新分配的task，释放这些tasker
 func (s *Server) startTaskMonitor() {
-	ticker := time.NewTicker(5 * time.Second) // 每5秒检查一次
+	ticker := time.NewTicker(s.checkInterval)
 	defer ticker.Stop()
 	for {
 		select {
 		case <-ticker.C:
 			s.monitorTasks()
+			s.checkStartReadiness()
 		case <-s.stopChan:
 			return
 		}
@@ -322,88 +531,201 @@ func (s *Server) monitorTasks() {
 	now := time.Now()
 	pendingTasks := make([]*TaskInfo, 0)
 	doingTasks := make([]*TaskInfo, 0)
-	doneTasks := make([]*TaskInfo, 0)
+	doneTasks := make([]*TaskInfo, 0) //已结束，包括成功、失败和取消
 
-	DoneTaskNum := 0
+	pausedTasks := 0
+	failedTasks := 0
+	purchaseTasks := 0
+	pendingChecks := 0
+	timeoutTasks := make([]*TaskInfo, 0)
 	for _, task := range s.tasks {
-		if task.Status == TaskStatusDoing {
-			if now.Sub(task.UpdatedAt) > s.taskTimeout {
-				log.Printf("[Timeout] Task %s timeout, marked as PENDING", task.ID)
-				task.Status = TaskStatusPending
-				pendingTasks = append(pendingTasks, task)
-			} else {
-				doingTasks = append(doingTasks, task)
+		if task.Status == TaskStatusDoing && now.Sub(task.UpdatedAt) > s.taskTimeout {
+			log.Printf("[Timeout] Task %s timeout, marked as PENDING", task.ID)
+			timeoutTasks = append(timeoutTasks, task)
+			s.events.emit(EventTaskTimeout, task.ID, "任务 <%s> 在 worker %s 上 %s 没有更新，重新分配",
+				task.TaskName, task.AssignedTo, now.Sub(task.UpdatedAt).Round(time.Second))
+			continue
+		}
+		if task.Kind == TaskKindSessionCheck {
+			// 账号检查不计入任务统计，检查没有通过不算抢票任务失败
+			if task.Status == TaskStatusPending {
+				pendingChecks++
 			}
+			continue
+		}
+		purchaseTasks++
+		if task.Status == TaskStatusDoing {
+			doingTasks = append(doingTasks, task)
 		} else if task.Status == TaskStatusPending {
 			pendingTasks = append(pendingTasks, task)
-		} else if task.Status == TaskStatusDone {
+		} else if task.Status.Finished() {
 			doneTasks = append(doneTasks, task)
+			if task.Status == TaskStatusFailed {
+				failedTasks++
+			}
+		} else if task.Status == TaskStatusPaused {
+			pausedTasks++
 		}
 	}
-	if DoneTaskNum == len(s.tasks) {
+	// 任务可以通过管理接口随时添加，全部完成后 master 继续运行
+	allDone := purchaseTasks > 0 && len(doneTasks) == purchaseTasks
+	if allDone && !s.allDone {
 		log.Infof("[Complete] All tasks done")
-		log.Exit(0)
+		s.events.emit(EventAllTasksDone, "", "%d 个任务全部结束，其中失败 %d 个", len(doneTasks), failedTasks)
 	}
+	s.allDone = allDone
 
-	log.Infof("[Task] Pending: %d, Done: %d, Doing: %d", len(pendingTasks), len(doneTasks), len(doingTasks))
-	// 重新分配risking任务
-	if len(pendingTasks) > 0 {
-		defer s.triggerSchedule()
+	log.Infof("[Task] Pending: %d, Done: %d (Failed: %d), Doing: %d, Paused: %d",
+		len(pendingTasks)+len(timeoutTasks), len(doneTasks), failedTasks, len(doingTasks), pausedTasks)
+	// 重新分配超时任务，等待中的任务只需要再触发一次调度
+	for _, task := range timeoutTasks {
+		s.clearAndPendingTask(task, "task heartbeat timeout")
 	}
-	for _, task := range pendingTasks {
-		s.clearAndPendingTask(task)
+	if len(pendingTasks)+len(timeoutTasks)+pendingChecks > 0 {
+		s.triggerSchedule()
 	}
 }
 
 // 分配任务给worker
 func (s *Server) assignTaskToWorker(task *TaskInfo, worker *Worker) bool {
-	// 通过gRPC调用worker
-	conn, err := grpc.Dial(worker.Address, grpc.WithInsecure())
-	if err != nil {
-		log.Printf("[ConnectFail] Worker %s: %v", worker.WorkerID, err)
-		return false
-	}
-	defer conn.Close()
-
-	client := workerpb.NewTicketWorkerClient(conn)
-	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-	defer cancel()
-
-	req := &workerpb.TaskRequest{
-		TaskId:      task.ID,
-		TicketsInfo: task.TickerConfigContent,
-	}
-
-	reply, err := client.PushTask(ctx, req)
-	if err != nil {
-		log.Printf("[AssignFail] Worker %s: %v", worker.WorkerID, err)
+	// 先记录分配关系，worker 收到任务后立即发出的心跳才不会被当成无主任务
+	s.tasksMux.Lock()
+	if task.Status != TaskStatusPending {
+		s.tasksMux.Unlock()
 		return false
 	}
+	task.Status = TaskStatusDoing
+	task.AssignedTo = worker.WorkerID
+	task.UpdatedAt = time.Now()
+	s.tasksMux.Unlock()
 
-	if !reply.Success {
-		log.Printf("[Reject] Worker %s: %s", worker.WorkerID, reply.Message)
+	reply, err := s.pushTask(worker, task)
+	if err != nil || !reply.Success {
+		s.tasksMux.Lock()
+		if task.Status == TaskStatusDoing && task.AssignedTo == worker.WorkerID {
+			if err == nil && reply.TaskError {
+				// 任务本身有问题（例如配置无法解析），计入重试次数
+				s.clearAndPendingTask(task, fmt.Sprintf("rejected by %s: %s", worker.WorkerID, reply.Message))
+			} else {
+				task.Status = TaskStatusPending
+				task.AssignedTo = ""
+				task.PurchaseState = ""
+			}
+		}
+		s.tasksMux.Unlock()
+		if err != nil {
+			assignFailures.WithLabelValues("unreachable").Inc()
+			log.Printf("[AssignFail] Worker %s: %v", worker.WorkerID, err)
+		} else {
+			assignFailures.WithLabelValues("rejected").Inc()
+			log.Printf("[Reject] Worker %s: %s", worker.WorkerID, reply.Message)
+		}
 		return false
 	}
 
 	// 更新状态
 	s.tasksMux.Lock()
-	task.Status = TaskStatusDoing
-	task.AssignedTo = worker.WorkerID
-	task.UpdatedAt = time.Now()
+	s.persistTask(task)
 	s.tasksMux.Unlock()
 
 	s.workersMux.Lock()
 	worker.Status = Working
 	worker.TaskAssigned = task.ID
+	s.persistWorker(worker)
 	s.workersMux.Unlock()
-	log.Printf("[Assign] Task <%s> -> Worker <%s>", task.TaskName, worker.Address)
+	log.Printf("[Assign] Task <%s> -> Worker <%s>", task.TaskName, worker.WorkerID)
 	return true
 }
 
-// 重新分配任务
-func (s *Server) clearAndPendingTask(task *TaskInfo) {
+// dialPushTask 直连 worker 的 PushTask，兼容没有建立长连接的旧 worker
+func (s *Server) dialPushTask(ctx context.Context, address string, task *TaskInfo) (*masterpb.TaskAck, error) {
+	if address == "" {
+		return nil, fmt.Errorf("worker has neither stream nor address")
+	}
+	conn, err := grpc.Dial(address, grpc.WithInsecure())
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	client := workerpb.NewTicketWorkerClient(conn)
+	reply, err := client.PushTask(ctx, &workerpb.TaskRequest{
+		TaskId:      task.ID,
+		TicketsInfo: task.TickerConfigContent,
+		Schedule:    task.Schedule.toWorkerPB(),
+		TaskName:    task.TaskName,
+		Kind:        string(task.Kind),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &masterpb.TaskAck{
+		TaskId:    task.ID,
+		Success:   reply.Success,
+		Message:   reply.Message,
+		TaskError: reply.TaskError,
+	}, nil
+}
+
+// stopTaskOnWorker 通知 worker 停止执行任务，不持有任何锁时调用
+func (s *Server) stopTaskOnWorker(workerID, taskID, reason string) {
+	if session := s.getSession(workerID); session != nil {
+		if err := session.stop(taskID, reason); err != nil {
+			log.Printf("[StopFail] Worker %s: %v", workerID, err)
+			return
+		}
+		log.Printf("[Stop] Task <%s> on Worker <%s> (%s)", taskID, workerID, reason)
+		return
+	}
+
+	s.workersMux.RLock()
+	worker, exists := s.workers[workerID]
+	address := ""
+	if exists {
+		address = worker.Address
+	}
+	s.workersMux.RUnlock()
+	if !exists || address == "" {
+		log.Printf("[StopFail] Worker %s not reachable, task %s", workerID, taskID)
+		return
+	}
+
+	conn, err := grpc.Dial(address, grpc.WithInsecure())
+	if err != nil {
+		log.Printf("[ConnectFail] Worker %s: %v", workerID, err)
+		return
+	}
+	defer conn.Close()
+
+	client := workerpb.NewTicketWorkerClient(conn)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	reply, err := client.StopTask(ctx, &workerpb.StopTaskRequest{TaskId: taskID, Reason: reason})
+	if err != nil {
+		log.Printf("[StopFail] Worker %s: %v", workerID, err)
+		return
+	}
+	if !reply.Success {
+		log.Printf("[StopReject] Worker %s: %s", workerID, reply.Message)
+		return
+	}
+	log.Printf("[Stop] Task <%s> on Worker <%s> (%s)", taskID, workerID, reason)
+}
+
+// 重新分配任务，超过 maxRetries 的任务进入死信（Failed），需要运维手动 RequeueTask
+func (s *Server) clearAndPendingTask(task *TaskInfo, reason string) {
 	task.RetryCount++
+	task.LastError = reason
 	task.Status = TaskStatusPending
 	task.AssignedTo = ""
+	task.PurchaseState = "" // 上一个 worker 的阶段对重新分配的任务没有意义
 	task.UpdatedAt = time.Now()
+	if threshold := s.events.rules.ReassignThreshold; threshold > 0 && task.RetryCount == threshold+1 {
+		s.events.emit(EventTaskReassigned, task.ID, "任务 <%s> 已重新分配 %d 次，最近一次原因：%s", task.TaskName, task.RetryCount, reason)
+	}
+	if task.RetryCount > s.maxRetries {
+		task.Status = TaskStatusFailed
+		log.Errorf("[DeadLetter] Task <%s> exceeded %d retries, last error: %s", task.TaskName, s.maxRetries, reason)
+	}
+	s.persistTask(task)
 }
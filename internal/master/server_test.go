@@ -0,0 +1,113 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"context"
+	"testing"
+	"time"
+)
+
+func newTestServerWithTask(t *testing.T) (*Server, *TaskInfo) {
+	t.Helper()
+	s := NewServer(NewMemoryStore())
+	t.Cleanup(s.Stop)
+	task := s.CreateTask("a", "{}")
+	s.tasksMux.Lock()
+	task.Status = TaskStatusDoing
+	task.AssignedTo = "w-1"
+	s.tasksMux.Unlock()
+	s.workersMux.Lock()
+	s.workers["w-1"] = &Worker{WorkerID: "w-1", Status: Working, TaskAssigned: task.ID, UpdateTime: time.Now()}
+	s.workersMux.Unlock()
+	return s, task
+}
+
+func TestReportResult(t *testing.T) {
+	s, task := newTestServerWithTask(t)
+	ctx := context.Background()
+
+	if _, err := s.ReportResult(ctx, &masterpb.TaskResultInfo{TaskId: task.ID, WorkerId: "w-2", Status: string(TaskStatusSucceeded)}); err == nil {
+		t.Fatal("result from a worker that does not own the task should be rejected")
+	}
+	if _, err := s.ReportResult(ctx, &masterpb.TaskResultInfo{TaskId: task.ID, WorkerId: "w-1", Status: string(TaskStatusDoing)}); err == nil {
+		t.Fatal("non-terminal status should be rejected")
+	}
+	_, err := s.ReportResult(ctx, &masterpb.TaskResultInfo{
+		TaskId: task.ID, WorkerId: "w-1", Status: string(TaskStatusSucceeded),
+		Errno: 0, OrderId: 123, PayMoney: 38000, Attempts: 4, DurationMs: 1500,
+	})
+	if err != nil {
+		t.Fatalf("ReportResult: %v", err)
+	}
+
+	// 之后的心跳不能覆盖已结束的状态
+	_, _ = s.RegisterWorker(ctx, &masterpb.WorkerInfo{WorkerId: "w-1", WorkStatus: int32(Idle), TaskAssigned: task.ID, TaskStatus: string(TaskStatusDone)})
+
+	s.tasksMux.RLock()
+	defer s.tasksMux.RUnlock()
+	if task.Status != TaskStatusSucceeded {
+		t.Fatalf("expected Succeeded, got %s", task.Status)
+	}
+	if task.Result == nil || task.Result.OrderId != 123 || task.Result.Attempts != 4 || task.Result.Duration != 1500*time.Millisecond {
+		t.Fatalf("unexpected result: %+v", task.Result)
+	}
+}
+
+func TestCancelTaskRiskingRequeues(t *testing.T) {
+	s, task := newTestServerWithTask(t)
+	ctx := context.Background()
+	_, _ = s.RegisterWorker(ctx, &masterpb.WorkerInfo{WorkerId: "w-1", WorkStatus: int32(Working), TaskAssigned: task.ID, TaskStatus: string(TaskStatusDoing), PurchaseState: "Creating"})
+
+	if _, err := s.CancelTask(ctx, &masterpb.CancelTaskInfo{CancelTaskId: task.ID, WorkerId: "w-1", WorkStatus: int32(Risking)}); err != nil {
+		t.Fatalf("CancelTask: %v", err)
+	}
+	// worker 空闲后上报 Idle，冷却期内仍保持 Risking
+	if _, err := s.RegisterWorker(ctx, &masterpb.WorkerInfo{WorkerId: "w-1", WorkStatus: int32(Idle)}); err != nil {
+		t.Fatalf("RegisterWorker: %v", err)
+	}
+
+	s.tasksMux.RLock()
+	if task.Status != TaskStatusPending || task.AssignedTo != "" || task.RetryCount != 1 || task.PurchaseState != "" {
+		t.Errorf("task should be requeued, got %+v", task)
+	}
+	s.tasksMux.RUnlock()
+	s.workersMux.RLock()
+	defer s.workersMux.RUnlock()
+	if w := s.workers["w-1"]; w.Status != Risking || w.BanTime.IsZero() {
+		t.Errorf("worker should stay Risking during cooldown, got %s", w.Status)
+	}
+}
+
+func TestRetryBudgetDeadLetter(t *testing.T) {
+	s, task := newTestServerWithTask(t)
+	ctx := context.Background()
+	admin := NewAdminServer(s)
+
+	s.tasksMux.Lock()
+	for i := 0; i < s.maxRetries; i++ {
+		s.clearAndPendingTask(task, "risk control")
+		if task.Status != TaskStatusPending {
+			t.Fatalf("retry %d should stay pending, got %s", i+1, task.Status)
+		}
+	}
+	s.clearAndPendingTask(task, "config broken")
+	s.tasksMux.Unlock()
+
+	dead, err := admin.ListDeadLetters(ctx, &masterpb.ListDeadLettersRequest{})
+	if err != nil || len(dead.Tasks) != 1 {
+		t.Fatalf("ListDeadLetters: %+v %v", dead, err)
+	}
+	if dead.Tasks[0].LastError != "config broken" || dead.Tasks[0].Status != string(TaskStatusFailed) {
+		t.Fatalf("unexpected dead letter: %+v", dead.Tasks[0])
+	}
+
+	if _, err := admin.RequeueTask(ctx, &masterpb.TaskIdRequest{TaskId: task.ID}); err != nil {
+		t.Fatalf("RequeueTask: %v", err)
+	}
+	s.tasksMux.RLock()
+	defer s.tasksMux.RUnlock()
+	if task.Status != TaskStatusPending || task.RetryCount != 0 {
+		t.Fatalf("requeued task should be pending with zero retries, got %+v", task)
+	}
+}
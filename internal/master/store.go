@@ -0,0 +1,332 @@
+package master
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// TaskStore 持久化 master 的任务和 worker 状态，用于重启后恢复
+type TaskStore interface {
+	SaveTask(task *TaskInfo) error
+	DeleteTask(taskID string) error
+	SaveWorker(worker *Worker) error
+	DeleteWorker(workerID string) error
+	// Load 返回上次持久化的全部任务和 worker
+	Load() ([]*TaskInfo, []*Worker, error)
+	Close() error
+}
+
+// memoryStore 不做任何持久化，未配置 STORE_PATH 时使用
+type memoryStore struct{}
+
+func NewMemoryStore() TaskStore { return memoryStore{} }
+
+func (memoryStore) SaveTask(*TaskInfo) error              { return nil }
+func (memoryStore) DeleteTask(string) error               { return nil }
+func (memoryStore) SaveWorker(*Worker) error              { return nil }
+func (memoryStore) DeleteWorker(string) error             { return nil }
+func (memoryStore) Load() ([]*TaskInfo, []*Worker, error) { return nil, nil, nil }
+func (memoryStore) Close() error                          { return nil }
+
+const (
+	walFileName      = "master.wal"
+	snapshotFileName = "master.snapshot"
+	// 默认每写入多少条 WAL 记录做一次快照
+	defaultSnapshotEvery = 1000
+)
+
+type walOp string
+
+const (
+	opSaveTask     walOp = "save_task"
+	opDeleteTask   walOp = "delete_task"
+	opSaveWorker   walOp = "save_worker"
+	opDeleteWorker walOp = "delete_worker"
+)
+
+type walRecord struct {
+	Op       walOp     `json:"op"`
+	Task     *TaskInfo `json:"task,omitempty"`
+	TaskID   string    `json:"task_id,omitempty"`
+	Worker   *Worker   `json:"worker,omitempty"`
+	WorkerID string    `json:"worker_id,omitempty"`
+}
+
+type snapshot struct {
+	Tasks   map[string]*TaskInfo `json:"tasks"`
+	Workers map[string]*Worker   `json:"workers"`
+}
+
+// FileStore 使用本地目录下的 WAL + 快照实现 TaskStore。
+// 每次写入只追加到 WAL，由后台 goroutine 合并 fsync（调用方通常持有 tasksMux，不能等磁盘），
+// 进程崩溃不丢数据，机器掉电最多丢失最近一次 fsync 之后的记录。
+// WAL 记录数达到 snapshotEvery 后把当前状态写成快照并截断 WAL。
+// 任务配置里有登录 cookie，目录和文件只对当前用户可读写。
+type FileStore struct {
+	mu            sync.Mutex
+	dir           string
+	wal           *os.File
+	walRecords    int
+	snapshotEvery int
+	state         snapshot
+	syncCh        chan struct{} // 有未 fsync 的记录
+	closed        chan struct{}
+}
+
+// OpenFileStore 打开（或创建）dir 下的存储，并回放已有的快照和 WAL
+func OpenFileStore(dir string) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("create store dir: %w", err)
+	}
+	fs := &FileStore{
+		dir:           dir,
+		snapshotEvery: defaultSnapshotEvery,
+		syncCh:        make(chan struct{}, 1),
+		closed:        make(chan struct{}),
+		state: snapshot{
+			Tasks:   make(map[string]*TaskInfo),
+			Workers: make(map[string]*Worker),
+		},
+	}
+	if err := fs.readSnapshot(); err != nil {
+		return nil, err
+	}
+	if err := fs.replayWAL(); err != nil {
+		return nil, err
+	}
+	wal, err := os.OpenFile(fs.walPath(), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("open wal: %w", err)
+	}
+	// 旧版本创建的 WAL 是 0644
+	if err := wal.Chmod(0o600); err != nil {
+		wal.Close()
+		return nil, fmt.Errorf("chmod wal: %w", err)
+	}
+	fs.wal = wal
+	go fs.syncLoop()
+	return fs, nil
+}
+
+func (fs *FileStore) walPath() string      { return filepath.Join(fs.dir, walFileName) }
+func (fs *FileStore) snapshotPath() string { return filepath.Join(fs.dir, snapshotFileName) }
+
+func (fs *FileStore) readSnapshot() error {
+	data, err := os.ReadFile(fs.snapshotPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("read snapshot: %w", err)
+	}
+	var snap snapshot
+	if err := json.Unmarshal(data, &snap); err != nil {
+		return fmt.Errorf("decode snapshot: %w", err)
+	}
+	for id, task := range snap.Tasks {
+		fs.state.Tasks[id] = task
+	}
+	for id, worker := range snap.Workers {
+		fs.state.Workers[id] = worker
+	}
+	return nil
+}
+
+func (fs *FileStore) replayWAL() error {
+	f, err := os.Open(fs.walPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("open wal: %w", err)
+	}
+	defer f.Close()
+
+	reader := bufio.NewReader(f)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if errors.Is(err, io.EOF) {
+			if len(line) > 0 {
+				// 崩溃时写了一半的最后一条记录，直接丢弃
+				log.Warningf("[Store] 丢弃不完整的 WAL 记录: %d bytes", len(line))
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("read wal: %w", err)
+		}
+		var rec walRecord
+		if err := json.Unmarshal(line, &rec); err != nil {
+			log.Warningf("[Store] 跳过无法解析的 WAL 记录: %v", err)
+			continue
+		}
+		fs.apply(&rec)
+		fs.walRecords++
+	}
+}
+
+func (fs *FileStore) apply(rec *walRecord) {
+	switch rec.Op {
+	case opSaveTask:
+		if rec.Task != nil {
+			fs.state.Tasks[rec.Task.ID] = rec.Task
+		}
+	case opDeleteTask:
+		delete(fs.state.Tasks, rec.TaskID)
+	case opSaveWorker:
+		if rec.Worker != nil {
+			fs.state.Workers[rec.Worker.WorkerID] = rec.Worker
+		}
+	case opDeleteWorker:
+		delete(fs.state.Workers, rec.WorkerID)
+	}
+}
+
+func (fs *FileStore) append(rec *walRecord) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.wal == nil {
+		return fmt.Errorf("store closed")
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return fmt.Errorf("encode wal record: %w", err)
+	}
+	data = append(data, '\n')
+	if _, err := fs.wal.Write(data); err != nil {
+		return fmt.Errorf("write wal: %w", err)
+	}
+	select {
+	case fs.syncCh <- struct{}{}:
+	default: // 已有一次 fsync 在排队，会一起落盘
+	}
+	fs.apply(rec)
+	fs.walRecords++
+	if fs.walRecords >= fs.snapshotEvery {
+		return fs.snapshotLocked()
+	}
+	return nil
+}
+
+// syncLoop 在锁外 fsync WAL，等待期间追加的记录由下一次 fsync 一起落盘
+func (fs *FileStore) syncLoop() {
+	for {
+		select {
+		case <-fs.syncCh:
+		case <-fs.closed:
+			return
+		}
+		fs.mu.Lock()
+		wal := fs.wal
+		fs.mu.Unlock()
+		if wal == nil {
+			return
+		}
+		if err := wal.Sync(); err != nil && !errors.Is(err, os.ErrClosed) {
+			log.Errorf("[Store] fsync WAL 失败: %v", err)
+		}
+	}
+}
+
+func (fs *FileStore) SaveTask(task *TaskInfo) error {
+	t := *task
+	return fs.append(&walRecord{Op: opSaveTask, Task: &t})
+}
+
+func (fs *FileStore) DeleteTask(taskID string) error {
+	return fs.append(&walRecord{Op: opDeleteTask, TaskID: taskID})
+}
+
+func (fs *FileStore) SaveWorker(worker *Worker) error {
+	w := *worker
+	return fs.append(&walRecord{Op: opSaveWorker, Worker: &w})
+}
+
+func (fs *FileStore) DeleteWorker(workerID string) error {
+	return fs.append(&walRecord{Op: opDeleteWorker, WorkerID: workerID})
+}
+
+func (fs *FileStore) Load() ([]*TaskInfo, []*Worker, error) {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	tasks := make([]*TaskInfo, 0, len(fs.state.Tasks))
+	for _, task := range fs.state.Tasks {
+		t := *task
+		tasks = append(tasks, &t)
+	}
+	workers := make([]*Worker, 0, len(fs.state.Workers))
+	for _, worker := range fs.state.Workers {
+		w := *worker
+		workers = append(workers, &w)
+	}
+	return tasks, workers, nil
+}
+
+// Snapshot 立即把当前状态写入快照并截断 WAL
+func (fs *FileStore) Snapshot() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.snapshotLocked()
+}
+
+func (fs *FileStore) snapshotLocked() error {
+	data, err := json.Marshal(&fs.state)
+	if err != nil {
+		return fmt.Errorf("encode snapshot: %w", err)
+	}
+	tmp := fs.snapshotPath() + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, fs.snapshotPath()); err != nil {
+		return fmt.Errorf("rename snapshot: %w", err)
+	}
+	// 快照落盘后再截断 WAL，中途崩溃最多重复回放一次
+	if fs.wal != nil {
+		if err := fs.wal.Truncate(0); err != nil {
+			return fmt.Errorf("truncate wal: %w", err)
+		}
+		if _, err := fs.wal.Seek(0, io.SeekStart); err != nil {
+			return fmt.Errorf("seek wal: %w", err)
+		}
+	}
+	fs.walRecords = 0
+	return nil
+}
+
+func (fs *FileStore) Close() error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	if fs.wal == nil {
+		return nil
+	}
+	close(fs.closed)
+	err := fs.snapshotLocked()
+	if cerr := fs.wal.Close(); err == nil {
+		err = cerr
+	}
+	fs.wal = nil
+	return err
+}
+
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,156 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestFileStoreReplay(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("OpenFileStore: %v", err)
+	}
+	task := &TaskInfo{ID: "task-1", TaskName: "a", Status: TaskStatusPending, CreatedAt: time.Now()}
+	if err := store.SaveTask(task); err != nil {
+		t.Fatalf("SaveTask: %v", err)
+	}
+	task.Status = TaskStatusDone
+	if err := store.SaveTask(task); err != nil {
+		t.Fatalf("SaveTask: %v", err)
+	}
+	if err := store.SaveWorker(&Worker{WorkerID: "w-1", Status: Working}); err != nil {
+		t.Fatalf("SaveWorker: %v", err)
+	}
+	if err := store.SaveWorker(&Worker{WorkerID: "w-2", Status: Idle}); err != nil {
+		t.Fatalf("SaveWorker: %v", err)
+	}
+	if err := store.DeleteWorker("w-2"); err != nil {
+		t.Fatalf("DeleteWorker: %v", err)
+	}
+	// 模拟崩溃：不调用 Close，直接丢弃句柄并追加半条记录
+	store.wal.Close()
+	f, err := os.OpenFile(filepath.Join(dir, walFileName), os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.WriteString(`{"op":"save_task","task":{"ID":"task-2"`)
+	f.Close()
+
+	reopened, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	tasks, workers, err := reopened.Load()
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if len(tasks) != 1 || tasks[0].ID != "task-1" || tasks[0].Status != TaskStatusDone {
+		t.Fatalf("unexpected tasks: %+v", tasks)
+	}
+	if len(workers) != 1 || workers[0].WorkerID != "w-1" || workers[0].Status != Working {
+		t.Fatalf("unexpected workers: %+v", workers)
+	}
+}
+
+func TestFileStoreSnapshot(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("OpenFileStore: %v", err)
+	}
+	store.snapshotEvery = 3
+	for _, id := range []string{"task-1", "task-2", "task-3", "task-4"} {
+		if err := store.SaveTask(&TaskInfo{ID: id, Status: TaskStatusPending}); err != nil {
+			t.Fatalf("SaveTask: %v", err)
+		}
+	}
+	if store.walRecords != 1 {
+		t.Fatalf("wal should be truncated after snapshot, records=%d", store.walRecords)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if info, err := os.Stat(filepath.Join(dir, walFileName)); err != nil || info.Size() != 0 {
+		t.Fatalf("wal should be empty after Close: %v %v", info, err)
+	}
+
+	reopened, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	defer reopened.Close()
+	tasks, _, _ := reopened.Load()
+	if len(tasks) != 4 {
+		t.Fatalf("expected 4 tasks from snapshot, got %d", len(tasks))
+	}
+}
+
+func TestFileStorePermissions(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "store")
+	store, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("OpenFileStore: %v", err)
+	}
+	// 任务配置中有 cookie
+	if err := store.SaveTask(&TaskInfo{ID: "task-1", TickerConfigContent: `{"cookies":[]}`}); err != nil {
+		t.Fatalf("SaveTask: %v", err)
+	}
+	if err := store.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	for name, want := range map[string]os.FileMode{"": 0o700, walFileName: 0o600, snapshotFileName: 0o600} {
+		info, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := info.Mode().Perm(); got != want {
+			t.Errorf("%s mode = %o, want %o", filepath.Join(dir, name), got, want)
+		}
+	}
+}
+
+func TestServerRecoverSkipsDoneTasks(t *testing.T) {
+	dir := t.TempDir()
+	store, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("OpenFileStore: %v", err)
+	}
+	_ = store.SaveTask(&TaskInfo{ID: "task-1", TaskName: "done", Status: TaskStatusDone})
+	_ = store.SaveTask(&TaskInfo{ID: "task-2", TaskName: "doing", Status: TaskStatusDoing, AssignedTo: "w-1"})
+	_ = store.SaveWorker(&Worker{WorkerID: "w-1", Status: Working, TaskAssigned: "task-2"})
+	store.Close()
+
+	configDir := t.TempDir()
+	for _, name := range []string{"done", "doing", "new"} {
+		if err := os.WriteFile(filepath.Join(configDir, name+".json"), []byte(ticketConfig(name)), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	reopened, err := OpenFileStore(dir)
+	if err != nil {
+		t.Fatalf("reopen: %v", err)
+	}
+	s := NewServer(reopened)
+	defer s.Stop()
+	if err := s.LoadTasksFromDir(configDir); err != nil {
+		t.Fatalf("LoadTasksFromDir: %v", err)
+	}
+
+	s.tasksMux.RLock()
+	defer s.tasksMux.RUnlock()
+	if len(s.tasks) != 3 {
+		t.Fatalf("expected 3 tasks, got %d", len(s.tasks))
+	}
+	if s.tasks["task-1"].Status != TaskStatusDone {
+		t.Errorf("done task should stay done, got %s", s.tasks["task-1"].Status)
+	}
+	if s.tasks["task-2"].Status != TaskStatusDoing || s.tasks["task-2"].AssignedTo != "w-1" {
+		t.Errorf("doing task should keep its assignment, got %+v", s.tasks["task-2"])
+	}
+}
@@ -0,0 +1,211 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"context"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sync"
+	"time"
+)
+
+// workerSession worker 通过 Connect 建立的长连接
+type workerSession struct {
+	workerID string
+	stream   masterpb.TicketMaster_ConnectServer
+	sendMu   sync.Mutex // grpc stream 不允许并发 Send
+	clock    clockEstimator
+
+	pendingMu sync.Mutex
+	pending   map[string]chan *masterpb.TaskAck // task_id -> 等待中的 assign
+}
+
+func newWorkerSession(workerID string, stream masterpb.TicketMaster_ConnectServer) *workerSession {
+	return &workerSession{
+		workerID: workerID,
+		stream:   stream,
+		pending:  make(map[string]chan *masterpb.TaskAck),
+	}
+}
+
+func (ws *workerSession) send(msg *masterpb.MasterMessage) error {
+	ws.sendMu.Lock()
+	defer ws.sendMu.Unlock()
+	return ws.stream.Send(msg)
+}
+
+// assign 下发任务并等待 worker 的 TaskAck
+func (ws *workerSession) assign(ctx context.Context, task *masterpb.AssignTask) (*masterpb.TaskAck, error) {
+	taskID := task.TaskId
+	ackChan := make(chan *masterpb.TaskAck, 1)
+	ws.pendingMu.Lock()
+	ws.pending[taskID] = ackChan
+	ws.pendingMu.Unlock()
+	defer func() {
+		ws.pendingMu.Lock()
+		if ws.pending[taskID] == ackChan {
+			delete(ws.pending, taskID)
+		}
+		ws.pendingMu.Unlock()
+	}()
+
+	err := ws.send(&masterpb.MasterMessage{Payload: &masterpb.MasterMessage_Assign{
+		Assign: task,
+	}})
+	if err != nil {
+		return nil, err
+	}
+	select {
+	case ack := <-ackChan:
+		return ack, nil
+	case <-ctx.Done():
+		return nil, fmt.Errorf("wait ack: %w", ctx.Err())
+	case <-ws.stream.Context().Done():
+		return nil, fmt.Errorf("stream closed: %w", ws.stream.Context().Err())
+	}
+}
+
+// resolve 把 TaskAck 交给等待中的 assign。重复或超时后才到的 ack 直接丢弃，不能阻塞接收循环
+func (ws *workerSession) resolve(ack *masterpb.TaskAck) {
+	ws.pendingMu.Lock()
+	ackChan, exists := ws.pending[ack.TaskId]
+	delete(ws.pending, ack.TaskId)
+	ws.pendingMu.Unlock()
+	if !exists {
+		log.Warningf("[Stream] Worker %s ack for unknown assignment <%s>", ws.workerID, ack.TaskId)
+		return
+	}
+	select {
+	case ackChan <- ack:
+	default:
+	}
+}
+
+func (ws *workerSession) stop(taskID, reason string) error {
+	return ws.send(&masterpb.MasterMessage{Payload: &masterpb.MasterMessage_Stop{
+		Stop: &masterpb.StopTaskCommand{TaskId: taskID, Reason: reason},
+	}})
+}
+
+// Connect 处理 worker 发起的双向流，连接期间 master 通过它下发任务和停止命令
+func (s *Server) Connect(stream masterpb.TicketMaster_ConnectServer) error {
+	first, err := stream.Recv()
+	if err != nil {
+		return err
+	}
+	hello := first.GetHeartbeat()
+	if hello == nil || hello.WorkerId == "" {
+		return fmt.Errorf("first message must be a heartbeat with worker_id")
+	}
+	session := newWorkerSession(hello.WorkerId, stream)
+	s.sessionsMux.Lock()
+	s.sessions[hello.WorkerId] = session
+	s.sessionsMux.Unlock()
+	log.Infof("[Stream] Worker %s connected", hello.WorkerId)
+	go s.probeClock(session)
+	defer func() {
+		s.sessionsMux.Lock()
+		if s.sessions[hello.WorkerId] == session {
+			delete(s.sessions, hello.WorkerId)
+		}
+		s.sessionsMux.Unlock()
+		log.Infof("[Stream] Worker %s disconnected", hello.WorkerId)
+	}()
+
+	// Recv 不能被取消，放在单独的 goroutine 中，master 关闭时不用等 worker 断开
+	msgs := make(chan *masterpb.WorkerMessage)
+	recvErr := make(chan error, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				recvErr <- err
+				return
+			}
+			select {
+			case msgs <- msg:
+			case <-stream.Context().Done():
+				return
+			}
+		}
+	}()
+	s.handleWorkerMessage(stream.Context(), session, first)
+	for {
+		select {
+		case msg := <-msgs:
+			s.handleWorkerMessage(stream.Context(), session, msg)
+		case err := <-recvErr:
+			return err
+		case <-s.streamsDone:
+			return status.Error(codes.Unavailable, "master is shutting down")
+		}
+	}
+}
+
+// CloseStreams 结束所有 worker 长连接，worker 会自动重连。
+// grpc 的 GracefulStop 会等待 Connect 返回，必须在它之前调用
+func (s *Server) CloseStreams() {
+	s.closeStream.Do(func() { close(s.streamsDone) })
+}
+
+func (s *Server) handleWorkerMessage(ctx context.Context, session *workerSession, msg *masterpb.WorkerMessage) {
+	var err error
+	switch payload := msg.Payload.(type) {
+	case *masterpb.WorkerMessage_Heartbeat:
+		payload.Heartbeat.WorkerId = session.workerID
+		_, err = s.RegisterWorker(ctx, payload.Heartbeat)
+	case *masterpb.WorkerMessage_CancelTask:
+		payload.CancelTask.WorkerId = session.workerID
+		_, err = s.CancelTask(ctx, payload.CancelTask)
+	case *masterpb.WorkerMessage_Result:
+		payload.Result.WorkerId = session.workerID
+		_, err = s.ReportResult(ctx, payload.Result)
+	case *masterpb.WorkerMessage_TaskAck:
+		session.resolve(payload.TaskAck)
+	case *masterpb.WorkerMessage_ClockPong:
+		session.clock.add(sampleFromPong(payload.ClockPong, s.clock.Now()))
+	case *masterpb.WorkerMessage_StartReport:
+		err = s.recordStart(session, payload.StartReport)
+	default:
+		err = fmt.Errorf("unknown message %T", payload)
+	}
+	if err != nil {
+		log.Warningf("[Stream] Worker %s: %v", session.workerID, err)
+	}
+}
+
+func (s *Server) getSession(workerID string) *workerSession {
+	s.sessionsMux.RLock()
+	defer s.sessionsMux.RUnlock()
+	return s.sessions[workerID]
+}
+
+// pushTask 通过长连接下发任务，没有长连接的旧 worker 回退到直连 PushTask
+func (s *Server) pushTask(worker *Worker, task *TaskInfo) (*masterpb.TaskAck, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if session := s.getSession(worker.WorkerID); session != nil {
+		assign := &masterpb.AssignTask{
+			TaskId:      task.ID,
+			TicketsInfo: task.TickerConfigContent,
+			Schedule:    task.Schedule.toPB(),
+			TaskName:    task.TaskName,
+			Kind:        string(task.Kind),
+		}
+		// 开抢前分配的抢票任务由 master 在 T0 发出开始信号，worker 的本地时钟只作为兜底
+		startAt := task.Schedule.StartAt
+		sample, _ := session.clock.best() // 还没测量时按没有偏差处理
+		if task.Kind == TaskKindPurchase && s.clock.Now().Before(startAt) {
+			assign.SyncStart = true
+			assign.ClockOffsetNs = int64(sample.offset)
+		}
+		ack, err := session.assign(ctx, assign)
+		if err == nil && ack.Success && assign.SyncStart {
+			s.armStartSignal(task.ID, startAt, sample.rtt)
+		}
+		return ack, err
+	}
+	return s.dialPushTask(ctx, worker.Address, task)
+}
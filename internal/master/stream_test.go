@@ -0,0 +1,189 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func startStreamServer(t *testing.T, s *Server) masterpb.TicketMasterClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	masterpb.RegisterTicketMasterServer(gs, s)
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return masterpb.NewTicketMasterClient(conn)
+}
+
+// recvWithin 读取下一条非时钟测量的 master 消息
+func recvWithin(t *testing.T, stream masterpb.TicketMaster_ConnectClient, d time.Duration) *masterpb.MasterMessage {
+	t.Helper()
+	ch := make(chan *masterpb.MasterMessage, 1)
+	go func() {
+		for {
+			msg, err := stream.Recv()
+			if err != nil {
+				close(ch)
+				return
+			}
+			if msg.GetClockPing() != nil {
+				continue // 时钟测量与测试无关
+			}
+			ch <- msg
+			return
+		}
+	}()
+	select {
+	case msg, ok := <-ch:
+		if !ok {
+			t.Fatal("stream closed")
+		}
+		return msg
+	case <-time.After(d):
+		t.Fatal("timeout waiting for master message")
+	}
+	return nil
+}
+
+func TestStreamAssignAndStop(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+	defer s.Stop()
+	client := startStreamServer(t, s)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := client.Connect(ctx)
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	hello := &masterpb.WorkerInfo{WorkerId: "w-1", WorkStatus: int32(Idle)}
+	if err := stream.Send(&masterpb.WorkerMessage{Payload: &masterpb.WorkerMessage_Heartbeat{Heartbeat: hello}}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	task := s.CreateTask("a", `{"username":"u"}`)
+	assign := recvWithin(t, stream, 5*time.Second).GetAssign()
+	if assign == nil || assign.TaskId != task.ID || assign.TicketsInfo != `{"username":"u"}` {
+		t.Fatalf("unexpected assign: %+v", assign)
+	}
+	// worker 在应答之前就发出 Doing 心跳，不能被当成无主任务
+	doing := &masterpb.WorkerInfo{WorkerId: "w-1", WorkStatus: int32(Working), TaskAssigned: task.ID, TaskStatus: string(TaskStatusDoing)}
+	_ = stream.Send(&masterpb.WorkerMessage{Payload: &masterpb.WorkerMessage_Heartbeat{Heartbeat: doing}})
+	_ = stream.Send(&masterpb.WorkerMessage{Payload: &masterpb.WorkerMessage_TaskAck{TaskAck: &masterpb.TaskAck{TaskId: task.ID, Success: true}}})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		s.workersMux.RLock()
+		status := s.workers["w-1"].Status
+		s.workersMux.RUnlock()
+		if status == Working {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("worker should be Working after ack, got %s", status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := s.DeleteTask(task.ID); err != nil {
+		t.Fatalf("DeleteTask: %v", err)
+	}
+	stop := recvWithin(t, stream, 5*time.Second).GetStop()
+	if stop == nil || stop.TaskId != task.ID || stop.Reason != "deleted" {
+		t.Fatalf("unexpected stop: %+v", stop)
+	}
+}
+
+func TestResolveDuplicateAckDoesNotBlock(t *testing.T) {
+	ws := newWorkerSession("w-1", nil)
+	ackChan := make(chan *masterpb.TaskAck, 1)
+	ws.pending["t-1"] = ackChan
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ws.resolve(&masterpb.TaskAck{TaskId: "t-1", Success: true})
+		ws.resolve(&masterpb.TaskAck{TaskId: "t-1", Success: true}) // 重复的 ack
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("duplicate ack blocked resolve")
+	}
+	if len(ackChan) != 1 || len(ws.pending) != 0 {
+		t.Fatalf("ack should be delivered once and pending cleared: %d %d", len(ackChan), len(ws.pending))
+	}
+}
+
+func TestAppStopWithConnectedWorker(t *testing.T) {
+	cfg := DefaultConfig()
+	cfg.HTTPAddr, cfg.NTPServers = "", nil
+	cfg.StorePath = t.TempDir()
+	app, err := New(cfg)
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	if err := app.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	lis := bufconn.Listen(1 << 20)
+	go func() { _ = app.Serve(lis) }()
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	defer conn.Close()
+	stream, err := masterpb.NewTicketMasterClient(conn).Connect(context.Background())
+	if err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	if err := stream.Send(&masterpb.WorkerMessage{Payload: &masterpb.WorkerMessage_Heartbeat{
+		Heartbeat: &masterpb.WorkerInfo{WorkerId: "w-1", WorkStatus: int32(Idle)},
+	}}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); ; time.Sleep(time.Millisecond) {
+		app.Server.sessionsMux.RLock()
+		_, connected := app.Server.sessions["w-1"]
+		app.Server.sessionsMux.RUnlock()
+		if connected {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("worker stream not connected")
+		}
+	}
+
+	// worker 没有断开时 master 也要能停下并写快照
+	stopped := make(chan struct{})
+	go func() {
+		app.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("App.Stop hung with a connected worker")
+	}
+	if _, err := os.Stat(filepath.Join(cfg.StorePath, snapshotFileName)); err != nil {
+		t.Fatalf("snapshot not written: %v", err)
+	}
+}
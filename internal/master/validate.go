@@ -0,0 +1,132 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/ticketconfig"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RunValidate 实现 master validate 子命令：按加载配置目录时的规则检查配置文件或目录，
+// 返回进程退出码，有文件没有通过检查时为 1
+func RunValidate(args []string, out io.Writer) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	fs.SetOutput(out)
+	timeStart := fs.String("time-start", "", "计划开抢时间，格式 2006-01-02T15:04（北京时间），默认取 schedule 文件中的 time_start")
+	fs.Usage = func() {
+		fmt.Fprintln(out, "用法: master validate [-time-start 2006-01-02T15:04] <配置文件或目录>...")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 2
+	}
+	var startAt time.Time
+	if *timeStart != "" {
+		t, err := ParseTicketTime(*timeStart)
+		if err != nil {
+			fmt.Fprintf(out, "-time-start 格式错误: %v\n", err)
+			return 2
+		}
+		startAt = t
+	}
+
+	var files []*configFile
+	broken := make(map[string]error)
+	for _, path := range fs.Args() {
+		found, unreadable, err := validateTargets(path)
+		if err != nil {
+			fmt.Fprintf(out, "❌ %s\n   - %v\n", path, err)
+			return 2
+		}
+		files = append(files, found...)
+		for p, err := range unreadable {
+			files = append(files, &configFile{path: p})
+			broken[p] = err
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool { return files[i].path < files[j].path })
+
+	rejected := 0
+	now := time.Now()
+	for _, file := range files {
+		// schedule 文件格式错误时开抢时间和通知目标都无从谈起，直接判为没有通过
+		err, ok := broken[file.path]
+		if !ok {
+			at := startAt
+			if at.IsZero() {
+				at = plannedStart(file, now)
+			}
+			err = lintConfigFile(file, at)
+		}
+		if err == nil {
+			fmt.Fprintf(out, "✅ %s\n", file.path)
+			continue
+		}
+		rejected++
+		fmt.Fprintf(out, "❌ %s\n", file.path)
+		var cfgErr *ticketconfig.ConfigError
+		if errors.As(err, &cfgErr) {
+			for _, p := range cfgErr.Problems {
+				fmt.Fprintf(out, "   - %s\n", p)
+			}
+		} else {
+			fmt.Fprintf(out, "   - %v\n", err)
+		}
+	}
+	fmt.Fprintf(out, "%d 个文件，%d 个没有通过检查\n", len(files), rejected)
+	if rejected > 0 {
+		return 1
+	}
+	return 0
+}
+
+// validateTargets 目录按 CONFIG_PATH 的规则读取，单个文件同样读取旁边的 schedule 文件；
+// 读取失败或 schedule 文件格式错误的配置按路径放在 unreadable 中
+func validateTargets(path string) (files []*configFile, unreadable map[string]error, err error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	unreadable = make(map[string]error)
+	if info.IsDir() {
+		byName, broken, err := readConfigDir(path)
+		if err != nil {
+			return nil, nil, err
+		}
+		for name, err := range broken {
+			unreadable[filepath.Join(path, name+".json")] = err
+		}
+		files = make([]*configFile, 0, len(byName))
+		for _, file := range byName {
+			files = append(files, file)
+		}
+		return files, unreadable, nil
+	}
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	name := strings.TrimSuffix(filepath.Base(path), ".json")
+	schedule, err := readScheduleFile(filepath.Join(filepath.Dir(path), name+scheduleSuffix))
+	if err != nil {
+		unreadable[path] = err
+		return nil, unreadable, nil
+	}
+	return []*configFile{{
+		name:     name,
+		path:     path,
+		content:  string(content),
+		schedule: schedule,
+	}}, unreadable, nil
+}
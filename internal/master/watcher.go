@@ -0,0 +1,317 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/ticketconfig"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// configFile CONFIG_PATH 下的一个任务配置文件
+type configFile struct {
+	name     string // 去掉 .json 的文件名，即 TaskName
+	path     string
+	content  string
+	schedule string // 同名 .schedule.json 的内容，没有时为空
+	hash     string // content 和 schedule 一起计算，修改任一文件都会触发热加载
+}
+
+// scheduleSuffix 任务配置旁的可选文件，指定该任务自己的售票窗口、间隔和通知目标
+const scheduleSuffix = ".schedule.json"
+
+// fileTaskID 目录任务以文件名为 ID，重复加载不会产生新任务
+func fileTaskID(taskName string) string {
+	return "file-" + taskName
+}
+
+func contentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// readConfigDir 读取目录下的任务配置。读取失败的文件（例如正在写入）和 schedule 文件格式错误的
+// 配置放在 unreadable 中，调用方不能把它们当作已删除
+func readConfigDir(dirPath string) (files map[string]*configFile, unreadable map[string]error, err error) {
+	entries, err := os.ReadDir(dirPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = make(map[string]*configFile)
+	unreadable = make(map[string]error)
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".json") || strings.HasSuffix(entry.Name(), scheduleSuffix) {
+			continue
+		}
+		fullPath := filepath.Join(dirPath, entry.Name())
+		name := strings.TrimSuffix(entry.Name(), ".json")
+		content, err := os.ReadFile(fullPath)
+		if err != nil {
+			log.Printf("Failed to read file %s: %v", fullPath, err)
+			unreadable[name] = err
+			continue
+		}
+		schedule, err := readScheduleFile(filepath.Join(dirPath, name+scheduleSuffix))
+		if err != nil {
+			unreadable[name] = err
+			continue
+		}
+		file := &configFile{
+			name:    name,
+			path:    fullPath,
+			content: string(content),
+			hash:    contentHash(string(content)),
+		}
+		file.schedule = schedule
+		if file.schedule != "" {
+			file.hash = contentHash(file.content + "\x00" + file.schedule)
+		}
+		files[name] = file
+	}
+	return files, unreadable, nil
+}
+
+// readScheduleFile 读取可选的 schedule 文件，不存在时返回空，任务使用 worker 默认值；
+// 读取失败或格式错误时返回错误，不能退回默认的开抢时间和通知目标
+func readScheduleFile(path string) (string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		log.Printf("Failed to read file %s: %v", path, err)
+		return "", err
+	}
+	if _, err := parseSchedule(string(content)); err != nil {
+		return "", fmt.Errorf("%s: %w", filepath.Base(path), err)
+	}
+	return string(content), nil
+}
+
+// WatchConfigDir 定期扫描配置目录：新文件创建任务，修改的文件更新任务，删除的文件取消任务。
+// autoRestart 为 false 时，正在执行的任务的配置变更需要通过 ConfirmReload 确认后才会重启。
+func (s *Server) WatchConfigDir(dirPath string, interval time.Duration, autoRestart bool) {
+	s.tasksMux.Lock()
+	s.autoRestartOnReload = autoRestart
+	s.tasksMux.Unlock()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.syncConfigDir(dirPath); err != nil {
+				log.Errorf("[Reload] 扫描 %s 失败: %v", dirPath, err)
+			}
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+func (s *Server) syncConfigDir(dirPath string) error {
+	files, unreadable, err := readConfigDir(dirPath)
+	if err != nil {
+		return err
+	}
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+
+	changed := false
+	for name := range s.rejectedFiles {
+		_, exists := files[name]
+		_, failed := unreadable[name]
+		if !exists && !failed {
+			delete(s.rejectedFiles, name)
+		}
+	}
+	// 读取失败的文件按没有通过检查处理：不创建、不更新，也不取消已有任务
+	for name, err := range unreadable {
+		file := &configFile{name: name, path: filepath.Join(dirPath, name+".json"), hash: "unreadable: " + err.Error()}
+		s.rejectFileLocked(file, err)
+	}
+	for _, file := range files {
+		if err := lintConfigFile(file, plannedStart(file, s.clock.Now())); err != nil {
+			s.rejectFileLocked(file, err)
+			continue
+		}
+		delete(s.rejectedFiles, file.name)
+		task := s.findFileTaskLocked(file.name)
+		if task == nil {
+			s.createTaskLocked(fileTaskID(file.name), file.name, file.content, file.schedule, file.path, file.hash)
+			changed = true
+			continue
+		}
+		if s.applyFileLocked(task, file) {
+			changed = true
+		}
+	}
+	// 文件被删除：取消还没结束的任务
+	for _, task := range s.tasks {
+		if task.Source == "" || task.SourceRemoved {
+			continue
+		}
+		if _, exists := files[task.TaskName]; exists {
+			continue
+		}
+		if _, failed := unreadable[task.TaskName]; failed {
+			continue
+		}
+		task.SourceRemoved = true
+		task.PendingContent, task.PendingSchedule, task.PendingHash = "", "", ""
+		if !task.Status.Finished() {
+			if task.Status == TaskStatusDoing && task.AssignedTo != "" {
+				go s.stopTaskOnWorker(task.AssignedTo, task.ID, "config file removed")
+			}
+			task.Status = TaskStatusCancelled
+			task.AssignedTo = ""
+			task.LastError = "config file removed"
+			log.Printf("[Reload] %s removed, task <%s> cancelled", task.Source, task.TaskName)
+		}
+		task.UpdatedAt = time.Now()
+		s.persistTask(task)
+	}
+	if changed {
+		s.triggerSchedule()
+	}
+	return nil
+}
+
+// plannedStart 计划的开抢时间：schedule 文件中还没到的 time_start，否则为 now
+func plannedStart(file *configFile, now time.Time) time.Time {
+	if schedule, err := parseSchedule(file.schedule); err == nil && schedule.StartAt.After(now) {
+		return schedule.StartAt
+	}
+	return now
+}
+
+// lintConfigFile 按 worker 的规则检查任务配置，在分配给 worker 之前发现问题
+func lintConfigFile(file *configFile, startAt time.Time) error {
+	return ticketconfig.LintTicketConfig([]byte(file.content), startAt)
+}
+
+// rejectFileLocked 没有通过检查的文件不创建或更新任务，已有任务保留原配置
+func (s *Server) rejectFileLocked(file *configFile, err error) {
+	if s.rejectedFiles[file.name] == file.hash {
+		return
+	}
+	s.rejectedFiles[file.name] = file.hash
+	if task := s.findFileTaskLocked(file.name); task != nil {
+		log.Errorf("[Reload] %s 没有通过检查，任务 <%s> 保留原配置: %v", file.path, task.TaskName, err)
+	} else {
+		log.Errorf("[Reload] %s 没有通过检查，不创建任务: %v", file.path, err)
+	}
+	s.events.emit(EventConfigRejected, file.name, "%s: %v", file.path, err)
+}
+
+// findFileTaskLocked 先按文件 ID 查找，兼容以前按时间戳生成 ID、只能靠名字匹配的任务
+func (s *Server) findFileTaskLocked(taskName string) *TaskInfo {
+	if task, exists := s.tasks[fileTaskID(taskName)]; exists {
+		return task
+	}
+	for _, task := range s.tasks {
+		if task.TaskName == taskName && task.ContentHash == "" {
+			return task
+		}
+	}
+	return nil
+}
+
+// applyFileLocked 把文件内容同步到已有任务，返回是否需要重新调度
+func (s *Server) applyFileLocked(task *TaskInfo, file *configFile) bool {
+	if task.ContentHash == "" {
+		// 旧版本恢复出来的任务没有记录来源，直接认领
+		task.Source, task.ContentHash = file.path, file.hash
+		s.persistTask(task)
+		return false
+	}
+	if task.SourceRemoved {
+		// 文件删除后又加回来，按新任务重新排队
+		task.SourceRemoved = false
+		if task.Status == TaskStatusCancelled || task.ContentHash != file.hash {
+			s.replaceContentLocked(task, file.content, file.schedule, file.hash)
+			task.Status = TaskStatusPending
+			task.RetryCount = 0
+			task.Result = nil
+			log.Printf("[Reload] %s restored, task <%s> requeued", file.path, task.TaskName)
+		}
+		s.persistTask(task)
+		return task.Status == TaskStatusPending
+	}
+	if task.ContentHash == file.hash {
+		return false
+	}
+	if task.PendingHash == file.hash && task.Status == TaskStatusDoing {
+		return false // 已在等待确认
+	}
+
+	switch {
+	case task.Status == TaskStatusDoing && !s.autoRestartOnReload:
+		task.PendingContent, task.PendingSchedule, task.PendingHash = file.content, file.schedule, file.hash
+		log.Warningf("[Reload] %s changed while task <%s> is running, waiting for ConfirmReload", file.path, task.TaskName)
+		s.persistTask(task)
+		return false
+	case task.Status == TaskStatusDoing:
+		s.restartTaskLocked(task, file.content, file.schedule, file.hash)
+		return true
+	case task.Status == TaskStatusSucceeded:
+		// 已经抢到票的任务不会因为配置变化重跑，需要运维显式 RequeueTask
+		log.Warningf("[Reload] %s changed but task <%s> already succeeded, ignored", file.path, task.TaskName)
+		task.ContentHash = file.hash
+		s.persistTask(task)
+		return false
+	default:
+		s.replaceContentLocked(task, file.content, file.schedule, file.hash)
+		log.Printf("[Reload] %s changed, task <%s> (%s) updated", file.path, task.TaskName, task.Status)
+		s.persistTask(task)
+		return task.Status == TaskStatusPending
+	}
+}
+
+func (s *Server) replaceContentLocked(task *TaskInfo, content, schedule, hash string) {
+	task.TickerConfigContent = content
+	task.ScheduleContent = schedule
+	task.ContentHash = hash
+	task.PendingContent, task.PendingSchedule, task.PendingHash = "", "", ""
+	task.UpdatedAt = time.Now()
+	task.applyMeta()
+}
+
+// restartTaskLocked 停掉正在执行的任务，用新配置重新排队
+func (s *Server) restartTaskLocked(task *TaskInfo, content, schedule, hash string) {
+	if task.AssignedTo != "" {
+		go s.stopTaskOnWorker(task.AssignedTo, task.ID, "config reloaded")
+	}
+	s.replaceContentLocked(task, content, schedule, hash)
+	task.Status = TaskStatusPending
+	task.AssignedTo = ""
+	task.PurchaseState = ""
+	log.Printf("[Reload] task <%s> restarted with new config", task.TaskName)
+	s.persistTask(task)
+}
+
+// ConfirmReload 确认正在执行的任务使用新配置重启
+func (s *Server) ConfirmReload(taskID string) error {
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	task, exists := s.tasks[taskID]
+	if !exists {
+		return status.Errorf(codes.NotFound, "<%s> not found", taskID)
+	}
+	if task.PendingHash == "" {
+		return status.Errorf(codes.FailedPrecondition, "<%s> has no pending config change", taskID)
+	}
+	if task.Status == TaskStatusDoing {
+		s.restartTaskLocked(task, task.PendingContent, task.PendingSchedule, task.PendingHash)
+	} else {
+		s.replaceContentLocked(task, task.PendingContent, task.PendingSchedule, task.PendingHash)
+		s.persistTask(task)
+	}
+	s.triggerSchedule()
+	return nil
+}
@@ -0,0 +1,259 @@
+package master
+
+import (
+	. "biliTickerStorm/internal/common"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name+".json"), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// ticketConfig 能通过 LintTicketConfig 的最小抢票配置，detail 用来区分不同版本
+func ticketConfig(detail string) string {
+	return `{"detail":"` + detail + `","project_id":1,"screen_id":2,"sku_id":3,"count":1,"pay_money":100,
+		"buyer_info":[{"name":"张三","personal_id":"11010519491231002X","id_type":0}],
+		"cookies":[{"name":"SESSDATA","value":"s","expires":-1},{"name":"bili_jct","value":"j","expires":-1}]}`
+}
+
+func TestSyncConfigDir(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+	defer s.Stop()
+	dir := t.TempDir()
+	id := fileTaskID("a")
+
+	writeConfig(t, dir, "a", ticketConfig("v1"))
+	if err := s.LoadTasksFromDir(dir); err != nil {
+		t.Fatalf("LoadTasksFromDir: %v", err)
+	}
+	if err := s.syncConfigDir(dir); err != nil {
+		t.Fatalf("sync: %v", err)
+	}
+	s.tasksMux.Lock()
+	if len(s.tasks) != 1 || s.tasks[id] == nil {
+		t.Fatalf("reload should not duplicate tasks: %+v", s.tasks)
+	}
+	task := s.tasks[id]
+	// 模拟已经分配出去
+	task.Status = TaskStatusDoing
+	task.AssignedTo = "w-1"
+	s.tasksMux.Unlock()
+
+	writeConfig(t, dir, "a", ticketConfig("v2"))
+	_ = s.syncConfigDir(dir)
+	s.tasksMux.Lock()
+	if task.TickerConfigContent != ticketConfig("v1") || task.PendingContent != ticketConfig("v2") || task.Status != TaskStatusDoing {
+		t.Fatalf("running task should wait for confirmation: %+v", task)
+	}
+	s.tasksMux.Unlock()
+
+	if err := s.ConfirmReload(id); err != nil {
+		t.Fatalf("ConfirmReload: %v", err)
+	}
+	s.tasksMux.Lock()
+	if task.TickerConfigContent != ticketConfig("v2") || task.Status != TaskStatusPending || task.PendingHash != "" {
+		t.Fatalf("confirmed task should restart with new config: %+v", task)
+	}
+	s.tasksMux.Unlock()
+
+	if err := os.Remove(filepath.Join(dir, "a.json")); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.syncConfigDir(dir)
+	s.tasksMux.Lock()
+	if task.Status != TaskStatusCancelled || !task.SourceRemoved {
+		t.Fatalf("removed file should cancel task: %+v", task)
+	}
+	s.tasksMux.Unlock()
+
+	writeConfig(t, dir, "a", ticketConfig("v3"))
+	_ = s.syncConfigDir(dir)
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	if task.Status != TaskStatusPending || task.TickerConfigContent != ticketConfig("v3") || len(s.tasks) != 1 {
+		t.Fatalf("restored file should requeue the same task: %+v", task)
+	}
+}
+
+func TestScheduleSidecar(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+	defer s.Stop()
+	dir := t.TempDir()
+	id := fileTaskID("a")
+
+	writeConfig(t, dir, "a", ticketConfig("v1"))
+	writeConfig(t, dir, "a.schedule", `{"time_start":"2025-06-01T20:00","time_end":"2025-06-01T20:30","interval":150}`)
+	if err := s.LoadTasksFromDir(dir); err != nil {
+		t.Fatalf("LoadTasksFromDir: %v", err)
+	}
+	s.tasksMux.Lock()
+	if len(s.tasks) != 1 {
+		t.Fatalf("schedule file should not become a task: %+v", s.tasks)
+	}
+	schedule := s.tasks[id].Schedule
+	s.tasksMux.Unlock()
+	if schedule.Interval != 150 || schedule.EndAt.Sub(schedule.StartAt) != 30*time.Minute {
+		t.Fatalf("unexpected schedule: %+v", schedule)
+	}
+	if got := schedule.StartAt.UTC().Hour(); got != 12 {
+		t.Errorf("time_start should be Beijing time, got %d UTC", got)
+	}
+
+	// 只修改 schedule 文件同样会更新任务
+	writeConfig(t, dir, "a.schedule", `{"interval":80,"pushplus_token":"tk","notify":["bark://key"]}`)
+	_ = s.syncConfigDir(dir)
+	s.tasksMux.Lock()
+	schedule = s.tasks[id].Schedule
+	s.tasksMux.Unlock()
+	if schedule.Interval != 80 || schedule.PushplusToken != "tk" || len(schedule.Notify) != 1 || !schedule.StartAt.IsZero() {
+		t.Fatalf("schedule not reloaded: %+v", schedule)
+	}
+
+	// 格式错误时拒绝，任务保留原来的 schedule
+	writeConfig(t, dir, "a.schedule", `{"time_start":"2025-06-01T21:00","time_end":"2025-06-01T20:00"}`)
+	_ = s.syncConfigDir(dir)
+	s.tasksMux.Lock()
+	task := s.tasks[id]
+	rejected := s.rejectedFiles["a"]
+	s.tasksMux.Unlock()
+	if task.Schedule.Interval != 80 || task.Status != TaskStatusPending || task.SourceRemoved || rejected == "" {
+		t.Fatalf("invalid schedule should be rejected and the task kept: %+v", task)
+	}
+
+	// 新任务的 schedule 格式错误时不创建任务
+	writeConfig(t, dir, "b", ticketConfig("v1"))
+	writeConfig(t, dir, "b.schedule", `{"time_start":"tomorrow"}`)
+	_ = s.syncConfigDir(dir)
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	if s.tasks[fileTaskID("b")] != nil || s.rejectedFiles["b"] == "" {
+		t.Fatalf("task with an invalid schedule should not be created: %+v", s.tasks[fileTaskID("b")])
+	}
+}
+
+func TestSyncConfigDirRejectsInvalidConfig(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+	defer s.Stop()
+	dir := t.TempDir()
+
+	writeConfig(t, dir, "bad", `{"project_id":1,"count":2}`)
+	writeConfig(t, dir, "a", ticketConfig("v1"))
+	if err := s.LoadTasksFromDir(dir); err != nil {
+		t.Fatalf("LoadTasksFromDir: %v", err)
+	}
+	s.tasksMux.Lock()
+	if len(s.tasks) != 1 || s.tasks[fileTaskID("bad")] != nil || s.rejectedFiles["bad"] == "" {
+		t.Fatalf("invalid config should not become a task: %+v", s.tasks)
+	}
+	s.tasksMux.Unlock()
+
+	// 改坏的配置不会替换已有任务，也不会被当成文件删除
+	writeConfig(t, dir, "a", `{"v":2}`)
+	_ = s.syncConfigDir(dir)
+	s.tasksMux.Lock()
+	task := s.tasks[fileTaskID("a")]
+	if task.TickerConfigContent != ticketConfig("v1") || task.Status != TaskStatusPending || task.SourceRemoved {
+		t.Fatalf("task should keep the last valid config: %+v", task)
+	}
+	s.tasksMux.Unlock()
+
+	writeConfig(t, dir, "bad", ticketConfig("fixed"))
+	_ = s.syncConfigDir(dir)
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	if s.tasks[fileTaskID("bad")] == nil || s.rejectedFiles["bad"] != "" {
+		t.Fatal("fixed config should become a task")
+	}
+}
+
+func TestSyncConfigDirKeepsUnreadableFile(t *testing.T) {
+	s := NewServer(NewMemoryStore())
+	defer s.Stop()
+	dir := t.TempDir()
+	id := fileTaskID("a")
+
+	writeConfig(t, dir, "a", ticketConfig("v1"))
+	if err := s.LoadTasksFromDir(dir); err != nil {
+		t.Fatalf("LoadTasksFromDir: %v", err)
+	}
+	s.tasksMux.Lock()
+	s.tasks[id].Status = TaskStatusDoing
+	s.tasks[id].AssignedTo = "w-1"
+	s.tasksMux.Unlock()
+
+	// 读取失败（这里用指向不存在文件的链接模拟）不能当作文件被删除
+	path := filepath.Join(dir, "a.json")
+	if err := os.Remove(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(filepath.Join(dir, "missing"), path); err != nil {
+		t.Skipf("symlink: %v", err)
+	}
+	_ = s.syncConfigDir(dir)
+	s.tasksMux.Lock()
+	defer s.tasksMux.Unlock()
+	task := s.tasks[id]
+	if task.SourceRemoved || task.Status != TaskStatusDoing || task.TickerConfigContent != ticketConfig("v1") {
+		t.Fatalf("unreadable file should keep the running task: %+v", task)
+	}
+	if s.rejectedFiles["a"] == "" {
+		t.Fatal("unreadable file should be reported")
+	}
+}
+
+func TestRunValidate(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "a", ticketConfig("v1"))
+	writeConfig(t, dir, "b", `{"project_id":1,"screen_id":2,"sku_id":3,"count":2,"buyer_info":[{"name":"张三","personal_id":"110105194912310021"}]}`)
+
+	var out strings.Builder
+	if code := RunValidate([]string{dir}, &out); code != 1 {
+		t.Fatalf("exit code %d, output:\n%s", code, out.String())
+	}
+	for _, want := range []string{"✅ " + filepath.Join(dir, "a.json"), "❌ " + filepath.Join(dir, "b.json"),
+		"count: 为 2，但 buyer_info 有 1 个购票人", "buyer_info[0].personal_id: 身份证号校验位错误", "缺少 SESSDATA"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+
+	out.Reset()
+	if code := RunValidate([]string{filepath.Join(dir, "a.json")}, &out); code != 0 {
+		t.Fatalf("exit code %d, output:\n%s", code, out.String())
+	}
+	// 指定的开抢时间晚于 cookie 过期时间
+	writeConfig(t, dir, "c", strings.Replace(ticketConfig("v1"), `"value":"j","expires":-1`, `"value":"j","expires":1750000000`, 1))
+	out.Reset()
+	if code := RunValidate([]string{"-time-start", "2030-01-01T20:00", filepath.Join(dir, "c.json")}, &out); code != 1 || !strings.Contains(out.String(), "bili_jct 在") {
+		t.Fatalf("exit code %d, output:\n%s", code, out.String())
+	}
+	// 没有指定时按 schedule 文件中的开抢时间检查
+	writeConfig(t, dir, "c.schedule", `{"time_start":"2030-01-01T20:00"}`)
+	out.Reset()
+	if code := RunValidate([]string{filepath.Join(dir, "c.json")}, &out); code != 1 || !strings.Contains(out.String(), "bili_jct 在") {
+		t.Fatalf("exit code %d, output:\n%s", code, out.String())
+	}
+
+	// schedule 文件格式错误不能算通过，其余文件照常检查
+	writeConfig(t, dir, "a.schedule", `{"time_start":"tomorrow"}`)
+	out.Reset()
+	if code := RunValidate([]string{dir}, &out); code != 1 {
+		t.Fatalf("exit code %d, output:\n%s", code, out.String())
+	}
+	for _, want := range []string{"❌ " + filepath.Join(dir, "a.json"), "a.schedule.json", "❌ " + filepath.Join(dir, "b.json"), "3 个文件，3 个没有通过检查"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, out.String())
+		}
+	}
+	out.Reset()
+	if code := RunValidate([]string{filepath.Join(dir, "a.json")}, &out); code != 1 || strings.Contains(out.String(), "✅") {
+		t.Fatalf("exit code %d, output:\n%s", code, out.String())
+	}
+}
@@ -0,0 +1,186 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// send 发送请求并读取响应。4xx 视为配置错误不再重试，5xx 和网络错误可以重试
+func send(req *http.Request) ([]byte, error) {
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode >= 500:
+		return nil, fmt.Errorf("HTTP %d: %s", resp.StatusCode, body)
+	case resp.StatusCode >= 300:
+		return nil, permanent(fmt.Errorf("HTTP %d: %s", resp.StatusCode, body))
+	}
+	return body, nil
+}
+
+func postJSON(ctx context.Context, endpoint string, payload any) ([]byte, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(data))
+	if err != nil {
+		return nil, permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	return send(req)
+}
+
+func postForm(ctx context.Context, endpoint string, form url.Values) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, permanent(err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	return send(req)
+}
+
+// checkCode 检查响应中的业务状态码，不符合时视为配置错误（token 无效、被限制等），不重试
+func checkCode(body []byte, want int) error {
+	var resp struct {
+		Code    *int   `json:"code"`
+		Msg     string `json:"msg"`
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Code == nil {
+		return fmt.Errorf("无法解析响应: %s", body)
+	}
+	if *resp.Code != want {
+		return permanent(fmt.Errorf("code=%d %s%s", *resp.Code, resp.Msg, resp.Message))
+	}
+	return nil
+}
+
+func baseOr(base, def string) string {
+	if base == "" {
+		return def
+	}
+	return strings.TrimSuffix(base, "/")
+}
+
+// PushPlus https://www.pushplus.plus
+type PushPlus struct {
+	Token   string
+	BaseURL string
+}
+
+func (p *PushPlus) Name() string { return "pushplus" }
+
+func (p *PushPlus) Notify(ctx context.Context, msg Message) error {
+	body, err := postJSON(ctx, baseOr(p.BaseURL, "https://www.pushplus.plus")+"/send", map[string]string{
+		"token":   p.Token,
+		"title":   msg.Title,
+		"content": msg.Content,
+	})
+	if err != nil {
+		return err
+	}
+	return checkCode(body, 200)
+}
+
+// ServerChan Server 酱 Turbo，https://sct.ftqq.com
+type ServerChan struct {
+	SendKey string
+	BaseURL string
+}
+
+func (s *ServerChan) Name() string { return "serverchan" }
+
+func (s *ServerChan) Notify(ctx context.Context, msg Message) error {
+	endpoint := fmt.Sprintf("%s/%s.send", baseOr(s.BaseURL, "https://sctapi.ftqq.com"), url.PathEscape(s.SendKey))
+	body, err := postForm(ctx, endpoint, url.Values{"title": {msg.Title}, "desp": {msg.Content}})
+	if err != nil {
+		return err
+	}
+	return checkCode(body, 0)
+}
+
+// Bark iOS 推送，BaseURL 为空时使用官方服务器
+type Bark struct {
+	DeviceKey string
+	BaseURL   string
+}
+
+func (b *Bark) Name() string { return "bark" }
+
+func (b *Bark) Notify(ctx context.Context, msg Message) error {
+	body, err := postJSON(ctx, baseOr(b.BaseURL, "https://api.day.app")+"/push", map[string]string{
+		"device_key": b.DeviceKey,
+		"title":      msg.Title,
+		"body":       msg.Content,
+		"group":      "biliTickerStorm",
+	})
+	if err != nil {
+		return err
+	}
+	return checkCode(body, 200)
+}
+
+// Webhook 把 {"title": ..., "content": ...} POST 到任意地址，2xx 即成功
+type Webhook struct {
+	URL string
+}
+
+func (w *Webhook) Name() string {
+	if u, err := url.Parse(w.URL); err == nil {
+		return "webhook " + u.Host
+	}
+	return "webhook"
+}
+
+func (w *Webhook) Notify(ctx context.Context, msg Message) error {
+	_, err := postJSON(ctx, w.URL, map[string]string{"title": msg.Title, "content": msg.Content})
+	return err
+}
+
+// Telegram Bot API 的 sendMessage，BaseURL 可以指向自建的 Bot API 服务或兼容的机器人接口
+type Telegram struct {
+	BotToken string
+	ChatID   string
+	BaseURL  string
+}
+
+func (t *Telegram) Name() string { return "telegram " + t.ChatID }
+
+func (t *Telegram) Notify(ctx context.Context, msg Message) error {
+	endpoint := fmt.Sprintf("%s/bot%s/sendMessage", baseOr(t.BaseURL, "https://api.telegram.org"), t.BotToken)
+	body, err := postJSON(ctx, endpoint, map[string]string{
+		"chat_id": t.ChatID,
+		"text":    msg.Title + "\n" + msg.Content,
+	})
+	if err != nil {
+		return err
+	}
+	var resp struct {
+		OK          bool   `json:"ok"`
+		Description string `json:"description"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return fmt.Errorf("无法解析响应: %s", body)
+	}
+	if !resp.OK {
+		return permanent(fmt.Errorf("telegram: %s", resp.Description))
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Package notify 把抢票结果推送到 PushPlus、Server 酱、Bark、Webhook、邮件和 Telegram 风格的机器人。
+// 每个目标用一个 URL 表示，可以来自全局配置或任务自己的 schedule，见 Parse。
+package notify
+
+import (
+	"biliTickerStorm/internal/common"
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var log = common.GetLogger("notify")
+
+// Message 一条通知
+type Message struct {
+	Title   string
+	Content string
+}
+
+// Notifier 一个通知渠道
+type Notifier interface {
+	// Name 用于日志，不包含 token 等敏感信息
+	Name() string
+	Notify(ctx context.Context, msg Message) error
+}
+
+// permanentError 重试也不会成功的错误，例如 token 错误或目标不存在
+type permanentError struct{ err error }
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+func permanent(err error) error { return &permanentError{err} }
+
+// IsPermanent err 是否不需要重试
+func IsPermanent(err error) bool {
+	var p *permanentError
+	return errors.As(err, &p)
+}
+
+// Parse 解析一个通知目标：
+//
+//	pushplus://<token>
+//	serverchan://<sendkey>
+//	bark://<device_key>[@<host>]
+//	webhook+https://example.com/hook
+//	smtp://<user>:<password>@<host>:<port>?from=<addr>&to=<addr>[,<addr>]
+//	telegram://<bot_token>@<chat_id>
+//
+// 除 webhook 和 smtp 外都可以用 ?base_url= 指向自建或兼容的服务。
+func Parse(target string) (Notifier, error) {
+	u, err := url.Parse(strings.TrimSpace(target))
+	if err != nil {
+		return nil, fmt.Errorf("通知目标格式错误: %w", err)
+	}
+	baseURL := u.Query().Get("base_url")
+	switch u.Scheme {
+	case "pushplus":
+		if u.Host == "" {
+			return nil, errors.New("pushplus 缺少 token")
+		}
+		return &PushPlus{Token: u.Host, BaseURL: baseURL}, nil
+	case "serverchan":
+		if u.Host == "" {
+			return nil, errors.New("serverchan 缺少 sendkey")
+		}
+		return &ServerChan{SendKey: u.Host, BaseURL: baseURL}, nil
+	case "bark":
+		key, host := u.User.Username(), u.Host
+		if key == "" {
+			key, host = host, ""
+		}
+		if key == "" {
+			return nil, errors.New("bark 缺少 device key")
+		}
+		if baseURL == "" && host != "" {
+			baseURL = "https://" + host
+		}
+		return &Bark{DeviceKey: key, BaseURL: baseURL}, nil
+	case "webhook+http", "webhook+https":
+		hook := *u
+		hook.Scheme = strings.TrimPrefix(u.Scheme, "webhook+")
+		return &Webhook{URL: hook.String()}, nil
+	case "smtp":
+		return parseSMTP(u)
+	case "telegram":
+		token := u.User.String()
+		if token == "" || u.Host == "" {
+			return nil, errors.New("telegram 格式为 telegram://<bot_token>@<chat_id>")
+		}
+		return &Telegram{BotToken: token, ChatID: u.Host, BaseURL: baseURL}, nil
+	}
+	return nil, fmt.Errorf("不支持的通知渠道 %q", u.Scheme)
+}
+
+// ParseAll 解析多个目标，任意一个错误都返回错误
+func ParseAll(targets []string) ([]Notifier, error) {
+	notifiers := make([]Notifier, 0, len(targets))
+	for _, target := range targets {
+		n, err := Parse(target)
+		if err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, n)
+	}
+	return notifiers, nil
+}
+
+// PushPlusTarget 兼容旧的 PUSHPLUS_TOKEN 配置
+func PushPlusTarget(token string) string {
+	return "pushplus://" + token
+}
+
+const (
+	DefaultAttempts = 3
+	defaultBackoff  = time.Second
+)
+
+// Dispatcher 并发发送到所有目标，每个目标失败后按 1s、2s、4s… 重试
+type Dispatcher struct {
+	targets  []Notifier
+	attempts int
+	backoff  time.Duration
+	clock    common.Clock
+}
+
+func NewDispatcher(targets []Notifier, attempts int) *Dispatcher {
+	if attempts <= 0 {
+		attempts = DefaultAttempts
+	}
+	return &Dispatcher{targets: targets, attempts: attempts, backoff: defaultBackoff, clock: common.SystemClock()}
+}
+
+func (d *Dispatcher) Empty() bool { return len(d.targets) == 0 }
+
+// Send 返回所有最终失败的目标的错误
+func (d *Dispatcher) Send(ctx context.Context, msg Message) error {
+	errs := make([]error, len(d.targets))
+	var wg sync.WaitGroup
+	for i, target := range d.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := d.sendOne(ctx, target, msg); err != nil {
+				errs[i] = fmt.Errorf("%s: %w", target.Name(), err)
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (d *Dispatcher) sendOne(ctx context.Context, target Notifier, msg Message) error {
+	backoff := d.backoff
+	var err error
+	for attempt := 1; attempt <= d.attempts; attempt++ {
+		if err = target.Notify(ctx, msg); err == nil {
+			return nil
+		}
+		if IsPermanent(err) || attempt == d.attempts {
+			break
+		}
+		log.Warningf("[Notify] %s 第 %d 次发送失败，%v 后重试: %v", target.Name(), attempt, backoff, err)
+		if common.SleepCtx(ctx, d.clock, backoff) != nil {
+			return errors.Join(err, context.Cause(ctx))
+		}
+		backoff *= 2
+	}
+	return err
+}
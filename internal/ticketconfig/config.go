@@ -0,0 +1,101 @@
+// Package ticketconfig 定义 master 下发、worker 执行的抢票配置（CONFIG_PATH 下的 JSON 文件）和配置检查。
+// master 只依赖这个包检查配置，不链接 worker。
+package ticketconfig
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+type BiliTickerBuyConfig struct {
+	Username    string      `json:"username"`
+	Detail      string      `json:"detail"`
+	Count       int         `json:"count"`
+	ScreenId    int         `json:"screen_id"`
+	ProjectId   int         `json:"project_id"`
+	SkuId       int         `json:"sku_id"`
+	OrderType   int         `json:"order_type"`
+	PayMoney    int         `json:"pay_money"`
+	BuyerInfo   []BuyerInfo `json:"buyer_info"`
+	Buyer       string      `json:"buyer"`
+	Tel         string      `json:"tel"`
+	DeliverInfo DeliverInfo `json:"deliver_info"`
+	Cookies     []Cookies   `json:"cookies"`
+	Phone       string      `json:"phone"`
+	Token       string      `json:"token"`
+	Again       int         `json:"again"`
+	Timestamp   int64       `json:"timestamp"`
+}
+type Cookies struct {
+	Name     string  `json:"name"`
+	Value    string  `json:"value"`
+	Domain   string  `json:"domain"`
+	Path     string  `json:"path"`
+	Expires  float64 `json:"expires"`
+	HttpOnly bool    `json:"httpOnly"`
+	Secure   bool    `json:"secure"`
+	SameSite string  `json:"sameSite"`
+}
+type DeliverInfo struct {
+	Name   string `json:"name"`
+	Tel    string `json:"tel"`
+	AddrId int    `json:"addr_id"`
+	Addr   string `json:"addr"`
+}
+type BuyerInfo struct {
+	Id             int    `json:"id"`
+	Uid            int    `json:"uid"`
+	AccountChannel string `json:"account_channel"`
+	PersonalId     string `json:"personal_id"`
+	Name           string `json:"name"`
+	IdCardFront    string `json:"id_card_front"`
+	IdCardBack     string `json:"id_card_back"`
+	IsDefault      int    `json:"is_default"`
+	Tel            string `json:"tel"`
+	ErrorCode      string `json:"error_code"`
+	IdType         int    `json:"id_type"`
+	VerifyStatus   int    `json:"verify_status"`
+	AccountId      int    `json:"accountId"`
+}
+
+type CreateV2RequestBody struct {
+	Count       int    `json:"count"`
+	ScreenId    int    `json:"screen_id"`
+	ProjectId   int    `json:"project_id"`
+	SkuId       int    `json:"sku_id"`
+	OrderType   int    `json:"order_type"`
+	PayMoney    int    `json:"pay_money"`
+	BuyerInfo   string `json:"buyer_info"`
+	Buyer       string `json:"buyer"`
+	Tel         string `json:"tel"`
+	DeliverInfo string `json:"deliver_info"`
+	Again       int    `json:"again"`
+	Token       string `json:"token"`
+	Timestamp   int64  `json:"timestamp"`
+}
+
+func (cfg *BiliTickerBuyConfig) ToCreateV2RequestBody() (*CreateV2RequestBody, error) {
+	buyerInfoStr, err := json.Marshal(cfg.BuyerInfo)
+	if err != nil {
+		return nil, fmt.Errorf("marshal buyer_info: %w", err)
+	}
+	deliverInfoStr, err := json.Marshal(cfg.DeliverInfo)
+	if err != nil {
+		return nil, fmt.Errorf("marshal deliver_info: %w", err)
+	}
+	return &CreateV2RequestBody{
+		Count:       cfg.Count,
+		ScreenId:    cfg.ScreenId,
+		ProjectId:   cfg.ProjectId,
+		SkuId:       cfg.SkuId,
+		OrderType:   cfg.OrderType,
+		PayMoney:    cfg.PayMoney,
+		BuyerInfo:   string(buyerInfoStr),
+		Buyer:       cfg.Buyer,
+		Tel:         cfg.Tel,
+		DeliverInfo: string(deliverInfoStr),
+		Again:       cfg.Again,
+		Token:       cfg.Token,
+		Timestamp:   cfg.Timestamp,
+	}, nil
+}
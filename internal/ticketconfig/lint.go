@@ -0,0 +1,168 @@
+package ticketconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// ConfigProblem 抢票配置中的一个问题
+type ConfigProblem struct {
+	Field   string
+	Message string
+}
+
+func (p ConfigProblem) String() string {
+	return p.Field + ": " + p.Message
+}
+
+// ConfigError 配置没有通过检查，Problems 列出所有问题
+type ConfigError struct {
+	Problems []ConfigProblem
+}
+
+func (e *ConfigError) Error() string {
+	items := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		items[i] = p.String()
+	}
+	return strings.Join(items, "; ")
+}
+
+// 身份证类型的 id_type，其他证件（护照、港澳通行证等）不校验号码
+const idTypeIDCard = 0
+
+var mobilePattern = regexp.MustCompile(`^1[3-9]\d{9}$`)
+
+// LintTicketConfig 解析并检查抢票配置，startAt 为计划的开抢时间，用于检查 cookie 是否会在开抢前过期
+func LintTicketConfig(content []byte, startAt time.Time) error {
+	var cfg BiliTickerBuyConfig
+	if err := json.Unmarshal(content, &cfg); err != nil {
+		return &ConfigError{Problems: []ConfigProblem{{Field: "json", Message: err.Error()}}}
+	}
+	if problems := cfg.Lint(startAt); len(problems) > 0 {
+		return &ConfigError{Problems: problems}
+	}
+	return nil
+}
+
+// Lint 检查 createV2 会拒绝或者开抢时才会暴露的问题，返回空表示通过
+func (cfg *BiliTickerBuyConfig) Lint(startAt time.Time) []ConfigProblem {
+	var problems []ConfigProblem
+	add := func(field, format string, args ...any) {
+		problems = append(problems, ConfigProblem{Field: field, Message: fmt.Sprintf(format, args...)})
+	}
+
+	ids := []struct {
+		field string
+		id    int
+	}{{"project_id", cfg.ProjectId}, {"screen_id", cfg.ScreenId}, {"sku_id", cfg.SkuId}}
+	for _, id := range ids {
+		if id.id <= 0 {
+			add(id.field, "缺少或不是正数")
+		}
+	}
+	if cfg.Count <= 0 {
+		add("count", "必须大于 0")
+	}
+
+	// 实名项目每张票对应一个购票人，非实名项目使用 buyer 和 tel
+	if len(cfg.BuyerInfo) > 0 {
+		if cfg.Count != len(cfg.BuyerInfo) {
+			add("count", "为 %d，但 buyer_info 有 %d 个购票人", cfg.Count, len(cfg.BuyerInfo))
+		}
+		for i, buyer := range cfg.BuyerInfo {
+			field := fmt.Sprintf("buyer_info[%d]", i)
+			if buyer.Name == "" {
+				add(field+".name", "不能为空")
+			}
+			if buyer.IdType == idTypeIDCard {
+				if err := checkIDCard(buyer.PersonalId); err != nil {
+					add(field+".personal_id", "%v", err)
+				}
+			}
+			if buyer.Tel != "" && !mobilePattern.MatchString(buyer.Tel) {
+				add(field+".tel", "不是有效的手机号")
+			}
+		}
+	} else {
+		if cfg.Buyer == "" {
+			add("buyer", "非实名项目（buyer_info 为空）需要填写联系人")
+		}
+		if cfg.Tel == "" {
+			add("tel", "非实名项目（buyer_info 为空）需要填写联系电话")
+		}
+	}
+	if cfg.Tel != "" && !mobilePattern.MatchString(cfg.Tel) {
+		add("tel", "不是有效的手机号")
+	}
+	if cfg.Phone != "" && !mobilePattern.MatchString(cfg.Phone) {
+		add("phone", "不是有效的手机号")
+	}
+
+	// 电子票没有收货信息；填写了就必须完整，否则纸质票下单会失败
+	if d := cfg.DeliverInfo; d != (DeliverInfo{}) {
+		if d.Name == "" {
+			add("deliver_info.name", "收货人不能为空")
+		}
+		if d.Addr == "" {
+			add("deliver_info.addr", "收货地址不能为空")
+		}
+		if d.AddrId <= 0 {
+			add("deliver_info.addr_id", "缺少地址 ID")
+		}
+		if !mobilePattern.MatchString(d.Tel) {
+			add("deliver_info.tel", "不是有效的手机号")
+		}
+	}
+
+	for _, name := range []string{"SESSDATA", "bili_jct"} {
+		cookie, ok := findCookie(cfg.Cookies, name)
+		switch {
+		case !ok || cookie.Value == "":
+			add("cookies", "缺少 %s，请重新导出登录 cookie", name)
+		case cookie.Expires > 0:
+			// 浏览器导出的 expires 是 unix 秒，-1 表示会话 cookie
+			expires := time.Unix(int64(cookie.Expires), 0)
+			if !expires.After(startAt) {
+				add("cookies", "%s 在 %s 过期，早于开抢时间 %s", name,
+					expires.Format(time.DateTime), startAt.Format(time.DateTime))
+			}
+		}
+	}
+	return problems
+}
+
+func findCookie(cookies []Cookies, name string) (Cookies, bool) {
+	for _, c := range cookies {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return Cookies{}, false
+}
+
+// checkIDCard 检查 18 位居民身份证号的出生日期和校验位（GB 11643-1999）
+func checkIDCard(id string) error {
+	if len(id) != 18 {
+		return fmt.Errorf("身份证号应为 18 位，当前 %d 位", len(id))
+	}
+	weights := []int{7, 9, 10, 5, 8, 4, 2, 1, 6, 3, 7, 9, 10, 5, 8, 4, 2}
+	sum := 0
+	for i, w := range weights {
+		if id[i] < '0' || id[i] > '9' {
+			return fmt.Errorf("身份证号前 17 位必须是数字")
+		}
+		sum += int(id[i]-'0') * w
+	}
+	if _, err := time.Parse("20060102", id[6:14]); err != nil {
+		return fmt.Errorf("身份证号中的出生日期无效")
+	}
+	want := "10X98765432"[sum%11]
+	if got := strings.ToUpper(id[17:])[0]; got != want {
+		return fmt.Errorf("身份证号校验位错误")
+	}
+	return nil
+}
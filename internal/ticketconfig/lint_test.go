@@ -0,0 +1,81 @@
+package ticketconfig
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validTicketConfig() BiliTickerBuyConfig {
+	return BiliTickerBuyConfig{
+		ProjectId: 1, ScreenId: 2, SkuId: 3, Count: 2, PayMoney: 38000,
+		BuyerInfo: []BuyerInfo{
+			{Name: "张三", PersonalId: "11010519491231002X", Tel: "13800138000"},
+			{Name: "Alice", PersonalId: "E12345678", IdType: 1},
+		},
+		Cookies: []Cookies{{Name: "SESSDATA", Value: "s", Expires: -1}, {Name: "bili_jct", Value: "j", Expires: 1893456000}},
+	}
+}
+
+func TestLint(t *testing.T) {
+	startAt := time.Date(2025, 6, 1, 20, 0, 0, 0, time.UTC)
+	if problems := (&BiliTickerBuyConfig{}).Lint(startAt); len(problems) == 0 {
+		t.Fatal("empty config should be rejected")
+	}
+	cfg := validTicketConfig()
+	if problems := cfg.Lint(startAt); len(problems) != 0 {
+		t.Fatalf("valid config rejected: %v", problems)
+	}
+
+	cases := map[string]func(*BiliTickerBuyConfig){
+		"sku_id":                    func(c *BiliTickerBuyConfig) { c.SkuId = 0 },
+		"count":                     func(c *BiliTickerBuyConfig) { c.Count = 3 },
+		"buyer_info[0].personal_id": func(c *BiliTickerBuyConfig) { c.BuyerInfo[0].PersonalId = "110105194912310021" },
+		"buyer_info[0].tel":         func(c *BiliTickerBuyConfig) { c.BuyerInfo[0].Tel = "12345" },
+		"deliver_info.addr": func(c *BiliTickerBuyConfig) {
+			c.DeliverInfo = DeliverInfo{Name: "张三", Tel: "13800138000", AddrId: 1}
+		},
+		"cookies": func(c *BiliTickerBuyConfig) { c.Cookies = c.Cookies[:1] },
+		"buyer":   func(c *BiliTickerBuyConfig) { c.BuyerInfo = nil; c.Tel = "13800138000" },
+	}
+	for field, mutate := range cases {
+		cfg := validTicketConfig()
+		cfg.BuyerInfo = append([]BuyerInfo(nil), cfg.BuyerInfo...)
+		mutate(&cfg)
+		problems := cfg.Lint(startAt)
+		if len(problems) != 1 || problems[0].Field != field {
+			t.Errorf("%s: got %v", field, problems)
+		}
+	}
+
+	// cookie 在开抢前过期
+	cfg = validTicketConfig()
+	cfg.Cookies[1].Expires = float64(startAt.Add(-time.Hour).Unix())
+	if problems := cfg.Lint(startAt); len(problems) != 1 || !strings.Contains(problems[0].Message, "bili_jct") {
+		t.Fatalf("expired cookie not reported: %v", problems)
+	}
+}
+
+func TestLintTicketConfig(t *testing.T) {
+	err := LintTicketConfig([]byte(`{"count": "2"}`), time.Now())
+	var cfgErr *ConfigError
+	if !errors.As(err, &cfgErr) || cfgErr.Problems[0].Field != "json" {
+		t.Fatalf("expected json problem, got %v", err)
+	}
+}
+
+func TestCheckIDCard(t *testing.T) {
+	for id, ok := range map[string]bool{
+		"11010519491231002X": true,
+		"11010519491231002x": true,
+		"110105194912310021": false, // 校验位
+		"110105194913310028": false, // 出生日期
+		"1101051949123100":   false,
+		"11010519491231A02X": false,
+	} {
+		if err := checkIDCard(id); (err == nil) != ok {
+			t.Errorf("checkIDCard(%s) = %v", id, err)
+		}
+	}
+}
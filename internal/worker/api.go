@@ -0,0 +1,306 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// ResponseError 接口响应的结构和预期不符：不是 JSON、缺少状态码，或者成功时缺少必需字段。
+// 通常说明接口改版，不能当作成功或普通的业务错误处理。
+type ResponseError struct {
+	Endpoint string
+	Body     []byte
+	Err      error
+}
+
+func (e *ResponseError) Error() string {
+	body := e.Body
+	if len(body) > 256 {
+		body = body[:256]
+	}
+	return fmt.Sprintf("%s 响应格式异常: %v, body: %s", e.Endpoint, e.Err, body)
+}
+
+func (e *ResponseError) Unwrap() error { return e.Err }
+
+const (
+	endpointPrepare         = "prepare"
+	endpointCreateV2        = "createV2"
+	endpointCaptchaRegister = "gaia-vgate/register"
+	endpointCaptchaValidate = "gaia-vgate/validate"
+	endpointNav             = "web-interface/nav"
+	endpointBuyerList       = "buyer/list"
+)
+
+// envelope 所有接口共同的外层结构。会员购接口使用 errno/msg，api.bilibili.com 使用 code/message，至少要有一个。
+// data 只在对应状态码需要时才按具体结构解析，其他错误码下 data 可能是 null、[] 或 {}。
+type envelope struct {
+	Errno   *int            `json:"errno"`
+	Code    *int            `json:"code"`
+	Msg     string          `json:"msg"`
+	Message string          `json:"message"`
+	Data    json.RawMessage `json:"data"`
+
+	endpoint string
+	body     []byte
+}
+
+func decodeEnvelope(endpoint string, body []byte) (*envelope, error) {
+	env := &envelope{endpoint: endpoint, body: body}
+	if err := json.Unmarshal(body, env); err != nil {
+		return nil, env.fail(err)
+	}
+	if env.Errno == nil && env.Code == nil {
+		return nil, env.fail(errors.New("缺少 errno/code 字段"))
+	}
+	return env, nil
+}
+
+func (e *envelope) fail(err error) *ResponseError {
+	return &ResponseError{Endpoint: e.endpoint, Body: e.body, Err: err}
+}
+
+func (e *envelope) code() int {
+	if e.Errno != nil {
+		return *e.Errno
+	}
+	return *e.Code
+}
+
+func (e *envelope) message() string {
+	if e.Msg != "" {
+		return e.Msg
+	}
+	return e.Message
+}
+
+// decodeData 按 v 的结构解析 data，data 缺失或为 null 时报错
+func (e *envelope) decodeData(v any) error {
+	data := bytes.TrimSpace(e.Data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return e.fail(fmt.Errorf("errno=%d 缺少 data", e.code()))
+	}
+	if err := json.Unmarshal(data, v); err != nil {
+		return e.fail(fmt.Errorf("data: %w", err))
+	}
+	return nil
+}
+
+// PrepareResponse order/prepare 的响应。Errno 为 -401 时需要先过验证码，RiskParams 原样提交给 gaia-vgate
+type PrepareResponse struct {
+	Errno      int
+	Message    string
+	Token      string
+	RiskParams json.RawMessage
+}
+
+func DecodePrepareResponse(body []byte) (*PrepareResponse, error) {
+	env, err := decodeEnvelope(endpointPrepare, body)
+	if err != nil {
+		return nil, err
+	}
+	resp := &PrepareResponse{Errno: env.code(), Message: env.message()}
+	switch resp.Errno {
+	case 0:
+		var data struct {
+			Token string `json:"token"`
+		}
+		if err := env.decodeData(&data); err != nil {
+			return nil, err
+		}
+		if data.Token == "" {
+			return nil, env.fail(errors.New("缺少 data.token"))
+		}
+		resp.Token = data.Token
+	case -401:
+		var data struct {
+			GaData struct {
+				RiskParams json.RawMessage `json:"riskParams"`
+			} `json:"ga_data"`
+		}
+		if err := env.decodeData(&data); err != nil {
+			return nil, err
+		}
+		if !isJSONObject(data.GaData.RiskParams) {
+			return nil, env.fail(errors.New("缺少 data.ga_data.riskParams"))
+		}
+		resp.RiskParams = data.GaData.RiskParams
+	}
+	return resp, nil
+}
+
+// CreateV2Response order/createV2 的响应。成功时必须带订单号，100034 票价错误时带新的票价
+type CreateV2Response struct {
+	Errno    int
+	Message  string
+	OrderID  int64
+	PayMoney *int // 100034 时一定有，其他错误码带了才有
+}
+
+func DecodeCreateV2Response(body []byte) (*CreateV2Response, error) {
+	env, err := decodeEnvelope(endpointCreateV2, body)
+	if err != nil {
+		return nil, err
+	}
+	resp := &CreateV2Response{Errno: env.code(), Message: env.message()}
+	switch resp.Errno {
+	case 0:
+		var data struct {
+			OrderID    int64 `json:"orderId"`
+			OrderIDAlt int64 `json:"order_id"`
+		}
+		if err := env.decodeData(&data); err != nil {
+			return nil, err
+		}
+		resp.OrderID = max(data.OrderID, data.OrderIDAlt)
+		if resp.OrderID == 0 {
+			return nil, env.fail(errors.New("errno=0 但缺少 data.orderId"))
+		}
+	case 100034:
+		var data struct {
+			PayMoney *int `json:"pay_money"`
+		}
+		if err := env.decodeData(&data); err != nil {
+			return nil, err
+		}
+		if data.PayMoney == nil || *data.PayMoney <= 0 {
+			return nil, env.fail(errors.New("票价错误但缺少 data.pay_money"))
+		}
+		resp.PayMoney = data.PayMoney
+	default:
+		// 策略表可能把其他错误码也配置成 update_price，这里不强制要求
+		var data struct {
+			PayMoney *int `json:"pay_money"`
+		}
+		if json.Unmarshal(env.Data, &data) == nil && data.PayMoney != nil && *data.PayMoney > 0 {
+			resp.PayMoney = data.PayMoney
+		}
+	}
+	return resp, nil
+}
+
+// CaptchaRegisterResponse gaia-vgate/v1/register 的响应
+type CaptchaRegisterResponse struct {
+	Code      int
+	Message   string
+	Token     string
+	Type      string // geetest 或 phone
+	GT        string // 仅 geetest
+	Challenge string
+}
+
+func DecodeCaptchaRegisterResponse(body []byte) (*CaptchaRegisterResponse, error) {
+	env, err := decodeEnvelope(endpointCaptchaRegister, body)
+	if err != nil {
+		return nil, err
+	}
+	resp := &CaptchaRegisterResponse{Code: env.code(), Message: env.message()}
+	if resp.Code != 0 {
+		return resp, nil
+	}
+	var data struct {
+		Token   string `json:"token"`
+		Type    string `json:"type"`
+		Geetest *struct {
+			GT        string `json:"gt"`
+			Challenge string `json:"challenge"`
+		} `json:"geetest"`
+	}
+	if err := env.decodeData(&data); err != nil {
+		return nil, err
+	}
+	if data.Token == "" || data.Type == "" {
+		return nil, env.fail(errors.New("缺少 data.token 或 data.type"))
+	}
+	resp.Token, resp.Type = data.Token, data.Type
+	if data.Type == "geetest" {
+		if data.Geetest == nil || data.Geetest.GT == "" || data.Geetest.Challenge == "" {
+			return nil, env.fail(errors.New("缺少 data.geetest.gt 或 challenge"))
+		}
+		resp.GT, resp.Challenge = data.Geetest.GT, data.Geetest.Challenge
+	}
+	return resp, nil
+}
+
+// CaptchaValidateResponse gaia-vgate/v1/validate 的响应，只关心状态码
+type CaptchaValidateResponse struct {
+	Code    int
+	Message string
+}
+
+func DecodeCaptchaValidateResponse(body []byte) (*CaptchaValidateResponse, error) {
+	env, err := decodeEnvelope(endpointCaptchaValidate, body)
+	if err != nil {
+		return nil, err
+	}
+	return &CaptchaValidateResponse{Code: env.code(), Message: env.message()}, nil
+}
+
+// NavResponse x/web-interface/nav 的响应。cookie 失效或已退出登录时 Code 为 -101
+type NavResponse struct {
+	Code    int
+	Message string
+	IsLogin bool
+	Mid     int64
+	Uname   string
+}
+
+func DecodeNavResponse(body []byte) (*NavResponse, error) {
+	env, err := decodeEnvelope(endpointNav, body)
+	if err != nil {
+		return nil, err
+	}
+	resp := &NavResponse{Code: env.code(), Message: env.message()}
+	if resp.Code != 0 {
+		return resp, nil
+	}
+	var data struct {
+		IsLogin bool   `json:"isLogin"`
+		Mid     int64  `json:"mid"`
+		Uname   string `json:"uname"`
+	}
+	if err := env.decodeData(&data); err != nil {
+		return nil, err
+	}
+	if data.IsLogin && data.Mid <= 0 {
+		return nil, env.fail(errors.New("已登录但缺少 data.mid"))
+	}
+	resp.IsLogin, resp.Mid, resp.Uname = data.IsLogin, data.Mid, data.Uname
+	return resp, nil
+}
+
+// BuyerListResponse ticket/buyer/list 的响应，成功时 data.list 可以为空但不能缺失
+type BuyerListResponse struct {
+	Errno   int
+	Message string
+	Buyers  []BuyerInfo
+}
+
+func DecodeBuyerListResponse(body []byte) (*BuyerListResponse, error) {
+	env, err := decodeEnvelope(endpointBuyerList, body)
+	if err != nil {
+		return nil, err
+	}
+	resp := &BuyerListResponse{Errno: env.code(), Message: env.message()}
+	if resp.Errno != 0 {
+		return resp, nil
+	}
+	var data struct {
+		List *[]BuyerInfo `json:"list"`
+	}
+	if err := env.decodeData(&data); err != nil {
+		return nil, err
+	}
+	if data.List == nil {
+		return nil, env.fail(errors.New("缺少 data.list"))
+	}
+	resp.Buyers = *data.List
+	return resp, nil
+}
+
+func isJSONObject(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 2 && raw[0] == '{'
+}
@@ -0,0 +1,136 @@
+package worker
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "重新生成 testdata/api 下的 .golden 文件")
+
+// decoders testdata/api 下文件名前缀对应的解析函数
+var decoders = map[string]func([]byte) (any, error){
+	"prepare":   func(b []byte) (any, error) { return DecodePrepareResponse(b) },
+	"createV2":  func(b []byte) (any, error) { return DecodeCreateV2Response(b) },
+	"register":  func(b []byte) (any, error) { return DecodeCaptchaRegisterResponse(b) },
+	"validate":  func(b []byte) (any, error) { return DecodeCaptchaValidateResponse(b) },
+	"nav":       func(b []byte) (any, error) { return DecodeNavResponse(b) },
+	"buyerlist": func(b []byte) (any, error) { return DecodeBuyerListResponse(b) },
+}
+
+func decoderFor(t testing.TB, path string) func([]byte) (any, error) {
+	prefix, _, _ := strings.Cut(filepath.Base(path), "_")
+	decode, ok := decoders[prefix]
+	if !ok {
+		t.Fatalf("no decoder for %s", path)
+	}
+	return decode
+}
+
+// describe 解析结果的稳定文本形式，格式错误时只输出接口和原因
+func describe(resp any, err error) []byte {
+	if err != nil {
+		var respErr *ResponseError
+		if !errors.As(err, &respErr) {
+			return []byte(fmt.Sprintf("unexpected error type %T: %v\n", err, err))
+		}
+		return []byte(fmt.Sprintf("ResponseError %s: %v\n", respErr.Endpoint, respErr.Err))
+	}
+	out, _ := json.MarshalIndent(resp, "", "  ")
+	return append(out, '\n')
+}
+
+func TestDecodeGolden(t *testing.T) {
+	files, _ := filepath.Glob("testdata/api/*.json")
+	if len(files) == 0 {
+		t.Fatal("no recorded payloads")
+	}
+	for _, file := range files {
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			body, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := describe(decoderFor(t, file)(body))
+			golden := strings.TrimSuffix(file, ".json") + ".golden"
+			if *update {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("%v（使用 -update 生成）", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("decoded %s:\n%s\nwant:\n%s", file, got, want)
+			}
+		})
+	}
+}
+
+// addSeeds 用录制的响应作为 fuzz 的初始语料
+func addSeeds(f *testing.F, prefix string) {
+	files, _ := filepath.Glob("testdata/api/" + prefix + "_*.json")
+	for _, file := range files {
+		body, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(body)
+	}
+}
+
+// checkDecoded 解析失败只能是 ResponseError，不能 panic，也不能把缺字段的响应当作成功
+func checkDecoded(t *testing.T, err error) {
+	var respErr *ResponseError
+	if err != nil && !errors.As(err, &respErr) {
+		t.Fatalf("unexpected error type %T: %v", err, err)
+	}
+}
+
+func FuzzDecodePrepareResponse(f *testing.F) {
+	addSeeds(f, "prepare")
+	f.Fuzz(func(t *testing.T, body []byte) {
+		resp, err := DecodePrepareResponse(body)
+		checkDecoded(t, err)
+		if err == nil && resp.Errno == 0 && resp.Token == "" {
+			t.Fatalf("success without token: %s", body)
+		}
+		if err == nil && resp.Errno == -401 && !isJSONObject(resp.RiskParams) {
+			t.Fatalf("captcha without riskParams: %s", body)
+		}
+	})
+}
+
+func FuzzDecodeCreateV2Response(f *testing.F) {
+	addSeeds(f, "createV2")
+	f.Fuzz(func(t *testing.T, body []byte) {
+		resp, err := DecodeCreateV2Response(body)
+		checkDecoded(t, err)
+		if err == nil && resp.Errno == 0 && resp.OrderID == 0 {
+			t.Fatalf("success without order id: %s", body)
+		}
+		if err == nil && resp.Errno == 100034 && resp.PayMoney == nil {
+			t.Fatalf("price error without pay_money: %s", body)
+		}
+	})
+}
+
+func FuzzDecodeCaptchaRegisterResponse(f *testing.F) {
+	addSeeds(f, "register")
+	f.Fuzz(func(t *testing.T, body []byte) {
+		resp, err := DecodeCaptchaRegisterResponse(body)
+		checkDecoded(t, err)
+		if err == nil && resp.Code == 0 && (resp.Token == "" || resp.Type == "geetest" && resp.GT == "") {
+			t.Fatalf("incomplete register response accepted: %s", body)
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package worker
+
+import (
+	"biliTickerStorm/internal/common"
+	workerpb "biliTickerStorm/internal/worker/pb"
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+// App 组装 Register、Worker、PushTask gRPC 服务和运维 HTTP 接口
+type App struct {
+	cfg      *Config
+	Register *Register
+	Worker   *Worker
+	grpc     *grpc.Server
+	health   *common.Health
+	ops      *common.OpsServer
+	stop     chan struct{}
+}
+
+// New 按 cfg 创建 worker，opts 用于指定 worker ID 或自定义到 master 的连接
+func New(cfg *Config, opts ...RegisterOption) (*App, error) {
+	port := ""
+	if cfg.ListenAddr != "" {
+		_, p, err := net.SplitHostPort(cfg.ListenAddr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid listen addr %q: %w", cfg.ListenAddr, err)
+		}
+		port = p
+	}
+	register := NewWorkerManager(cfg.MasterServerAddr, opts...)
+	register.port = port
+	app := &App{
+		cfg:      cfg,
+		Register: register,
+		Worker:   NewWorker(cfg, register),
+		grpc:     grpc.NewServer(),
+		health:   common.NewHealth(),
+		stop:     make(chan struct{}),
+	}
+	app.health.AddCheck("master", register.Ready)
+	app.health.AddCheck("gt", func(ctx context.Context) error { return CheckGTService(ctx, cfg.GTBaseURL) })
+	workerpb.RegisterTicketWorkerServer(app.grpc, NewServer(app.Worker))
+	healthpb.RegisterHealthServer(app.grpc, app.health.GRPCServer())
+	return app, nil
+}
+
+// Start 启动运维 HTTP，注册到 master 并开始心跳，不监听 gRPC 端口
+func (a *App) Start() error {
+	mux := common.NewOpsMux()
+	a.health.Register(mux)
+	a.ops = common.ServeOps(a.cfg.HTTPAddr, mux)
+	go a.health.Run(5*time.Second, a.stop)
+
+	retries := max(a.cfg.RegisterRetries, 1)
+	var err error
+	for i := 0; i < retries; i++ {
+		if err = a.Register.RegisterToMaster(); err == nil {
+			break
+		}
+		log.Errorf("注册尝试 %d/%d 失败: %v", i+1, retries, err)
+		if i < retries-1 {
+			time.Sleep(time.Duration(i+1) * 2 * time.Second) // 线性退避
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("register to master: %w", err)
+	}
+	go a.Register.StartHeartbeat(a.cfg.HeartbeatInterval)
+	return nil
+}
+
+// Serve 在 lis 上提供 PushTask 服务，直到 Stop
+func (a *App) Serve(lis net.Listener) error {
+	return a.grpc.Serve(lis)
+}
+
+// ListenAndServe 监听配置中的 ListenAddr
+func (a *App) ListenAndServe() error {
+	lis, err := net.Listen("tcp", a.cfg.ListenAddr)
+	if err != nil {
+		return err
+	}
+	log.Printf("listening at %s", a.cfg.ListenAddr)
+	return a.Serve(lis)
+}
+
+// Stop 通知 master 下线，断开连接并关闭服务
+func (a *App) Stop() {
+	close(a.stop)
+	if err := a.Register.CancelTask(common.Down); err != nil {
+		log.Errorf("%v", err)
+	}
+	a.Register.Stop()
+	a.grpc.GracefulStop()
+	if a.ops != nil {
+		a.ops.Shutdown()
+	}
+}
@@ -3,7 +3,7 @@ package worker
 import (
 	. "biliTickerStorm/internal/common"
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
 	"time"
@@ -12,138 +12,96 @@ import (
 
 var log = GetLogger("worker")
 
-func (w *Worker) Buy(ctx context.Context, ticketsInfo BiliTickerBuyConfig, timeStart *time.Time, interval int, pushplusToken string) error {
+// BuyResult 一次抢票任务的结果，由 RunTask 上报给 master
+type BuyResult struct {
+	Errno    int // 最后一次 createV2 的 errno，未请求过为 -1
+	Message  string
+	OrderId  int64
+	PayMoney int
+	Attempts int  // createV2 请求次数
+	Ordered  bool // 下单成功或已有未完成订单
+}
+
+// Buy 执行一次抢票，直到成功、策略判定失败或 ctx 结束。状态变化随心跳上报给 master
+func (w *Worker) Buy(ctx context.Context, ticketsInfo BiliTickerBuyConfig, schedule TaskSchedule) (*BuyResult, error) {
 	log.WithFields(logrus.Fields{
-		"detail":        ticketsInfo.Detail,
-		"timeStart":     timeStart,
-		"interval":      interval,
-		"pushplusToken": pushplusToken,
-		"Username":      ticketsInfo.Username,
+		"detail":    ticketsInfo.Detail,
+		"timeStart": schedule.TimeStart,
+		"timeEnd":   schedule.TimeEnd,
+		"interval":  schedule.Interval,
+		"notify":    len(schedule.notifyTargets()),
+		"Username":  ticketsInfo.Username,
 	}).Info("接受到抢票任务")
-	client := NewBiliClient(ticketsInfo.Cookies, w)
-	tokenPayload := map[string]interface{}{
-		"count":      ticketsInfo.Count,
-		"screen_id":  ticketsInfo.ScreenId,
-		"order_type": 1,
-		"project_id": ticketsInfo.ProjectId,
-		"sku_id":     ticketsInfo.SkuId,
-		"token":      "",
-		"newRisk":    true,
+	p := w.newPurchase(ticketsInfo, schedule)
+	if w.m != nil {
+		w.m.SetPurchaseState(p.state)
+		p.onTransition(func(e PurchaseEvent) { w.m.SetPurchaseState(e.To) })
 	}
-	if timeStart != nil {
-		log.Infof("开始时间 :%s", timeStart.String())
-		err := SleepUntilAccurate(*timeStart)
-		if err != nil {
-			return err
-		}
+	p.run(ctx)
+	if p.state != StateCancelled {
+		return p.result, p.err
 	}
-	for {
-		select {
-		case <-ctx.Done():
-			err := w.m.CancelTask(Risking)
-			if err != nil {
-				return err
-			}
-			return fmt.Errorf("任务被取消: %w", ctx.Err())
-		default:
-		}
-		log.Info("1）订单准备")
-		prepareURL := fmt.Sprintf("https://show.bilibili.com/api/ticket/order/prepare?project_id=%d", ticketsInfo.ProjectId)
-		resp, err := client.Post(prepareURL, tokenPayload)
-		if err != nil {
-			log.Errorf("读取响应失败: %v", err)
-			continue
-		}
-		var requestResult map[string]interface{}
-		if err := json.Unmarshal(resp, &requestResult); err != nil {
-			log.Errorf("解析响应失败: %s", string(resp))
-			continue
-		}
-		code := getIntFromMap(requestResult, "errno", "code")
-		if code == -401 {
-			log.Info("检测到验证码，调用验证码服务处理")
-			err := HandleCaptcha(client, requestResult, ticketsInfo.Phone)
-			if err != nil {
-				log.Info("验证码失败")
-			} else {
-				log.Info("过验证码失败")
-			}
-			continue
-		}
-		if data, ok := requestResult["data"].(map[string]interface{}); ok {
-			if token, ok := data["token"].(string); ok {
-				ticketsInfo.Token = token
-			}
-		}
-		log.Info("2）创建订单")
-		ticketsInfo.Again = 1
-		ticketsInfo.Timestamp = time.Now().UnixNano() / int64(time.Millisecond)
-		createURL := fmt.Sprintf("https://show.bilibili.com/api/ticket/order/createV2?project_id=%d", ticketsInfo.ProjectId)
-		var errno int
-		for attempt := 1; attempt <= 60; attempt++ {
-			body, err := ticketsInfo.ToCreateV2RequestBody()
-			if err != nil {
-				log.Errorf("[尝试 %d/60] 创建CreateV2请求体失败: %v", attempt, err)
-				time.Sleep(time.Duration(interval) * time.Millisecond)
-				continue
-			}
-			resp, err := client.Post(createURL, body)
-			if err != nil {
-				log.Errorf("[尝试 %d/60] 请求异常: %v", attempt, err)
-				time.Sleep(time.Duration(interval) * time.Millisecond)
-				continue
-			}
-			var ret map[string]interface{}
-			if err := json.Unmarshal(resp, &ret); err != nil {
-				log.Errorf("[尝试 %d/60] 解析响应失败: %v", attempt, err)
-				time.Sleep(time.Duration(interval) * time.Millisecond)
-				continue
-			}
-			errno = getIntFromMap(ret, "errno", "code")
-			errMsg := errnoDict[errno]
-			if errMsg == "" {
-				errMsg = "未知错误码"
-			}
-			log.Infof("[Create] attempt=%d errno=%d msg=%s", attempt, errno, errMsg)
-			if errno == 100034 {
-				if data, ok := ret["data"].(map[string]interface{}); ok {
-					if payMoney, ok := data["pay_money"].(float64); ok {
-						log.Infof("更新票价为：%.2f", payMoney/100)
-						ticketsInfo.PayMoney = int(payMoney)
-					}
-				}
-			}
-			//抢票成功
-			if errno == 0 {
-				log.Info("3）抢票成功，请前往订单中心查看")
-				if pushplusToken != "" {
-					err := sendPushPlusMessage(pushplusToken, "抢票成功", "前往订单中心付款吧")
-					if err != nil {
-						return err
-					}
-				}
-				break
-			}
-			if errno == 100048 || errno == 100079 {
-				log.Info("已经下单，有尚未完成订单")
-				break
-			}
-			if errno == 100051 {
-				log.Info("订单准备过期，重新验证")
-				break
-			}
-			time.Sleep(time.Duration(interval) * time.Millisecond)
-		}
-		if errno == 100051 {
-			log.Info("token过期，需要重新准备订单")
-			continue
-		}
-		if errno == 0 {
-			break
+	cause := p.err
+	if errors.Is(cause, ErrSaleEnded) {
+		// 任务本身结束，由 RunTask 上报失败结果，不需要交还 master
+		return p.result, cause
+	}
+	ws := Idle
+	if errors.Is(cause, ErrRiskControl) {
+		ws = Risking
+	}
+	if w.m != nil {
+		if err := w.m.CancelTask(ws); err != nil {
+			log.Warningf("通知 master 释放任务失败: %v", err)
 		}
-		log.Info("0）重新下单")
+	}
+	return p.result, fmt.Errorf("任务被取消: %w", cause)
+}
 
+// startSignalGrace 本地时钟到达开抢时间后继续等待开始信号的时间，超时按本地时钟开始
+const startSignalGrace = 500 * time.Millisecond
+
+// waitStart 等到开抢时间。SyncStart 时等待 master 的开始信号，返回是否由信号唤醒；
+// ctx 被取消时直接返回，由状态机处理
+func (w *Worker) waitStart(ctx context.Context, schedule TaskSchedule) bool {
+	if schedule.TimeStart == nil {
+		return false
+	}
+	if !schedule.SyncStart {
+		log.Infof("开始时间 :%s", schedule.TimeStart.String())
+		if err := WaitUntil(ctx, w.clock, *schedule.TimeStart); err != nil {
+			log.Infof("等待开抢时任务被取消: %v", err)
+		}
+		return false
+	}
+	// master 测得的偏差以本机系统时钟为基准，这里不使用 NTP 校准
+	local := schedule.TimeStart.Add(schedule.ClockOffset)
+	log.Infof("等待 master 开始信号，开始时间 :%s（本地时钟偏差 %v）", schedule.TimeStart.String(), schedule.ClockOffset)
+	w.mu.Lock()
+	started := w.started
+	w.mu.Unlock()
+	timer := time.NewTimer(time.Until(local) + startSignalGrace)
+	defer timer.Stop()
+	select {
+	case <-started:
+		return true
+	case <-timer.C:
+		log.Warning("未收到 master 开始信号，按本地时钟开始")
+		return false
+	case <-ctx.Done():
+		return false
 	}
+}
 
-	return nil
+// reportStart 异步上报第一个 prepare 请求的发出时间，不耽误抢票
+func (w *Worker) reportStart(sentAt time.Time, bySignal bool) {
+	taskID := w.CurrentTask()
+	if taskID == "" || w.m == nil {
+		return
+	}
+	go func() {
+		if err := w.m.ReportStart(taskID, sentAt, bySignal); err != nil {
+			log.Warningf("上报开始时间失败: %v", err)
+		}
+	}()
 }
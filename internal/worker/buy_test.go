@@ -0,0 +1,201 @@
+package worker
+
+import (
+	. "biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/fakebili"
+	"context"
+	"errors"
+	"github.com/valyala/fasthttp"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// useFakeBili 启动进程内的假服务器，返回的配置让 BiliClient 和验证码服务都指向它
+func useFakeBili(t *testing.T, scenario fakebili.Scenario) (*fakebili.Server, *Config) {
+	t.Helper()
+	fake, srv := fakebili.NewTestServer(scenario)
+	t.Cleanup(srv.Close)
+	cfg := DefaultConfig()
+	cfg.ShowBaseURL, cfg.APIBaseURL, cfg.GTBaseURL = srv.URL, srv.URL, srv.URL
+	return fake, cfg
+}
+
+func newTestWorker(t *testing.T, cfg *Config) (*Worker, context.Context) {
+	t.Helper()
+	m := NewWorkerManager("127.0.0.1:1") // 不可达，CancelTask 只会记录警告
+	t.Cleanup(m.Stop)
+	w := NewWorker(cfg, m)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	w.cancel = cancel
+	t.Cleanup(func() { cancel(nil) })
+	return w, ctx
+}
+
+func TestBuyAgainstFake(t *testing.T) {
+	fake, cfg := useFakeBili(t, fakebili.Scenario{Captcha: "geetest", Price: 12800, TokenExpiry: 2, SuccessOn: 4, OrderID: 42})
+	w, ctx := newTestWorker(t, cfg)
+
+	result, err := w.Buy(ctx, BiliTickerBuyConfig{ProjectId: 1, PayMoney: 10000}, TaskSchedule{Interval: 1})
+	if err != nil {
+		t.Fatalf("Buy: %v", err)
+	}
+	if result.Errno != 0 || result.OrderId != 42 || result.PayMoney != 12800 {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if fake.Calls(fakebili.PathValidate) != 1 {
+		t.Errorf("captcha should be validated once, got %d", fake.Calls(fakebili.PathValidate))
+	}
+	// 100034 改价后 token 用满两次需要重新 prepare
+	if got := fake.Calls(fakebili.PathPrepare); got < 3 {
+		t.Errorf("expected re-prepare after token expiry, prepare calls = %d", got)
+	}
+}
+
+func TestBuyRiskControl(t *testing.T) {
+	_, cfg := useFakeBili(t, fakebili.Scenario{RiskAfter: 3})
+	w, ctx := newTestWorker(t, cfg)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Buy(ctx, BiliTickerBuyConfig{ProjectId: 1}, TaskSchedule{Interval: 1})
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrRiskControl) {
+			t.Fatalf("expected ErrRiskControl, got %v", err)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("Buy did not stop after 412")
+	}
+}
+
+func TestBuyWaitsOnClock(t *testing.T) {
+	fake, cfg := useFakeBili(t, fakebili.Scenario{})
+	w, _ := newTestWorker(t, cfg)
+	clock := NewFakeClock(time.Now())
+	w.clock = clock
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	start := clock.Now().Add(time.Hour)
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Buy(ctx, BiliTickerBuyConfig{ProjectId: 1}, TaskSchedule{TimeStart: &start, Interval: int(time.Hour / time.Millisecond)})
+		done <- err
+	}()
+	waitFor(t, func() bool { return clock.Timers() == 1 })
+	if fake.Calls(fakebili.PathPrepare) != 0 {
+		t.Fatal("prepare sent before start time")
+	}
+	clock.Advance(time.Hour)
+
+	// 第一次 createV2 之后进入一小时的重试间隔，取消要立即生效
+	waitFor(t, func() bool { return fake.Calls(fakebili.PathCreateV2) == 1 && clock.Timers() == 1 })
+	cancel(ErrTaskStopped)
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrTaskStopped) {
+			t.Fatalf("expected ErrTaskStopped, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Buy did not stop during interval sleep")
+	}
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBuyAbortsInFlightRequest(t *testing.T) {
+	fake, cfg := useFakeBili(t, fakebili.Scenario{Delay: time.Second})
+	w, _ := newTestWorker(t, cfg)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := w.Buy(ctx, BiliTickerBuyConfig{ProjectId: 1}, TaskSchedule{Interval: 1})
+		done <- err
+	}()
+	waitFor(t, func() bool { return fake.Calls(fakebili.PathPrepare) == 1 })
+	stoppedAt := time.Now()
+	cancel(ErrTaskStopped)
+	select {
+	case err := <-done:
+		if !errors.Is(err, ErrTaskStopped) {
+			t.Fatalf("expected ErrTaskStopped, got %v", err)
+		}
+		if elapsed := time.Since(stoppedAt); elapsed > 100*time.Millisecond {
+			t.Fatalf("Buy returned %v after stop, request was not aborted", elapsed)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Buy did not stop during prepare")
+	}
+}
+
+func TestRequestDeadline(t *testing.T) {
+	_, cfg := useFakeBili(t, fakebili.Scenario{Delay: time.Second})
+	client := NewBiliClient(cfg, nil, nil)
+
+	// 售票窗口结束的原因要透传给调用方
+	ctx, cancel := context.WithDeadlineCause(context.Background(), time.Now().Add(50*time.Millisecond), ErrSaleEnded)
+	defer cancel()
+	start := time.Now()
+	_, err := client.Post(ctx, client.showBaseURL+fakebili.PathPrepare, map[string]any{})
+	if !errors.Is(err, ErrSaleEnded) {
+		t.Fatalf("expected ErrSaleEnded, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("request took %v, deadline not applied", elapsed)
+	}
+}
+
+func TestRequestReadTimeoutBeforeDeadline(t *testing.T) {
+	_, cfg := useFakeBili(t, fakebili.Scenario{Delay: time.Second})
+	client := NewBiliClient(cfg, nil, nil)
+	client.client.ReadTimeout = 50 * time.Millisecond
+
+	// 截止时间还远时 client 超时要直接返回，不能等到售票窗口结束
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	start := time.Now()
+	_, err := client.Post(ctx, client.showBaseURL+fakebili.PathPrepare, map[string]any{})
+	if !errors.Is(err, fasthttp.ErrTimeout) {
+		t.Fatalf("expected fasthttp.ErrTimeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Fatalf("request took %v, read timeout was not returned", elapsed)
+	}
+}
+
+func TestBuyWithoutMaster(t *testing.T) {
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer hook.Close()
+	_, cfg := useFakeBili(t, fakebili.Scenario{SuccessOn: 1, OrderID: 7})
+	cfg.NotifyTargets = []string{"webhook+" + hook.URL}
+	w := NewWorker(cfg, nil)
+
+	// 不连接 master 时成功通知和风控取消都不能依赖 Register
+	result, err := w.Buy(context.Background(), BiliTickerBuyConfig{ProjectId: 1}, TaskSchedule{Interval: 1}.withDefaults(cfg))
+	if err != nil || !result.Ordered {
+		t.Fatalf("Buy: %+v %v", result, err)
+	}
+	_, cfg = useFakeBili(t, fakebili.Scenario{RiskAfter: 1})
+	w = NewWorker(cfg, nil)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	defer cancel(nil)
+	w.cancel = cancel
+	if _, err := w.Buy(ctx, BiliTickerBuyConfig{ProjectId: 1}, TaskSchedule{Interval: 1}); !errors.Is(err, ErrRiskControl) {
+		t.Fatalf("expected ErrRiskControl, got %v", err)
+	}
+}
@@ -1,72 +1,45 @@
 package worker
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/valyala/fasthttp"
 	"net/http"
 )
 
-func HandleCaptcha(client *BiliClient, requestResult map[string]interface{}, phone string) error {
-	data, ok := requestResult["data"].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("无法获取data字段")
-	}
-	gaData, ok := data["ga_data"].(map[string]interface{})
+// HandleCaptcha 用 prepare 返回的 riskParams 注册验证码，按类型完成极验或手机验证
+func HandleCaptcha(ctx context.Context, client *BiliClient, riskParams json.RawMessage, phone string) error {
 	csrf := client.getCookieValue("bili_jct")
-	if !ok {
-		return fmt.Errorf("无法获取ga_data字段")
-	}
-	riskParams, ok := gaData["riskParams"]
-	resp, err := client.Post("https://api.bilibili.com/x/gaia-vgate/v1/register", riskParams)
+	resp, err := client.Post(ctx, client.apiBaseURL+"/x/gaia-vgate/v1/register", riskParams)
 	if err != nil {
 		return fmt.Errorf("验证码注册请求失败: %v", err)
 	}
-	var registerResult map[string]interface{}
-	if err := json.Unmarshal(resp, &registerResult); err != nil {
-		return fmt.Errorf("解析验证码注册响应失败: %v", err)
-	}
-	registerData, ok := registerResult["data"].(map[string]interface{})
-	if !ok {
-		return fmt.Errorf("验证码注册响应格式错误")
-	}
-	token, ok := registerData["token"].(string)
-	if !ok {
-		return fmt.Errorf("无法获取验证码token")
+	register, err := DecodeCaptchaRegisterResponse(resp)
+	if err != nil {
+		return err
 	}
-	captchaType, ok := registerData["type"].(string)
-	if !ok {
-		return fmt.Errorf("无法获取验证码类型")
+	if register.Code != 0 {
+		return fmt.Errorf("验证码注册失败: code=%d %s", register.Code, register.Message)
 	}
-	var validateData map[string]interface{}
-	switch captchaType {
+	var validateResp []byte
+	switch register.Type {
 	case "geetest":
-		gt, ok := GetNestedString(registerData, "geetest", "gt")
-		if !ok {
-			return fmt.Errorf("无法获取gt参数")
-		}
-		challenge, ok := GetNestedString(registerData, "geetest", "challenge")
-		if !ok {
-			return fmt.Errorf("无法获取challenge参数")
-		}
-		validate, seccode, err := HandleGeetest(gt, challenge)
+		validate, seccode, err := HandleGeetest(ctx, client, register.GT, register.Challenge)
 		if err != nil {
 			return fmt.Errorf("极验验证码处理失败: %v", err)
 		}
 		requestBody := map[string]string{
-			"challenge": challenge,
-			"token":     token,
+			"challenge": register.Challenge,
+			"token":     register.Token,
 			"seccode":   seccode,
 			"csrf":      csrf,
 			"validate":  validate,
 		}
-		resp, err := client.DoFormRequest("https://api.bilibili.com/x/gaia-vgate/v1/validate", requestBody)
+		validateResp, err = client.DoFormRequest(ctx, client.apiBaseURL+"/x/gaia-vgate/v1/validate", requestBody)
 		if err != nil {
 			return fmt.Errorf("极验验证请求失败: %v", err)
 		}
-		if err := json.Unmarshal(resp, &validateData); err != nil {
-			return fmt.Errorf("解析极验验证响应失败: %v", err)
-		}
 	case "phone":
 		if phone == "" {
 			return fmt.Errorf("需要手机号码进行验证")
@@ -74,30 +47,29 @@ func HandleCaptcha(client *BiliClient, requestResult map[string]interface{}, pho
 		requestBody := map[string]interface{}{
 			"code":  phone,
 			"csrf":  csrf,
-			"token": token,
+			"token": register.Token,
 		}
-		resp, err := client.Post("https://api.bilibili.com/x/gaia-vgate/v1/validate", requestBody)
+		validateResp, err = client.Post(ctx, client.apiBaseURL+"/x/gaia-vgate/v1/validate", requestBody)
 		if err != nil {
 			return fmt.Errorf("手机验证请求失败: %v", err)
 		}
-		if err := json.Unmarshal(resp, &validateData); err != nil {
-			return fmt.Errorf("解析手机验证响应失败: %v", err)
-		}
-
 	default:
-		return fmt.Errorf("这是一个程序无法应对的验证码类型: %s", captchaType)
+		return fmt.Errorf("这是一个程序无法应对的验证码类型: %s", register.Type)
 	}
 
-	code := getIntFromMap(validateData, "errno", "code")
-	if code == 0 {
-		return nil
-	} else {
-		return fmt.Errorf("验证码失败: %v", validateData)
+	result, err := DecodeCaptchaValidateResponse(validateResp)
+	if err != nil {
+		return err
+	}
+	if result.Code != 0 {
+		return fmt.Errorf("验证码失败: code=%d %s", result.Code, result.Message)
 	}
+	return nil
 }
 
-func HandleGeetest(gt string, challenge string) (validate string, seccode string, err error) {
-	gt_url := fmt.Sprintf("%s/validate/geetest", Cfg.GTBaseURL)
+// HandleGeetest 请求验证码服务识别极验，使用 worker 共用的 client，超时跟随 ctx。
+// 验证码服务不是 B 站接口，不带 cookie，状态码也不按风控处理
+func HandleGeetest(ctx context.Context, client *BiliClient, gt, challenge string) (validate string, seccode string, err error) {
 	requestBody := map[string]interface{}{
 		"type":      "geetest",
 		"gt":        gt,
@@ -107,20 +79,41 @@ func HandleGeetest(gt string, challenge string) (validate string, seccode string
 	if err != nil {
 		return "", "", fmt.Errorf("编码请求体失败: %v", err)
 	}
-	resp, err := http.Post(gt_url, "application/json", bytes.NewBuffer(jsonData))
+	req := fasthttp.AcquireRequest()
+	req.Header.SetMethod("POST")
+	req.SetRequestURI(client.gtBaseURL + "/validate/geetest")
+	req.Header.SetContentType("application/json")
+	req.SetBody(jsonData)
+	status, body, err := client.send(ctx, req)
 	if err != nil {
-		return "", "", fmt.Errorf("请求验证服务失败: %v", err)
+		return "", "", fmt.Errorf("请求验证服务失败: %w", err)
 	}
-	defer resp.Body.Close()
-	if resp.StatusCode != http.StatusOK {
-		return "", "", fmt.Errorf("验证码服务返回状态码 %d", resp.StatusCode)
+	if status != fasthttp.StatusOK {
+		return "", "", fmt.Errorf("验证码服务返回状态码 %d", status)
 	}
 	var response struct {
 		Validate string `json:"validate"`
 		Seccode  string `json:"seccode"`
 	}
-	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+	if err := json.Unmarshal(body, &response); err != nil {
 		return "", "", fmt.Errorf("解析响应失败: %v", err)
 	}
 	return response.Validate, response.Seccode, nil
 }
+
+// CheckGTService 验证码服务是否可以访问，收到任意非 5xx 响应即认为可用
+func CheckGTService(ctx context.Context, gtBaseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, gtBaseURL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("验证码服务不可达: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("验证码服务返回状态码 %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -1,8 +1,8 @@
 package worker
 
 import (
-	"encoding/json"
-	"os"
+	"biliTickerStorm/internal/fakebili"
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -10,18 +10,8 @@ import (
 
 func TestHandleGeetest_ConcurrentPerformance(t *testing.T) {
 	const concurrency = 10
-	ticketsInfoStr, err := ReadFileAsString("data.json")
-	if err != nil {
-		t.Fatalf("读取文件出错: %v", err)
-	}
-	var config BiliTickerBuyConfig
-	if err := json.Unmarshal([]byte(ticketsInfoStr), &config); err != nil {
-		t.Fatalf("解析 JSON 出错: %v", err)
-	}
-	client := NewBiliClient(config.Cookies, nil)
-	os.Setenv("GT_BASE_URL", "http://127.0.0.1:8000")
-	defer os.Unsetenv("GT_BASE_URL")
-	// 获取 gt/challenge
+	fake, cfg := useFakeBili(t, fakebili.Scenario{Captcha: "geetest"})
+	client := NewBiliClient(cfg, []Cookies{{Name: "bili_jct", Value: "csrf", Domain: ".bilibili.com"}}, nil)
 
 	var wg sync.WaitGroup
 	var totalDuration time.Duration
@@ -30,25 +20,27 @@ func TestHandleGeetest_ConcurrentPerformance(t *testing.T) {
 		wg.Add(1)
 		go func(i int) {
 			defer wg.Done()
-			get, _ := client.Get("https://passport.bilibili.com/x/passport-login/captcha?source=main_web")
-			var ret map[string]interface{}
-			if err := json.Unmarshal(get, &ret); err != nil {
-				t.Fatalf("gt challenge 获取错误: %v", err)
-			}
-			gt, ok := GetNestedString(ret, "data", "geetest", "gt")
-			if !ok {
-				t.Fatal("gt 获取失败")
+			// 获取 gt/challenge
+			get, err := client.Post(context.Background(), client.apiBaseURL+fakebili.PathRegister, map[string]string{})
+			if err != nil {
+				t.Errorf("gt challenge 获取错误: %v", err)
+				return
 			}
-			challenge, ok := GetNestedString(ret, "data", "geetest", "challenge")
-			if !ok {
-				t.Fatal("challenge 获取失败")
+			register, err := DecodeCaptchaRegisterResponse(get)
+			if err != nil {
+				t.Errorf("gt challenge 解析错误: %v", err)
+				return
 			}
+			gt, challenge := register.GT, register.Challenge
 			csrf := client.getCookieValue("bili_jct")
 			start := time.Now()
-			validate, seccode, err := HandleGeetest(gt, challenge)
+			validate, seccode, err := HandleGeetest(context.Background(), client, gt, challenge)
 			duration := time.Since(start)
-			registerData, _ := ret["data"].(map[string]interface{})
-			token, _ := registerData["token"].(string)
+			if err != nil {
+				t.Errorf("第 %d 个请求 HandleGeetest 返回错误: %v", i, err)
+				return
+			}
+			token := register.Token
 			requestBody := map[string]string{
 				"challenge": challenge,
 				"token":     token,
@@ -56,25 +48,29 @@ func TestHandleGeetest_ConcurrentPerformance(t *testing.T) {
 				"csrf":      csrf,
 				"validate":  validate,
 			}
-			resp, err := client.DoFormRequest("https://api.bilibili.com/x/gaia-vgate/v1/validate", requestBody)
+			resp, err := client.DoFormRequest(context.Background(), client.apiBaseURL+fakebili.PathValidate, requestBody)
 			if err != nil {
-				t.Errorf("第 %d 个请求 HandleGeetest 返回错误: %v", i, err)
+				t.Errorf("第 %d 个请求 validate 返回错误: %v", i, err)
 				return
 			}
-			var validateData map[string]interface{}
-			if err := json.Unmarshal(resp, &validateData); err != nil {
+			validateData, err := DecodeCaptchaValidateResponse(resp)
+			if err != nil {
+				t.Errorf("第 %d 个请求 validate 解析错误: %v", i, err)
+				return
 			}
 			if validate == "" || seccode == "" {
 				t.Errorf("第 %d 个请求返回值为空: validate=%s, seccode=%s", i, validate, seccode)
 			}
-			code := getIntFromMap(validateData, "errno", "code")
-			t.Logf("[%d] validate[%s]  code[%d]", i, validate, code)
+			t.Logf("[%d] validate[%s]  code[%d]", i, validate, validateData.Code)
 			mu.Lock()
 			totalDuration += duration
 			mu.Unlock()
 		}(i)
 	}
 	wg.Wait()
+	if got := fake.Calls(fakebili.PathGeetest); got != concurrency {
+		t.Errorf("geetest calls = %d, want %d", got, concurrency)
+	}
 	avg := totalDuration / time.Duration(concurrency)
 	t.Logf("在 %d 个并发请求下的平均响应时间: %v", concurrency, avg)
 }
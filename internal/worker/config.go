@@ -1,52 +1,179 @@
 package worker
 
 import (
+	"biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/notify"
+	"flag"
 	"fmt"
-	"github.com/caarlos0/env/v10"
 	"time"
 )
 
+// Config worker 配置，可以来自 YAML 文件、环境变量或命令行参数，见 LoadConfig
 type Config struct {
-	MasterServerAddr string     `env:"MASTER_SERVER_ADDR"`
-	TimeStartRaw     string     `env:"TICKET_TIME_START"` // 原始字符串
-	TimeStart        *time.Time // 解析后的时间
-	PushplusToken    string     `env:"PUSHPLUS_TOKEN"`
-	Interval         int        `env:"TICKET_INTERVAL" envDefault:"300"`
-	GTBaseURL        string     `env:"GT_BASE_URL"`
+	MasterServerAddr string     `env:"MASTER_SERVER_ADDR" yaml:"master_server_addr"`
+	TimeStartRaw     string     `env:"TICKET_TIME_START" yaml:"ticket_time_start"` // 原始字符串
+	TimeStart        *time.Time `yaml:"-"`                                         // 解析后的时间
+	PushplusToken    string     `env:"PUSHPLUS_TOKEN" yaml:"pushplus_token"`
+	Interval         int        `env:"TICKET_INTERVAL" yaml:"ticket_interval"` // createV2 重试间隔，毫秒
+	GTBaseURL        string     `env:"GT_BASE_URL" yaml:"gt_base_url"`
+	ShowBaseURL      string     `env:"BILI_SHOW_BASE_URL" yaml:"bili_show_base_url"` // 会员购下单接口
+	APIBaseURL       string     `env:"BILI_API_BASE_URL" yaml:"bili_api_base_url"`   // 验证码接口
+	HTTPAddr         string     `env:"HTTP_ADDR" yaml:"http_addr"`                   // /metrics 监听地址，为空则不启动
+	// PushTask 的 gRPC 监听地址，为空则只通过长连接接收任务
+	ListenAddr        string        `env:"LISTEN_ADDR" yaml:"listen_addr"`
+	HeartbeatInterval time.Duration `env:"HEARTBEAT_INTERVAL" yaml:"heartbeat_interval"`
+	RegisterRetries   int           `env:"REGISTER_RETRIES" yaml:"register_retries"` // 启动时注册到 master 的重试次数
+	// 单次请求的超时，售票窗口结束或任务被停止时会更早中止
+	PrepareTimeout time.Duration `env:"PREPARE_TIMEOUT" yaml:"prepare_timeout"`
+	CreateTimeout  time.Duration `env:"CREATE_TIMEOUT" yaml:"create_timeout"`
+	CaptchaTimeout time.Duration `env:"CAPTCHA_TIMEOUT" yaml:"captcha_timeout"` // 整个验证码流程，包括验证码服务
+	// createV2 errno 的处理策略文件，为空则使用内置规则
+	ErrnoPolicyFile string       `env:"ERRNO_POLICY_FILE" yaml:"errno_policy_file"`
+	ErrnoPolicy     *ErrnoPolicy `yaml:"-"` // 解析后的策略
+	// 全局通知目标 URL，任务没有指定通知目标时使用，格式见 notify.Parse
+	NotifyTargets  []string `env:"NOTIFY_TARGETS" yaml:"notify_targets"`
+	NotifyAttempts int      `env:"NOTIFY_ATTEMPTS" yaml:"notify_attempts"` // 每个目标最多发送次数
+	// 通知模板：内置 zh、en 两种语言，模板文件中没有写的通知使用 NotifyLang 的内置模板
+	NotifyLang         string           `env:"NOTIFY_LANG" yaml:"notify_lang"`
+	NotifyTemplateFile string           `env:"NOTIFY_TEMPLATE_FILE" yaml:"notify_template_file"`
+	NotifyTemplates    *NotifyTemplates `yaml:"-"` // 解析后的模板
+	// 没有 master 开始信号时按 NTP 校准的时间开抢，为空则使用本地时钟
+	NTPServers []string `env:"NTP_SERVERS" yaml:"ntp_servers"`
 }
 
-func LoadConfig() *Config {
-	cfg := &Config{}
-	if err := env.Parse(cfg); err != nil {
-		log.Fatalf("环境变量解析失败: %v", err)
+func DefaultConfig() *Config {
+	return &Config{
+		Interval:          300,
+		ShowBaseURL:       "https://show.bilibili.com",
+		APIBaseURL:        "https://api.bilibili.com",
+		HTTPAddr:          ":40081",
+		ListenAddr:        ":40051",
+		HeartbeatInterval: 3 * time.Second,
+		RegisterRetries:   5,
+		PrepareTimeout:    5 * time.Second,
+		CreateTimeout:     5 * time.Second,
+		CaptchaTimeout:    30 * time.Second,
+		NotifyAttempts:    notify.DefaultAttempts,
+		NotifyLang:        "zh",
+		NTPServers:        common.DefaultNTPServers,
 	}
-	if cfg.MasterServerAddr == "" {
-		log.Fatalf("❌ MASTER_SERVER_ADDR 是必需的环境变量，当前未设置")
+}
+
+func bindFlags(fs *flag.FlagSet, cfg *Config) {
+	fs.StringVar(&cfg.MasterServerAddr, "master", cfg.MasterServerAddr, "master 地址（MASTER_SERVER_ADDR）")
+	fs.StringVar(&cfg.TimeStartRaw, "time-start", cfg.TimeStartRaw, "开抢时间，格式 2006-01-02T15:04（北京时间）")
+	fs.StringVar(&cfg.PushplusToken, "pushplus-token", cfg.PushplusToken, "PushPlus token")
+	fs.IntVar(&cfg.Interval, "interval", cfg.Interval, "createV2 重试间隔（毫秒）")
+	fs.StringVar(&cfg.GTBaseURL, "gt-base-url", cfg.GTBaseURL, "验证码服务地址（GT_BASE_URL）")
+	fs.StringVar(&cfg.ShowBaseURL, "show-base-url", cfg.ShowBaseURL, "会员购接口地址")
+	fs.StringVar(&cfg.APIBaseURL, "api-base-url", cfg.APIBaseURL, "B 站 API 地址")
+	fs.StringVar(&cfg.HTTPAddr, "http-addr", cfg.HTTPAddr, "运维 HTTP 监听地址")
+	fs.StringVar(&cfg.ListenAddr, "listen", cfg.ListenAddr, "gRPC 监听地址")
+	fs.DurationVar(&cfg.HeartbeatInterval, "heartbeat-interval", cfg.HeartbeatInterval, "心跳周期")
+	fs.IntVar(&cfg.RegisterRetries, "register-retries", cfg.RegisterRetries, "启动时注册重试次数")
+	fs.DurationVar(&cfg.PrepareTimeout, "prepare-timeout", cfg.PrepareTimeout, "prepare 请求超时")
+	fs.DurationVar(&cfg.CreateTimeout, "create-timeout", cfg.CreateTimeout, "createV2 请求超时")
+	fs.DurationVar(&cfg.CaptchaTimeout, "captcha-timeout", cfg.CaptchaTimeout, "验证码流程超时")
+	fs.StringVar(&cfg.ErrnoPolicyFile, "errno-policy", cfg.ErrnoPolicyFile, "createV2 errno 处理策略文件（YAML）")
+	fs.Func("notify", "通知目标 URL，逗号分隔", func(v string) error {
+		cfg.NotifyTargets = common.SplitList(v)
+		return nil
+	})
+	fs.IntVar(&cfg.NotifyAttempts, "notify-attempts", cfg.NotifyAttempts, "每个通知目标最多发送次数")
+	fs.StringVar(&cfg.NotifyLang, "notify-lang", cfg.NotifyLang, "内置通知模板的语言：zh、en")
+	fs.StringVar(&cfg.NotifyTemplateFile, "notify-template", cfg.NotifyTemplateFile, "通知模板文件（YAML）")
+	fs.Func("ntp-servers", "NTP 服务器，逗号分隔", func(v string) error {
+		cfg.NTPServers = common.SplitList(v)
+		return nil
+	})
+}
+
+func (c *Config) errnoPolicy() *ErrnoPolicy {
+	if c.ErrnoPolicy == nil {
+		return DefaultErrnoPolicy()
 	}
-	if cfg.GTBaseURL == "" {
-		log.Fatalf("❌ GT_BASE_URL 是必需的环境变量，当前未设置")
+	return c.ErrnoPolicy
+}
+
+func (c *Config) notifyTemplates() *NotifyTemplates {
+	if c.NotifyTemplates != nil {
+		return c.NotifyTemplates
 	}
-	if cfg.PushplusToken == "" {
-		log.Println("⚠️ 未设置 PUSHPLUS_TOKEN，将不会发送推送提醒")
+	templates, err := DefaultNotifyTemplates(c.NotifyLang)
+	if err != nil {
+		templates, _ = DefaultNotifyTemplates("zh")
 	}
-	if cfg.TimeStartRaw == "" {
+	return templates
+}
+
+func (c *Config) clock() common.Clock {
+	if len(c.NTPServers) == 0 {
+		return common.SystemClock()
+	}
+	clock := common.NewNTPClock(c.NTPServers, common.DefaultNTPRefresh)
+	clock.Start()
+	return clock
+}
+
+// LoadConfig 按 默认值 < YAML 文件（-config / CONFIG_FILE）< 环境变量 < 命令行参数 读取配置
+func LoadConfig(args []string) (*Config, error) {
+	cfg, err := common.LoadLayeredConfig("worker", args, DefaultConfig, bindFlags)
+	if err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	if cfg.PushplusToken == "" && len(cfg.NotifyTargets) == 0 {
+		log.Println("⚠️ 未设置 PUSHPLUS_TOKEN 或 NOTIFY_TARGETS，任务没有指定通知目标时将不会发送推送提醒")
+	}
+	if cfg.TimeStart == nil {
 		log.Println("⚠️ 未设置 TICKET_TIME_START，将不会使用定时抢票")
-	} else {
-		//设置时间
-		loc, _ := time.LoadLocation("Asia/Shanghai")
-		TimeStart, err := time.ParseInLocation("2006-01-02T15:04", cfg.TimeStartRaw, loc)
+	}
+	log.Printf("ℹ️ 抢票重试间隔: %d 毫秒", cfg.Interval)
+	return cfg, nil
+}
+
+// Validate 检查必需项，解析开抢时间、errno 策略、通知目标和通知模板
+func (c *Config) Validate() error {
+	if c.MasterServerAddr == "" {
+		return fmt.Errorf("❌ MASTER_SERVER_ADDR 是必需的配置，当前未设置")
+	}
+	if c.GTBaseURL == "" {
+		return fmt.Errorf("❌ GT_BASE_URL 是必需的配置，当前未设置")
+	}
+	if c.Interval <= 0 {
+		log.Println("⚠️ TICKET_INTERVAL 格式错误（非正数），使用默认值 300")
+		c.Interval = 300
+	}
+	c.TimeStart = nil
+	if c.TimeStartRaw != "" {
+		timeStart, err := common.ParseTicketTime(c.TimeStartRaw)
 		if err != nil {
-			_ = fmt.Errorf("时间格式错误: %v，正确格式应为 2006-01-02T15:04（北京时间）", err)
+			return fmt.Errorf("时间格式错误: %v，正确格式应为 2006-01-02T15:04（北京时间）", err)
 		}
-		cfg.TimeStart = &TimeStart
+		c.TimeStart = &timeStart
 	}
-	if cfg.Interval <= 0 {
-		log.Println("⚠️ TICKET_INTERVAL 格式错误（非正数），使用默认值 300")
-		cfg.Interval = 300
-	} else {
-		log.Printf("ℹ️ 抢票重试间隔: %d 秒", cfg.Interval)
+	c.ErrnoPolicy = nil
+	if c.ErrnoPolicyFile != "" {
+		policy, err := LoadErrnoPolicy(c.ErrnoPolicyFile)
+		if err != nil {
+			return err
+		}
+		c.ErrnoPolicy = policy
 	}
-	return cfg
+	if _, err := notify.ParseAll(c.NotifyTargets); err != nil {
+		return fmt.Errorf("NOTIFY_TARGETS: %w", err)
+	}
+	c.NotifyTemplates = nil
+	if c.NotifyTemplateFile != "" {
+		templates, err := LoadNotifyTemplates(c.NotifyTemplateFile, c.NotifyLang)
+		if err != nil {
+			return err
+		}
+		c.NotifyTemplates = templates
+	} else if _, err := DefaultNotifyTemplates(c.NotifyLang); err != nil {
+		return err
+	}
+	return nil
 }
-
-var Cfg = LoadConfig()
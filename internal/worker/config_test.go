@@ -0,0 +1,58 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadConfigPrecedence(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "worker.yaml")
+	yaml := "master_server_addr: yaml:1\ngt_base_url: http://gt\nticket_interval: 100\nheartbeat_interval: 5s\n"
+	if err := os.WriteFile(file, []byte(yaml), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("CONFIG_FILE", file)
+	t.Setenv("TICKET_INTERVAL", "200")
+	t.Setenv("MASTER_SERVER_ADDR", "env:1")
+
+	cfg, err := LoadConfig([]string{"-master", "flag:1", "-time-start", "2025-01-02T20:00"})
+	if err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	if cfg.MasterServerAddr != "flag:1" {
+		t.Errorf("flag should override env, got %q", cfg.MasterServerAddr)
+	}
+	if cfg.Interval != 200 {
+		t.Errorf("env should override yaml, got %d", cfg.Interval)
+	}
+	if cfg.GTBaseURL != "http://gt" || cfg.HeartbeatInterval != 5*time.Second {
+		t.Errorf("yaml values not applied: %+v", cfg)
+	}
+	if cfg.ListenAddr != ":40051" {
+		t.Errorf("default listen addr lost: %q", cfg.ListenAddr)
+	}
+	if cfg.TimeStart == nil || cfg.TimeStart.Hour() != 20 {
+		t.Errorf("time start not parsed: %v", cfg.TimeStart)
+	}
+}
+
+func TestLoadConfigInvalid(t *testing.T) {
+	t.Setenv("MASTER_SERVER_ADDR", "127.0.0.1:40052")
+	t.Setenv("GT_BASE_URL", "http://gt")
+	if _, err := LoadConfig([]string{"-time-start", "tomorrow"}); err == nil {
+		t.Error("expected error for bad time start")
+	}
+	if _, err := LoadConfig([]string{"-no-such-flag"}); err == nil {
+		t.Error("expected error for unknown flag")
+	}
+
+	file := filepath.Join(t.TempDir(), "worker.yaml")
+	if err := os.WriteFile(file, []byte("unknown_key: 1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadConfig([]string{"-config", file}); err == nil {
+		t.Error("expected error for unknown yaml key")
+	}
+}
@@ -1,113 +1,94 @@
 package worker
 
 import (
-	"encoding/json"
-	"fmt"
+	"biliTickerStorm/internal/common"
+	masterpb "biliTickerStorm/internal/master/pb"
+	"biliTickerStorm/internal/notify"
+	"biliTickerStorm/internal/ticketconfig"
+	"biliTickerStorm/internal/worker/pb"
+	"slices"
+	"time"
 )
 
-type BiliTickerBuyConfig struct {
-	Username    string      `json:"username"`
-	Detail      string      `json:"detail"`
-	Count       int         `json:"count"`
-	ScreenId    int         `json:"screen_id"`
-	ProjectId   int         `json:"project_id"`
-	SkuId       int         `json:"sku_id"`
-	OrderType   int         `json:"order_type"`
-	PayMoney    int         `json:"pay_money"`
-	BuyerInfo   []BuyerInfo `json:"buyer_info"`
-	Buyer       string      `json:"buyer"`
-	Tel         string      `json:"tel"`
-	DeliverInfo DeliverInfo `json:"deliver_info"`
-	Cookies     []Cookies   `json:"cookies"`
-	Phone       string      `json:"phone"`
-	Token       string      `json:"token"`
-	Again       int         `json:"again"`
-	Timestamp   int64       `json:"timestamp"`
-}
-type Cookies struct {
-	Name     string  `json:"name"`
-	Value    string  `json:"value"`
-	Domain   string  `json:"domain"`
-	Path     string  `json:"path"`
-	Expires  float64 `json:"expires"`
-	HttpOnly bool    `json:"httpOnly"`
-	Secure   bool    `json:"secure"`
-	SameSite string  `json:"sameSite"`
+// 抢票配置定义在 ticketconfig，master 和 worker 共用
+type (
+	BiliTickerBuyConfig = ticketconfig.BiliTickerBuyConfig
+	Cookies             = ticketconfig.Cookies
+	DeliverInfo         = ticketconfig.DeliverInfo
+	BuyerInfo           = ticketconfig.BuyerInfo
+)
+
+// TaskSchedule 单个任务的售票窗口、重试间隔和通知目标，由 master 随任务下发，零值字段使用 worker 配置
+type TaskSchedule struct {
+	TimeStart     *time.Time
+	TimeEnd       *time.Time // 售票窗口结束，到达后停止抢票
+	Interval      int        // 毫秒
+	PushplusToken string
+	NotifyTargets []string        // 通知目标 URL，和 PushplusToken 都为空时使用 worker 配置
+	TaskName      string          // master 上的配置文件名，用于通知模板
+	Kind          common.TaskKind // 任务类型，为空表示抢票
+	// SyncStart 为 true 时等待 master 的开始信号，TimeStart 只作为兜底
+	SyncStart   bool
+	ClockOffset time.Duration // 本地时钟减 master 时钟
 }
-type DeliverInfo struct {
-	Name   string `json:"name"`
-	Tel    string `json:"tel"`
-	AddrId int    `json:"addr_id"`
-	Addr   string `json:"addr"`
+
+// interval createV2 的重试间隔
+func (s TaskSchedule) interval() time.Duration {
+	return time.Duration(s.Interval) * time.Millisecond
 }
-type BuyerInfo struct {
-	Id             int    `json:"id"`
-	Uid            int    `json:"uid"`
-	AccountChannel string `json:"account_channel"`
-	PersonalId     string `json:"personal_id"`
-	Name           string `json:"name"`
-	IdCardFront    string `json:"id_card_front"`
-	IdCardBack     string `json:"id_card_back"`
-	IsDefault      int    `json:"is_default"`
-	Tel            string `json:"tel"`
-	ErrorCode      string `json:"error_code"`
-	IdType         int    `json:"id_type"`
-	VerifyStatus   int    `json:"verify_status"`
-	AccountId      int    `json:"accountId"`
+
+func newTaskSchedule(startAt, endAt int64, intervalMs int32, pushplusToken string, notifyTargets []string) TaskSchedule {
+	schedule := TaskSchedule{Interval: int(intervalMs), PushplusToken: pushplusToken, NotifyTargets: notifyTargets}
+	if startAt != 0 {
+		t := time.UnixMilli(startAt)
+		schedule.TimeStart = &t
+	}
+	if endAt != 0 {
+		t := time.UnixMilli(endAt)
+		schedule.TimeEnd = &t
+	}
+	return schedule
 }
 
-var errnoDict = map[int]string{
-	0:      "成功",
-	3:      "抢票CD中",
-	100009: "库存不足,暂无余票",
-	100001: "前方拥堵",
-	100041: "对未发售的票进行抢票",
-	100003: "验证码过期",
-	100016: "项目不可售",
-	100039: "活动收摊啦,下次要快点哦",
-	100048: "已经下单，有尚未完成订单",
-	100017: "票种不可售",
-	100051: "订单准备过期，重新验证",
-	100034: "票价错误",
+func scheduleFromRequest(req *pb.TaskRequest) TaskSchedule {
+	s := req.GetSchedule()
+	schedule := newTaskSchedule(s.GetStartAt(), s.GetEndAt(), s.GetIntervalMs(), s.GetPushplusToken(), s.GetNotifyTargets())
+	schedule.TaskName = req.GetTaskName()
+	schedule.Kind = common.TaskKind(req.GetKind())
+	return schedule
 }
 
-type CreateV2RequestBody struct {
-	Count       int    `json:"count"`
-	ScreenId    int    `json:"screen_id"`
-	ProjectId   int    `json:"project_id"`
-	SkuId       int    `json:"sku_id"`
-	OrderType   int    `json:"order_type"`
-	PayMoney    int    `json:"pay_money"`
-	BuyerInfo   string `json:"buyer_info"`
-	Buyer       string `json:"buyer"`
-	Tel         string `json:"tel"`
-	DeliverInfo string `json:"deliver_info"`
-	Again       int    `json:"again"`
-	Token       string `json:"token"`
-	Timestamp   int64  `json:"timestamp"`
+func scheduleFromAssign(assign *masterpb.AssignTask) TaskSchedule {
+	s := assign.GetSchedule()
+	schedule := newTaskSchedule(s.GetStartAt(), s.GetEndAt(), s.GetIntervalMs(), s.GetPushplusToken(), s.GetNotifyTargets())
+	schedule.TaskName = assign.GetTaskName()
+	schedule.Kind = common.TaskKind(assign.GetKind())
+	schedule.SyncStart = assign.GetSyncStart() && schedule.TimeStart != nil
+	schedule.ClockOffset = time.Duration(assign.GetClockOffsetNs())
+	return schedule
 }
 
-func (cfg *BiliTickerBuyConfig) ToCreateV2RequestBody() (*CreateV2RequestBody, error) {
-	buyerInfoStr, err := json.Marshal(cfg.BuyerInfo)
-	if err != nil {
-		return nil, fmt.Errorf("marshal buyer_info: %w", err)
+// withDefaults 用 worker 配置（TICKET_TIME_START 等环境变量）补齐任务没有指定的字段
+func (s TaskSchedule) withDefaults(cfg *Config) TaskSchedule {
+	if s.TimeStart == nil {
+		s.TimeStart = cfg.TimeStart
+	}
+	if s.Interval <= 0 {
+		s.Interval = cfg.Interval
+	}
+	// 任务指定了任意通知目标时只通知任务自己的目标
+	if s.PushplusToken == "" && len(s.NotifyTargets) == 0 {
+		s.PushplusToken = cfg.PushplusToken
+		s.NotifyTargets = cfg.NotifyTargets
 	}
-	deliverInfoStr, err := json.Marshal(cfg.DeliverInfo)
-	if err != nil {
-		return nil, fmt.Errorf("marshal deliver_info: %w", err)
+	return s
+}
+
+// notifyTargets 所有通知目标，PushplusToken 转换为 pushplus:// 目标
+func (s TaskSchedule) notifyTargets() []string {
+	targets := slices.Clone(s.NotifyTargets)
+	if s.PushplusToken != "" {
+		targets = append(targets, notify.PushPlusTarget(s.PushplusToken))
 	}
-	return &CreateV2RequestBody{
-		Count:       cfg.Count,
-		ScreenId:    cfg.ScreenId,
-		ProjectId:   cfg.ProjectId,
-		SkuId:       cfg.SkuId,
-		OrderType:   cfg.OrderType,
-		PayMoney:    cfg.PayMoney,
-		BuyerInfo:   string(buyerInfoStr),
-		Buyer:       cfg.Buyer,
-		Tel:         cfg.Tel,
-		DeliverInfo: string(deliverInfoStr),
-		Again:       cfg.Again,
-		Timestamp:   cfg.Timestamp,
-	}, nil
+	return targets
 }
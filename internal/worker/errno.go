@@ -0,0 +1,129 @@
+package worker
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"time"
+)
+
+// ErrnoAction createV2 返回某个 errno 后的处理方式
+type ErrnoAction string
+
+const (
+	ActionRetry       ErrnoAction = "retry"        // 按任务间隔重试 createV2
+	ActionBackoff     ErrnoAction = "backoff"      // 间隔逐次翻倍，最长 MaxBackoff，收到其他 errno 后恢复
+	ActionReprepare   ErrnoAction = "reprepare"    // 重新 prepare 获取 token
+	ActionUpdatePrice ErrnoAction = "update_price" // 使用响应中的 pay_money 后重试
+	ActionSuccess     ErrnoAction = "stop_success" // 下单成功或已有订单，任务成功
+	ActionFailure     ErrnoAction = "stop_failure" // 项目不可售等终态，任务失败，不再重试
+	ActionNotify      ErrnoAction = "notify"       // 每个任务推送一次提醒，然后按 retry 继续
+)
+
+func (a ErrnoAction) valid() bool {
+	switch a {
+	case ActionRetry, ActionBackoff, ActionReprepare, ActionUpdatePrice, ActionSuccess, ActionFailure, ActionNotify:
+		return true
+	}
+	return false
+}
+
+// ErrTicketUnavailable createV2 返回了 stop_failure 的 errno，继续重试也不会成功
+var ErrTicketUnavailable = errors.New("票务不可购买")
+
+// ErrnoRule 一个 errno 的说明和处理方式
+type ErrnoRule struct {
+	Message string      `yaml:"message"`
+	Action  ErrnoAction `yaml:"action"`
+}
+
+// ErrnoPolicy createV2 errno 到处理方式的映射，不在表中的 errno 使用 Default
+type ErrnoPolicy struct {
+	Default    ErrnoAction       `yaml:"default"`
+	MaxBackoff time.Duration     `yaml:"max_backoff"`
+	Rules      map[int]ErrnoRule `yaml:"rules"`
+}
+
+func DefaultErrnoPolicy() *ErrnoPolicy {
+	return &ErrnoPolicy{
+		Default:    ActionRetry,
+		MaxBackoff: 10 * time.Second,
+		Rules: map[int]ErrnoRule{
+			0:      {"成功", ActionSuccess},
+			3:      {"抢票CD中", ActionBackoff},
+			100001: {"前方拥堵", ActionRetry},
+			100003: {"验证码过期", ActionReprepare},
+			100009: {"库存不足,暂无余票", ActionRetry},
+			100016: {"项目不可售", ActionFailure},
+			100017: {"票种不可售", ActionFailure},
+			100034: {"票价错误", ActionUpdatePrice},
+			100039: {"活动收摊啦,下次要快点哦", ActionFailure},
+			100041: {"对未发售的票进行抢票", ActionRetry},
+			100048: {"已经下单，有尚未完成订单", ActionSuccess},
+			100051: {"订单准备过期，重新验证", ActionReprepare},
+			100079: {"本项目已经下单", ActionSuccess},
+		},
+	}
+}
+
+// LoadErrnoPolicy 读取 YAML（或 JSON）策略文件，文件中的规则覆盖内置规则，未写的字段保留默认值
+func LoadErrnoPolicy(path string) (*ErrnoPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取 errno 策略文件失败: %w", err)
+	}
+	var file ErrnoPolicy
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&file); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("解析 errno 策略文件 %s 失败: %w", path, err)
+	}
+	policy := DefaultErrnoPolicy()
+	if file.Default != "" {
+		policy.Default = file.Default
+	}
+	if file.MaxBackoff != 0 {
+		policy.MaxBackoff = file.MaxBackoff
+	}
+	for errno, rule := range file.Rules {
+		if rule.Message == "" {
+			rule.Message = policy.Rules[errno].Message
+		}
+		policy.Rules[errno] = rule
+	}
+	if err := policy.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return policy, nil
+}
+
+func (p *ErrnoPolicy) Validate() error {
+	if !p.Default.valid() {
+		return fmt.Errorf("未知的默认动作 %q", p.Default)
+	}
+	if p.MaxBackoff <= 0 {
+		return fmt.Errorf("max_backoff 必须为正数")
+	}
+	for errno, rule := range p.Rules {
+		if !rule.Action.valid() {
+			return fmt.Errorf("errno %d: 未知动作 %q", errno, rule.Action)
+		}
+	}
+	return nil
+}
+
+// Lookup 返回 errno 的规则，known 为 false 表示不在表中，按 Default 处理
+func (p *ErrnoPolicy) Lookup(errno int) (rule ErrnoRule, known bool) {
+	if rule, ok := p.Rules[errno]; ok {
+		return rule, true
+	}
+	return ErrnoRule{Message: "未知错误码", Action: p.Default}, false
+}
+
+// nextBackoff 连续 backoff 时的下一次等待时间
+func (p *ErrnoPolicy) nextBackoff(current time.Duration) time.Duration {
+	return min(current*2, p.MaxBackoff)
+}
@@ -0,0 +1,68 @@
+package worker
+
+import (
+	. "biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/fakebili"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadErrnoPolicy(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "errno.yaml")
+	content := "default: backoff\nmax_backoff: 2s\nrules:\n  100009: {action: stop_failure}\n  100088: {message: 新错误码, action: notify}\n"
+	if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := LoadErrnoPolicy(file)
+	if err != nil {
+		t.Fatalf("LoadErrnoPolicy: %v", err)
+	}
+	if rule, _ := policy.Lookup(100009); rule.Action != ActionFailure || rule.Message != "库存不足,暂无余票" {
+		t.Errorf("override should keep builtin message: %+v", rule)
+	}
+	if rule, known := policy.Lookup(100088); !known || rule.Action != ActionNotify {
+		t.Errorf("new rule not loaded: %+v", rule)
+	}
+	if rule, known := policy.Lookup(100051); !known || rule.Action != ActionReprepare {
+		t.Errorf("builtin rule lost: %+v", rule)
+	}
+	if rule, known := policy.Lookup(123); known || rule.Action != ActionBackoff {
+		t.Errorf("unknown errno should use default: %+v", rule)
+	}
+	if got := policy.nextBackoff(1500 * time.Millisecond); got != 2*time.Second {
+		t.Errorf("backoff not capped: %v", got)
+	}
+
+	if err := os.WriteFile(file, []byte("rules:\n  1: {action: panic}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadErrnoPolicy(file); err == nil {
+		t.Error("expected error for unknown action")
+	}
+}
+
+func TestBuyStopsOnTerminalErrno(t *testing.T) {
+	fake, cfg := useFakeBili(t, fakebili.Scenario{Errno: 100016})
+	w, ctx := newTestWorker(t, cfg)
+
+	result, err := w.Buy(ctx, BiliTickerBuyConfig{ProjectId: 1}, TaskSchedule{Interval: 1})
+	if !errors.Is(err, ErrTicketUnavailable) {
+		t.Fatalf("expected ErrTicketUnavailable, got %v", err)
+	}
+	if result.Errno != 100016 || result.Message != "项目不可售" || result.Ordered {
+		t.Fatalf("unexpected result: %+v", result)
+	}
+	if calls := fake.Calls(fakebili.PathCreateV2); calls != 1 {
+		t.Fatalf("createV2 calls = %d, terminal errno should not be retried", calls)
+	}
+	if status := taskOutcome(result, err); status != TaskStatusFailed {
+		t.Fatalf("task status = %s", status)
+	}
+	// 下一次心跳带给 master 的购买阶段
+	if state := w.m.workerInfo().PurchaseState; state != string(StateFailed) {
+		t.Fatalf("heartbeat purchase state = %q", state)
+	}
+}
@@ -0,0 +1,26 @@
+package worker
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "bili_worker_request_duration_seconds",
+		Help:    "BiliClient 请求耗时，endpoint 为 URL path",
+		Buckets: []float64{0.05, 0.1, 0.2, 0.3, 0.5, 0.75, 1, 2, 5, 10},
+	}, []string{"endpoint"})
+	createErrno = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bili_worker_create_errno_total",
+		Help: "createV2 返回的 errno 次数",
+	}, []string{"errno"})
+	captchaTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bili_worker_captcha_total",
+		Help: "prepare 遇到验证码的次数，result 为 passed 或 failed",
+	}, []string{"result"})
+	throttledTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "bili_worker_throttled_total",
+		Help: "被 B 站限流的响应次数，code 为 412（风控）或 429",
+	}, []string{"code"})
+)
@@ -25,6 +25,9 @@ type TaskRequest struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
 	TicketsInfo   string                 `protobuf:"bytes,2,opt,name=tickets_info,json=ticketsInfo,proto3" json:"tickets_info,omitempty"`
+	Schedule      *TaskSchedule          `protobuf:"bytes,3,opt,name=schedule,proto3" json:"schedule,omitempty"`                 // 为空或字段为 0 时使用 worker 的环境变量
+	TaskName      string                 `protobuf:"bytes,4,opt,name=task_name,json=taskName,proto3" json:"task_name,omitempty"` // 配置文件名，用于通知模板
+	Kind          string                 `protobuf:"bytes,5,opt,name=kind,proto3" json:"kind,omitempty"`                         // 任务类型，为空表示抢票
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -73,17 +76,116 @@ func (x *TaskRequest) GetTicketsInfo() string {
 	return ""
 }
 
+func (x *TaskRequest) GetSchedule() *TaskSchedule {
+	if x != nil {
+		return x.Schedule
+	}
+	return nil
+}
+
+func (x *TaskRequest) GetTaskName() string {
+	if x != nil {
+		return x.TaskName
+	}
+	return ""
+}
+
+func (x *TaskRequest) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+// 任务自己的售票窗口、重试间隔和通知目标
+type TaskSchedule struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	StartAt       int64                  `protobuf:"varint,1,opt,name=start_at,json=startAt,proto3" json:"start_at,omitempty"`          // unix 毫秒，开抢时间
+	EndAt         int64                  `protobuf:"varint,2,opt,name=end_at,json=endAt,proto3" json:"end_at,omitempty"`                // unix 毫秒，售票窗口结束后停止抢票
+	IntervalMs    int32                  `protobuf:"varint,3,opt,name=interval_ms,json=intervalMs,proto3" json:"interval_ms,omitempty"` // createV2 重试间隔
+	PushplusToken string                 `protobuf:"bytes,4,opt,name=pushplus_token,json=pushplusToken,proto3" json:"pushplus_token,omitempty"`
+	NotifyTargets []string               `protobuf:"bytes,5,rep,name=notify_targets,json=notifyTargets,proto3" json:"notify_targets,omitempty"` // 通知目标 URL，见 internal/notify
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *TaskSchedule) Reset() {
+	*x = TaskSchedule{}
+	mi := &file_proto_worker_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TaskSchedule) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TaskSchedule) ProtoMessage() {}
+
+func (x *TaskSchedule) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TaskSchedule.ProtoReflect.Descriptor instead.
+func (*TaskSchedule) Descriptor() ([]byte, []int) {
+	return file_proto_worker_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *TaskSchedule) GetStartAt() int64 {
+	if x != nil {
+		return x.StartAt
+	}
+	return 0
+}
+
+func (x *TaskSchedule) GetEndAt() int64 {
+	if x != nil {
+		return x.EndAt
+	}
+	return 0
+}
+
+func (x *TaskSchedule) GetIntervalMs() int32 {
+	if x != nil {
+		return x.IntervalMs
+	}
+	return 0
+}
+
+func (x *TaskSchedule) GetPushplusToken() string {
+	if x != nil {
+		return x.PushplusToken
+	}
+	return ""
+}
+
+func (x *TaskSchedule) GetNotifyTargets() []string {
+	if x != nil {
+		return x.NotifyTargets
+	}
+	return nil
+}
+
 type TaskResponse struct {
 	state         protoimpl.MessageState `protogen:"open.v1"`
 	Success       bool                   `protobuf:"varint,1,opt,name=success,proto3" json:"success,omitempty"`
 	Message       string                 `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
+	TaskError     bool                   `protobuf:"varint,3,opt,name=task_error,json=taskError,proto3" json:"task_error,omitempty"` // 拒绝原因在任务本身（如配置无法解析），master 会计入重试次数
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *TaskResponse) Reset() {
 	*x = TaskResponse{}
-	mi := &file_proto_worker_proto_msgTypes[1]
+	mi := &file_proto_worker_proto_msgTypes[2]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -95,7 +197,7 @@ func (x *TaskResponse) String() string {
 func (*TaskResponse) ProtoMessage() {}
 
 func (x *TaskResponse) ProtoReflect() protoreflect.Message {
-	mi := &file_proto_worker_proto_msgTypes[1]
+	mi := &file_proto_worker_proto_msgTypes[2]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -108,7 +210,7 @@ func (x *TaskResponse) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use TaskResponse.ProtoReflect.Descriptor instead.
 func (*TaskResponse) Descriptor() ([]byte, []int) {
-	return file_proto_worker_proto_rawDescGZIP(), []int{1}
+	return file_proto_worker_proto_rawDescGZIP(), []int{2}
 }
 
 func (x *TaskResponse) GetSuccess() bool {
@@ -125,19 +227,94 @@ func (x *TaskResponse) GetMessage() string {
 	return ""
 }
 
+func (x *TaskResponse) GetTaskError() bool {
+	if x != nil {
+		return x.TaskError
+	}
+	return false
+}
+
+type StopTaskRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TaskId        string                 `protobuf:"bytes,1,opt,name=task_id,json=taskId,proto3" json:"task_id,omitempty"`
+	Reason        string                 `protobuf:"bytes,2,opt,name=reason,proto3" json:"reason,omitempty"` // deleted / paused / superseded
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *StopTaskRequest) Reset() {
+	*x = StopTaskRequest{}
+	mi := &file_proto_worker_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StopTaskRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StopTaskRequest) ProtoMessage() {}
+
+func (x *StopTaskRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_proto_worker_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StopTaskRequest.ProtoReflect.Descriptor instead.
+func (*StopTaskRequest) Descriptor() ([]byte, []int) {
+	return file_proto_worker_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *StopTaskRequest) GetTaskId() string {
+	if x != nil {
+		return x.TaskId
+	}
+	return ""
+}
+
+func (x *StopTaskRequest) GetReason() string {
+	if x != nil {
+		return x.Reason
+	}
+	return ""
+}
+
 var File_proto_worker_proto protoreflect.FileDescriptor
 
 const file_proto_worker_proto_rawDesc = "" +
 	"\n" +
-	"\x12proto/worker.proto\x12\x06worker\"I\n" +
+	"\x12proto/worker.proto\x12\x06worker\"\xac\x01\n" +
 	"\vTaskRequest\x12\x17\n" +
 	"\atask_id\x18\x01 \x01(\tR\x06taskId\x12!\n" +
-	"\ftickets_info\x18\x02 \x01(\tR\vticketsInfo\"B\n" +
+	"\ftickets_info\x18\x02 \x01(\tR\vticketsInfo\x120\n" +
+	"\bschedule\x18\x03 \x01(\v2\x14.worker.TaskScheduleR\bschedule\x12\x1b\n" +
+	"\ttask_name\x18\x04 \x01(\tR\btaskName\x12\x12\n" +
+	"\x04kind\x18\x05 \x01(\tR\x04kind\"\xaf\x01\n" +
+	"\fTaskSchedule\x12\x19\n" +
+	"\bstart_at\x18\x01 \x01(\x03R\astartAt\x12\x15\n" +
+	"\x06end_at\x18\x02 \x01(\x03R\x05endAt\x12\x1f\n" +
+	"\vinterval_ms\x18\x03 \x01(\x05R\n" +
+	"intervalMs\x12%\n" +
+	"\x0epushplus_token\x18\x04 \x01(\tR\rpushplusToken\x12%\n" +
+	"\x0enotify_targets\x18\x05 \x03(\tR\rnotifyTargets\"a\n" +
 	"\fTaskResponse\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage2E\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x1d\n" +
+	"\n" +
+	"task_error\x18\x03 \x01(\bR\ttaskError\"B\n" +
+	"\x0fStopTaskRequest\x12\x17\n" +
+	"\atask_id\x18\x01 \x01(\tR\x06taskId\x12\x16\n" +
+	"\x06reason\x18\x02 \x01(\tR\x06reason2\x80\x01\n" +
 	"\fTicketWorker\x125\n" +
-	"\bPushTask\x12\x13.worker.TaskRequest\x1a\x14.worker.TaskResponseB\x17Z\x15internal/worker/pb;pbb\x06proto3"
+	"\bPushTask\x12\x13.worker.TaskRequest\x1a\x14.worker.TaskResponse\x129\n" +
+	"\bStopTask\x12\x17.worker.StopTaskRequest\x1a\x14.worker.TaskResponseB\x17Z\x15internal/worker/pb;pbb\x06proto3"
 
 var (
 	file_proto_worker_proto_rawDescOnce sync.Once
@@ -151,19 +328,24 @@ func file_proto_worker_proto_rawDescGZIP() []byte {
 	return file_proto_worker_proto_rawDescData
 }
 
-var file_proto_worker_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_proto_worker_proto_msgTypes = make([]protoimpl.MessageInfo, 4)
 var file_proto_worker_proto_goTypes = []any{
-	(*TaskRequest)(nil),  // 0: worker.TaskRequest
-	(*TaskResponse)(nil), // 1: worker.TaskResponse
+	(*TaskRequest)(nil),     // 0: worker.TaskRequest
+	(*TaskSchedule)(nil),    // 1: worker.TaskSchedule
+	(*TaskResponse)(nil),    // 2: worker.TaskResponse
+	(*StopTaskRequest)(nil), // 3: worker.StopTaskRequest
 }
 var file_proto_worker_proto_depIdxs = []int32{
-	0, // 0: worker.TicketWorker.PushTask:input_type -> worker.TaskRequest
-	1, // 1: worker.TicketWorker.PushTask:output_type -> worker.TaskResponse
-	1, // [1:2] is the sub-list for method output_type
-	0, // [0:1] is the sub-list for method input_type
-	0, // [0:0] is the sub-list for extension type_name
-	0, // [0:0] is the sub-list for extension extendee
-	0, // [0:0] is the sub-list for field type_name
+	1, // 0: worker.TaskRequest.schedule:type_name -> worker.TaskSchedule
+	0, // 1: worker.TicketWorker.PushTask:input_type -> worker.TaskRequest
+	3, // 2: worker.TicketWorker.StopTask:input_type -> worker.StopTaskRequest
+	2, // 3: worker.TicketWorker.PushTask:output_type -> worker.TaskResponse
+	2, // 4: worker.TicketWorker.StopTask:output_type -> worker.TaskResponse
+	3, // [3:5] is the sub-list for method output_type
+	1, // [1:3] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
 }
 
 func init() { file_proto_worker_proto_init() }
@@ -177,7 +359,7 @@ func file_proto_worker_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_proto_worker_proto_rawDesc), len(file_proto_worker_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   2,
+			NumMessages:   4,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -20,6 +20,7 @@ const _ = grpc.SupportPackageIsVersion9
 
 const (
 	TicketWorker_PushTask_FullMethodName = "/worker.TicketWorker/PushTask"
+	TicketWorker_StopTask_FullMethodName = "/worker.TicketWorker/StopTask"
 )
 
 // TicketWorkerClient is the client API for TicketWorker service.
@@ -29,6 +30,7 @@ const (
 // protoc --go_out=. --go-grpc_out=. proto/worker.proto
 type TicketWorkerClient interface {
 	PushTask(ctx context.Context, in *TaskRequest, opts ...grpc.CallOption) (*TaskResponse, error)
+	StopTask(ctx context.Context, in *StopTaskRequest, opts ...grpc.CallOption) (*TaskResponse, error)
 }
 
 type ticketWorkerClient struct {
@@ -49,6 +51,16 @@ func (c *ticketWorkerClient) PushTask(ctx context.Context, in *TaskRequest, opts
 	return out, nil
 }
 
+func (c *ticketWorkerClient) StopTask(ctx context.Context, in *StopTaskRequest, opts ...grpc.CallOption) (*TaskResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TaskResponse)
+	err := c.cc.Invoke(ctx, TicketWorker_StopTask_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // TicketWorkerServer is the server API for TicketWorker service.
 // All implementations must embed UnimplementedTicketWorkerServer
 // for forward compatibility.
@@ -56,6 +68,7 @@ func (c *ticketWorkerClient) PushTask(ctx context.Context, in *TaskRequest, opts
 // protoc --go_out=. --go-grpc_out=. proto/worker.proto
 type TicketWorkerServer interface {
 	PushTask(context.Context, *TaskRequest) (*TaskResponse, error)
+	StopTask(context.Context, *StopTaskRequest) (*TaskResponse, error)
 	mustEmbedUnimplementedTicketWorkerServer()
 }
 
@@ -69,6 +82,9 @@ type UnimplementedTicketWorkerServer struct{}
 func (UnimplementedTicketWorkerServer) PushTask(context.Context, *TaskRequest) (*TaskResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method PushTask not implemented")
 }
+func (UnimplementedTicketWorkerServer) StopTask(context.Context, *StopTaskRequest) (*TaskResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method StopTask not implemented")
+}
 func (UnimplementedTicketWorkerServer) mustEmbedUnimplementedTicketWorkerServer() {}
 func (UnimplementedTicketWorkerServer) testEmbeddedByValue()                      {}
 
@@ -108,6 +124,24 @@ func _TicketWorker_PushTask_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
+func _TicketWorker_StopTask_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StopTaskRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TicketWorkerServer).StopTask(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TicketWorker_StopTask_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TicketWorkerServer).StopTask(ctx, req.(*StopTaskRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // TicketWorker_ServiceDesc is the grpc.ServiceDesc for TicketWorker service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -119,6 +153,10 @@ var TicketWorker_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "PushTask",
 			Handler:    _TicketWorker_PushTask_Handler,
 		},
+		{
+			MethodName: "StopTask",
+			Handler:    _TicketWorker_StopTask_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "proto/worker.proto",
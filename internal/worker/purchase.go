@@ -0,0 +1,354 @@
+package worker
+
+import (
+	. "biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/notify"
+	"biliTickerStorm/internal/ticketconfig"
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"strconv"
+	"time"
+)
+
+// PurchaseState 一次抢票所处的阶段，随心跳上报给 master
+type PurchaseState string
+
+const (
+	StateWaiting   PurchaseState = "Waiting"   // 等待开抢时间或 master 开始信号
+	StatePreparing PurchaseState = "Preparing" // 请求 prepare 获取 token
+	StateCaptcha   PurchaseState = "Captcha"   // prepare 要求验证码
+	StateCreating  PurchaseState = "Creating"  // 使用 token 请求 createV2
+	StateSucceeded PurchaseState = "Succeeded" // 下单成功或已有订单
+	StateFailed    PurchaseState = "Failed"    // errno 策略判定无法购买
+	StateCancelled PurchaseState = "Cancelled" // 任务被停止、风控或售票窗口结束
+)
+
+func (s PurchaseState) terminal() bool {
+	return s == StateSucceeded || s == StateFailed || s == StateCancelled
+}
+
+// notifyTimeout 一条通知包括重试在内的总时长
+const notifyTimeout = time.Minute
+
+// maxCreateAttempts 同一个 token 连续请求 createV2 的次数，用完后重新 prepare
+const maxCreateAttempts = 60
+
+// PurchaseEvent 状态机的一次状态变化
+type PurchaseEvent struct {
+	At     time.Time
+	From   PurchaseState
+	To     PurchaseState
+	Reason string
+}
+
+// purchase 一次抢票的状态机：Waiting → Preparing ⇄ Captcha，Preparing → Creating → 终态。
+// 每个 step 只执行一步并返回下一个状态，可以单独对着 fakebili 测试。
+// 下单过程中变化的 token、票价保存在状态机中，不修改传入的配置。
+type purchase struct {
+	w        *Worker
+	client   *BiliClient
+	order    BiliTickerBuyConfig
+	schedule TaskSchedule
+	result   *BuyResult
+	err      error // 进入 Failed / Cancelled 的原因
+
+	state  PurchaseState
+	reason string // 下一次状态变化的原因，由 step 设置
+	hooks  []func(PurchaseEvent)
+
+	started       time.Time // 离开 Waiting 的时间，用于通知中的用时，不包括等待开抢的时间
+	bySignal      bool
+	startReported bool
+	riskParams    json.RawMessage // 要求验证码的 prepare 响应中的 riskParams
+	token         string
+	payMoney      int
+	attempts      int // 当前 token 的 createV2 次数
+	backoff       time.Duration
+	unknownErrnos map[int]bool
+	notified      map[int]bool
+}
+
+func (w *Worker) newPurchase(order BiliTickerBuyConfig, schedule TaskSchedule) *purchase {
+	return &purchase{
+		w:             w,
+		client:        NewBiliClient(w.cfg, order.Cookies, w),
+		order:         order,
+		schedule:      schedule,
+		result:        &BuyResult{Errno: -1},
+		state:         StateWaiting,
+		payMoney:      order.PayMoney,
+		backoff:       schedule.interval(),
+		unknownErrnos: make(map[int]bool),
+		notified:      make(map[int]bool),
+	}
+}
+
+// onTransition 注册状态变化的回调，在状态机所在的 goroutine 中同步调用
+func (p *purchase) onTransition(hook func(PurchaseEvent)) {
+	p.hooks = append(p.hooks, hook)
+}
+
+func (p *purchase) transition(to PurchaseState) {
+	reason := p.reason
+	p.reason = ""
+	if to == p.state {
+		return
+	}
+	event := PurchaseEvent{At: time.Now(), From: p.state, To: to, Reason: reason}
+	p.state = to
+	log.WithFields(logrus.Fields{"from": event.From, "to": event.To, "reason": reason}).Info("[Purchase] 状态变化")
+	for _, hook := range p.hooks {
+		hook(event)
+	}
+}
+
+// run 执行到终态，ctx 结束时进入 Cancelled
+func (p *purchase) run(ctx context.Context) {
+	for !p.state.terminal() {
+		if ctx.Err() != nil {
+			p.err = context.Cause(ctx)
+			p.reason = p.err.Error()
+			p.transition(StateCancelled)
+			break
+		}
+		p.transition(p.step(ctx))
+	}
+	p.result.PayMoney = p.payMoney
+}
+
+func (p *purchase) step(ctx context.Context) PurchaseState {
+	switch p.state {
+	case StateWaiting:
+		return p.wait(ctx)
+	case StatePreparing:
+		return p.prepare(ctx)
+	case StateCaptcha:
+		return p.captcha(ctx)
+	case StateCreating:
+		return p.create(ctx)
+	}
+	return p.state
+}
+
+func (p *purchase) sleep(ctx context.Context, d time.Duration) {
+	_ = SleepCtx(ctx, p.w.clock, d)
+}
+
+func (p *purchase) wait(ctx context.Context) PurchaseState {
+	p.bySignal = p.w.waitStart(ctx, p.schedule)
+	p.started = time.Now()
+	return StatePreparing
+}
+
+func (p *purchase) prepare(ctx context.Context) PurchaseState {
+	log.Info("1）订单准备")
+	if p.schedule.TimeStart != nil && !p.startReported {
+		p.startReported = true
+		p.w.reportStart(time.Now(), p.bySignal)
+	}
+	payload := map[string]interface{}{
+		"count":      p.order.Count,
+		"screen_id":  p.order.ScreenId,
+		"order_type": 1,
+		"project_id": p.order.ProjectId,
+		"sku_id":     p.order.SkuId,
+		"token":      "",
+		"newRisk":    true,
+	}
+	url := fmt.Sprintf("%s/api/ticket/order/prepare?project_id=%d", p.client.showBaseURL, p.order.ProjectId)
+	prepareCtx, cancel := withTimeout(ctx, p.client.prepareTimeout)
+	resp, err := p.client.Post(prepareCtx, url, payload)
+	cancel()
+	if err != nil {
+		log.Errorf("读取响应失败: %v", err)
+		p.sleep(ctx, p.schedule.interval())
+		return StatePreparing
+	}
+	ret, err := DecodePrepareResponse(resp)
+	if err != nil {
+		log.Errorf("解析响应失败: %v", err)
+		p.sleep(ctx, p.schedule.interval())
+		return StatePreparing
+	}
+	if ret.Errno == -401 {
+		p.riskParams = ret.RiskParams
+		p.reason = "prepare 返回 -401"
+		return StateCaptcha
+	}
+	if ret.Errno != 0 {
+		log.Errorf("订单准备失败: errno=%d %s", ret.Errno, ret.Message)
+		p.sleep(ctx, p.schedule.interval())
+		return StatePreparing
+	}
+	p.token = ret.Token
+	p.attempts = 0
+	return StateCreating
+}
+
+func (p *purchase) captcha(ctx context.Context) PurchaseState {
+	log.Info("检测到验证码，调用验证码服务处理")
+	captchaCtx, cancel := withTimeout(ctx, p.client.captchaTimeout)
+	err := HandleCaptcha(captchaCtx, p.client, p.riskParams, p.order.Phone)
+	cancel()
+	p.riskParams = nil
+	if err != nil {
+		captchaTotal.WithLabelValues("failed").Inc()
+		log.Infof("验证码失败: %v", err)
+		p.reason = "验证码失败"
+	} else {
+		captchaTotal.WithLabelValues("passed").Inc()
+		log.Info("过验证码成功")
+		p.reason = "验证码通过"
+	}
+	return StatePreparing
+}
+
+// createBody 按当前 token 和票价生成 createV2 请求体
+func (p *purchase) createBody() (*ticketconfig.CreateV2RequestBody, error) {
+	body, err := p.order.ToCreateV2RequestBody()
+	if err != nil {
+		return nil, err
+	}
+	body.Token = p.token
+	body.PayMoney = p.payMoney
+	body.Again = 1
+	body.Timestamp = time.Now().UnixMilli()
+	return body, nil
+}
+
+// create 请求一次 createV2，按 errno 策略决定下一个状态
+func (p *purchase) create(ctx context.Context) PurchaseState {
+	if p.attempts >= maxCreateAttempts {
+		log.Info("0）重新下单")
+		p.reason = "createV2 次数用完"
+		return StatePreparing
+	}
+	p.attempts++
+	attempt, interval := p.attempts, p.schedule.interval()
+	if attempt == 1 {
+		log.Info("2）创建订单")
+	}
+	body, err := p.createBody()
+	if err != nil {
+		log.Errorf("[尝试 %d/%d] 创建CreateV2请求体失败: %v", attempt, maxCreateAttempts, err)
+		p.sleep(ctx, interval)
+		return StateCreating
+	}
+	p.result.Attempts++
+	url := fmt.Sprintf("%s/api/ticket/order/createV2?project_id=%d", p.client.showBaseURL, p.order.ProjectId)
+	createCtx, cancel := withTimeout(ctx, p.client.createTimeout)
+	resp, err := p.client.Post(createCtx, url, body)
+	cancel()
+	if err != nil {
+		log.Errorf("[尝试 %d/%d] 请求异常: %v", attempt, maxCreateAttempts, err)
+		p.sleep(ctx, interval)
+		return StateCreating
+	}
+	ret, err := DecodeCreateV2Response(resp)
+	if err != nil {
+		log.Errorf("[尝试 %d/%d] 解析响应失败: %v", attempt, maxCreateAttempts, err)
+		p.sleep(ctx, interval)
+		return StateCreating
+	}
+	errno := ret.Errno
+	createErrno.WithLabelValues(strconv.Itoa(errno)).Inc()
+	rule, known := p.w.errnos.Lookup(errno)
+	if !known && !p.unknownErrnos[errno] {
+		// 每个任务只记录一次，便于之后补充到策略表
+		p.unknownErrnos[errno] = true
+		log.Warningf("[Create] 未知错误码 errno=%d，按 %s 处理，响应: %s", errno, rule.Action, resp)
+	}
+	p.result.Errno, p.result.Message = errno, rule.Message
+	log.Infof("[Create] attempt=%d errno=%d msg=%s action=%s", attempt, errno, rule.Message, rule.Action)
+	p.reason = fmt.Sprintf("errno=%d %s", errno, rule.Message)
+
+	switch rule.Action {
+	case ActionSuccess:
+		if errno == 0 {
+			log.Info("3）抢票成功，请前往订单中心查看")
+			p.result.OrderId = ret.OrderID
+			p.notify(&p.w.templates.Success)
+		} else {
+			log.Info("已经下单，有尚未完成订单")
+		}
+		p.result.Ordered = true
+		return StateSucceeded
+	case ActionFailure:
+		p.err = fmt.Errorf("%w: errno=%d %s", ErrTicketUnavailable, errno, rule.Message)
+		return StateFailed
+	case ActionReprepare:
+		log.Info("token过期，需要重新准备订单")
+		return StatePreparing
+	case ActionBackoff:
+		log.Infof("[Create] 退避 %v", p.backoff)
+		p.sleep(ctx, p.backoff)
+		p.backoff = p.w.errnos.nextBackoff(p.backoff)
+		return StateCreating
+	case ActionUpdatePrice:
+		if ret.PayMoney != nil {
+			log.Infof("更新票价为：%.2f", float64(*ret.PayMoney)/100)
+			p.payMoney = *ret.PayMoney
+		}
+	case ActionNotify:
+		if !p.notified[errno] {
+			p.notified[errno] = true
+			p.notify(&p.w.templates.Errno)
+		}
+	}
+	p.backoff = interval
+	p.sleep(ctx, interval)
+	return StateCreating
+}
+
+// notifyData 渲染通知模板使用的任务上下文
+func (p *purchase) notifyData() NotifyData {
+	buyers := make([]string, 0, len(p.order.BuyerInfo))
+	for _, buyer := range p.order.BuyerInfo {
+		buyers = append(buyers, buyer.Name)
+	}
+	if len(buyers) == 0 && p.order.Buyer != "" {
+		buyers = append(buyers, p.order.Buyer)
+	}
+	return NotifyData{
+		TaskID:   p.w.CurrentTask(),
+		TaskName: p.schedule.TaskName,
+		Detail:   p.order.Detail,
+		Buyers:   buyers,
+		Count:    p.order.Count,
+		PayMoney: p.payMoney,
+		OrderID:  p.result.OrderId,
+		WorkerID: p.w.workerID(),
+		Attempts: p.result.Attempts,
+		Elapsed:  time.Since(p.started).Round(time.Millisecond),
+		Errno:    p.result.Errno,
+		Message:  p.result.Message,
+	}
+}
+
+// notify 按模板渲染后在后台发送通知，不阻塞抢票，也不随任务结束而取消
+func (p *purchase) notify(tmpl *NotifyTemplate) {
+	targets := p.schedule.notifyTargets()
+	if len(targets) == 0 {
+		return
+	}
+	notifiers, err := notify.ParseAll(targets)
+	if err != nil {
+		log.Warningf("通知目标配置错误: %v", err)
+		return
+	}
+	msg, err := tmpl.render(p.notifyData())
+	if err != nil {
+		log.Warningf("渲染通知模板失败: %v", err)
+		return
+	}
+	d := notify.NewDispatcher(notifiers, p.w.cfg.NotifyAttempts)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), notifyTimeout)
+		defer cancel()
+		if err := d.Send(ctx, msg); err != nil {
+			log.Warningf("推送失败: %v", err)
+		}
+	}()
+}
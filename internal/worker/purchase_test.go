@@ -0,0 +1,149 @@
+package worker
+
+import (
+	. "biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/fakebili"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// steps 从 from 开始逐步执行，返回经过的状态
+func steps(ctx context.Context, p *purchase, from PurchaseState, n int) []PurchaseState {
+	p.state = from
+	var got []PurchaseState
+	for i := 0; i < n && !p.state.terminal(); i++ {
+		p.transition(p.step(ctx))
+		got = append(got, p.state)
+	}
+	return got
+}
+
+func equalStates(a, b []PurchaseState) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPurchaseCaptchaThenCreate(t *testing.T) {
+	_, cfg := useFakeBili(t, fakebili.Scenario{Captcha: "geetest"})
+	w, ctx := newTestWorker(t, cfg)
+	order := BiliTickerBuyConfig{ProjectId: 1, PayMoney: 100}
+	p := w.newPurchase(order, TaskSchedule{Interval: 1})
+	var events []PurchaseEvent
+	p.onTransition(func(e PurchaseEvent) { events = append(events, e) })
+
+	got := steps(ctx, p, StatePreparing, 4)
+	want := []PurchaseState{StateCaptcha, StatePreparing, StateCreating, StateCreating}
+	if !equalStates(got, want) {
+		t.Fatalf("states = %v, want %v", got, want)
+	}
+	if p.token == "" || p.result.Errno != 100001 {
+		t.Fatalf("token %q errno %d", p.token, p.result.Errno)
+	}
+	if order.Token != "" {
+		t.Fatal("caller config mutated")
+	}
+	if len(events) != 3 || events[0].Reason == "" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+}
+
+func TestPurchaseUpdatesPriceAndSucceeds(t *testing.T) {
+	_, cfg := useFakeBili(t, fakebili.Scenario{Price: 12800, SuccessOn: 2, OrderID: 7})
+	w, ctx := newTestWorker(t, cfg)
+	p := w.newPurchase(BiliTickerBuyConfig{ProjectId: 1, PayMoney: 100}, TaskSchedule{Interval: 1})
+
+	got := steps(ctx, p, StatePreparing, 5)
+	want := []PurchaseState{StateCreating, StateCreating, StateSucceeded}
+	if !equalStates(got, want) {
+		t.Fatalf("states = %v, want %v", got, want)
+	}
+	if p.payMoney != 12800 || p.result.OrderId != 7 || !p.result.Ordered {
+		t.Fatalf("unexpected purchase: pay %d result %+v", p.payMoney, p.result)
+	}
+}
+
+func TestPurchaseNotifiesTaskTargets(t *testing.T) {
+	received := make(chan string, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	defer hook.Close()
+	_, cfg := useFakeBili(t, fakebili.Scenario{SuccessOn: 1, OrderID: 7})
+	cfg.NotifyTargets = []string{"webhook+http://127.0.0.1:1/global"}
+	w, ctx := newTestWorker(t, cfg)
+	// 任务自己的目标优先于 worker 配置
+	schedule := TaskSchedule{Interval: 1, NotifyTargets: []string{"webhook+" + hook.URL}}.withDefaults(cfg)
+	p := w.newPurchase(BiliTickerBuyConfig{ProjectId: 1, PayMoney: 100}, schedule)
+
+	if got := steps(ctx, p, StatePreparing, 2); p.state != StateSucceeded {
+		t.Fatalf("states = %v", got)
+	}
+	select {
+	case body := <-received:
+		if !strings.Contains(body, "抢票成功") || !strings.Contains(body, "订单号：7") {
+			t.Fatalf("unexpected notification: %s", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification not sent")
+	}
+}
+
+func TestPurchaseElapsedExcludesWait(t *testing.T) {
+	_, cfg := useFakeBili(t, fakebili.Scenario{})
+	w, ctx := newTestWorker(t, cfg)
+	w.clock = SystemClock()
+	start := time.Now().Add(300 * time.Millisecond)
+	p := w.newPurchase(BiliTickerBuyConfig{ProjectId: 1}, TaskSchedule{TimeStart: &start, Interval: 1})
+
+	if got := steps(ctx, p, StateWaiting, 1); !equalStates(got, []PurchaseState{StatePreparing}) {
+		t.Fatalf("states = %v", got)
+	}
+	// 通知中的用时从开抢开始算
+	if elapsed := p.notifyData().Elapsed; elapsed > 100*time.Millisecond {
+		t.Fatalf("elapsed = %v, should not include the wait before start", elapsed)
+	}
+}
+
+func TestPurchaseReprepareWhenTokenExpires(t *testing.T) {
+	_, cfg := useFakeBili(t, fakebili.Scenario{TokenExpiry: 1})
+	w, ctx := newTestWorker(t, cfg)
+	p := w.newPurchase(BiliTickerBuyConfig{ProjectId: 1}, TaskSchedule{Interval: 1})
+
+	got := steps(ctx, p, StatePreparing, 4)
+	want := []PurchaseState{StateCreating, StateCreating, StatePreparing, StateCreating}
+	if !equalStates(got, want) {
+		t.Fatalf("states = %v, want %v", got, want)
+	}
+}
+
+func TestPurchaseCancelled(t *testing.T) {
+	_, cfg := useFakeBili(t, fakebili.Scenario{})
+	w, _ := newTestWorker(t, cfg)
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(ErrTaskStopped)
+	p := w.newPurchase(BiliTickerBuyConfig{ProjectId: 1}, TaskSchedule{Interval: 1})
+	var hooked []PurchaseState
+	p.onTransition(func(e PurchaseEvent) { hooked = append(hooked, e.To) })
+
+	p.run(ctx)
+	if p.state != StateCancelled || !errors.Is(p.err, ErrTaskStopped) {
+		t.Fatalf("state %s err %v", p.state, p.err)
+	}
+	if !equalStates(hooked, []PurchaseState{StateCancelled}) {
+		t.Fatalf("hook saw %v", hooked)
+	}
+}
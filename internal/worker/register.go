@@ -7,20 +7,41 @@ import (
 	"fmt"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
+	"net"
 	"os"
 	"sync"
 	"time"
 )
 
+// TaskHandler 处理 master 通过长连接下发的命令，由 Worker 实现
+type TaskHandler interface {
+	RunTask(ctx context.Context, info, taskId string, schedule TaskSchedule) error
+	StopTask(taskId, reason string) error
+	// StartSignal master 在开抢时刻发出的开始信号
+	StartSignal(taskId string)
+}
+
 type Register struct {
 	mu           sync.Mutex
 	workerID     string
+	port         string // 上报给 master 的 PushTask 端口
 	address      string
 	masterAddr   string
 	ws           WorkerStatus
 	ts           TaskStatus
+	ps           PurchaseState // 当前（或最近一个）任务的购买阶段
 	TaskAssigned string
+	registered   bool
 	stopChan     chan struct{}
+
+	// 与 master 的长连接，所有上报和命令都走这条双向流
+	ctx      context.Context
+	cancel   context.CancelFunc
+	dialOpts []grpc.DialOption
+	sendMu   sync.Mutex // 保护 conn/stream，grpc stream 不允许并发 Send
+	conn     *grpc.ClientConn
+	stream   masterpb.TicketMaster_ConnectClient
+	handler  TaskHandler
 }
 
 func (wm *Register) GetStatus() WorkerStatus {
@@ -37,34 +58,83 @@ func (wm *Register) SetStatus(ws WorkerStatus, ts TaskStatus, taskId string) {
 	wm.TaskAssigned = taskId
 }
 
-func NewWorkerManager(masterAddr string) *Register {
+// SetPurchaseState 记录购买阶段，下一次心跳带给 master，不单独发送
+func (wm *Register) SetPurchaseState(ps PurchaseState) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	wm.ps = ps
+}
+
+// RegisterOption 调整 Register 的身份和连接方式，主要用于测试
+type RegisterOption func(*Register)
+
+// WithWorkerID 指定 worker ID，默认由主机名和启动时间生成
+func WithWorkerID(id string) RegisterOption {
+	return func(wm *Register) { wm.workerID = id }
+}
+
+// WithDialOptions 追加连接 master 时的 grpc.DialOption，例如 bufconn 的 ContextDialer
+func WithDialOptions(opts ...grpc.DialOption) RegisterOption {
+	return func(wm *Register) { wm.dialOpts = append(wm.dialOpts, opts...) }
+}
+
+func NewWorkerManager(masterAddr string, opts ...RegisterOption) *Register {
 	hostname, _ := os.Hostname()
 	workerID := fmt.Sprintf("worker-%s-%d", hostname, time.Now().Unix())
+	ctx, cancel := context.WithCancel(context.Background())
 
-	return &Register{
+	wm := &Register{
 		workerID:   workerID,
+		port:       "40051",
 		masterAddr: masterAddr,
 		ws:         Idle,
 		stopChan:   make(chan struct{}),
+		ctx:        ctx,
+		cancel:     cancel,
+		dialOpts:   []grpc.DialOption{grpc.WithTransportCredentials(insecure.NewCredentials())},
+	}
+	for _, opt := range opts {
+		opt(wm)
 	}
+	return wm
+}
+
+// SetHandler 设置处理 master 命令的 TaskHandler
+func (wm *Register) SetHandler(h TaskHandler) {
+	wm.sendMu.Lock()
+	defer wm.sendMu.Unlock()
+	wm.handler = h
 }
 
 func (wm *Register) RegisterToMaster() error {
-	var err error
-	wm.address, err = GetOutboundIPToMaster(wm.masterAddr)
+	// 地址只用于兼容旧版 master 直连 PushTask，拿不到也可以通过长连接工作
+	// 没有监听 PushTask 时 port 为空，只使用长连接
+	address, err := GetOutboundIPToMaster(wm.masterAddr)
 	if err != nil {
-		return fmt.Errorf("连接获取本地IP失败: %v", err)
+		log.Warningf("获取本地IP失败，仅使用长连接: %v", err)
+	} else if wm.port != "" {
+		wm.mu.Lock()
+		wm.address = net.JoinHostPort(address, wm.port)
+		wm.mu.Unlock()
 	}
-	wm.address += ":40051"
 
-	err = wm.sendHeartbeat()
-	if err != nil {
-		log.Errorf("注册到主服务器失败:%v", err)
+	if err := wm.sendHeartbeat(); err != nil {
+		return fmt.Errorf("注册到主服务器失败: %w", err)
 	}
 	log.Printf("成功注册到主服务器: WorkerID=%s, Address=%s", wm.workerID, wm.address)
 	return nil
 }
 
+// Ready 与 master 的长连接建立（注册或心跳成功）之前 worker 未就绪，连接断开后重新变为未就绪
+func (wm *Register) Ready(ctx context.Context) error {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	if !wm.registered {
+		return fmt.Errorf("not registered to master")
+	}
+	return nil
+}
+
 func (wm *Register) StartHeartbeat(interval time.Duration) {
 	ticker := time.NewTicker(interval)
 	defer ticker.Stop()
@@ -81,50 +151,188 @@ func (wm *Register) StartHeartbeat(interval time.Duration) {
 	}
 }
 
-func (wm *Register) sendHeartbeat() error {
-	conn, err := grpc.Dial(wm.masterAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+func (wm *Register) workerInfo() *masterpb.WorkerInfo {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	return &masterpb.WorkerInfo{
+		WorkerId:      wm.workerID,
+		Address:       wm.address,
+		WorkStatus:    int32(wm.ws),
+		TaskStatus:    string(wm.ts),
+		TaskAssigned:  wm.TaskAssigned,
+		PurchaseState: string(wm.ps),
+	}
+}
+
+func heartbeatMessage(info *masterpb.WorkerInfo) *masterpb.WorkerMessage {
+	return &masterpb.WorkerMessage{Payload: &masterpb.WorkerMessage_Heartbeat{Heartbeat: info}}
+}
+
+// connectLocked 建立（或重建）到 master 的双向流，调用方持有 sendMu
+func (wm *Register) connectLocked() error {
+	if wm.conn == nil {
+		conn, err := grpc.NewClient(wm.masterAddr, wm.dialOpts...)
+		if err != nil {
+			return err
+		}
+		wm.conn = conn
+	}
+	stream, err := masterpb.NewTicketMasterClient(wm.conn).Connect(wm.ctx)
 	if err != nil {
 		return err
 	}
-	defer conn.Close()
+	// 第一条消息必须是心跳，master 用它识别 worker
+	if err := stream.Send(heartbeatMessage(wm.workerInfo())); err != nil {
+		return err
+	}
+	wm.stream = stream
+	go wm.receive(stream)
+	log.Infof("与 master 建立长连接: %s", wm.masterAddr)
+	return nil
+}
 
-	client := masterpb.NewTicketMasterClient(conn)
-	req := &masterpb.WorkerInfo{
-		WorkerId:     wm.workerID,
-		Address:      wm.address,
-		WorkStatus:   int32(wm.GetStatus()),
-		TaskStatus:   string(wm.ts),
-		TaskAssigned: wm.TaskAssigned,
+func (wm *Register) send(msg *masterpb.WorkerMessage) error {
+	wm.sendMu.Lock()
+	defer wm.sendMu.Unlock()
+	if wm.ctx.Err() != nil {
+		return fmt.Errorf("register stopped")
+	}
+	if wm.stream == nil {
+		if err := wm.connectLocked(); err != nil {
+			wm.setRegistered(false)
+			return fmt.Errorf("连接 master 失败: %w", err)
+		}
+	}
+	if err := wm.stream.Send(msg); err != nil {
+		wm.stream = nil // 下次发送时重连
+		wm.setRegistered(false)
+		return err
 	}
+	return nil
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	_, err = client.RegisterWorker(ctx, req)
-	if err != nil {
-		log.Errorf("%v", err)
+// setRegistered 更新就绪状态，连接断开时置为 false，直到下一次心跳成功
+func (wm *Register) setRegistered(registered bool) {
+	wm.mu.Lock()
+	defer wm.mu.Unlock()
+	wm.registered = registered
+}
+
+// receive 读取 master 下发的命令，直到流断开
+func (wm *Register) receive(stream masterpb.TicketMaster_ConnectClient) {
+	for {
+		msg, err := stream.Recv()
+		recvAt := time.Now()
+		if err != nil {
+			if wm.ctx.Err() == nil {
+				log.Warningf("与 master 的长连接断开: %v", err)
+			}
+			wm.sendMu.Lock()
+			if wm.stream == stream {
+				wm.stream = nil
+				wm.setRegistered(false)
+			}
+			wm.sendMu.Unlock()
+			return
+		}
+		if ping := msg.GetClockPing(); ping != nil {
+			wm.replyClockPing(ping, recvAt)
+			continue
+		}
+		wm.sendMu.Lock()
+		handler := wm.handler
+		wm.sendMu.Unlock()
+		if handler == nil {
+			log.Warningf("未设置 TaskHandler，忽略 master 命令 %T", msg.Payload)
+			continue
+		}
+		switch payload := msg.Payload.(type) {
+		case *masterpb.MasterMessage_Assign:
+			wm.handleAssign(handler, payload.Assign)
+		case *masterpb.MasterMessage_Stop:
+			if err := handler.StopTask(payload.Stop.TaskId, payload.Stop.Reason); err != nil {
+				log.Warningf("停止任务失败: %v", err)
+			}
+		case *masterpb.MasterMessage_Start:
+			handler.StartSignal(payload.Start.TaskId)
+		default:
+			log.Warningf("未知的 master 命令 %T", payload)
+		}
 	}
-	return err
 }
-func (wm *Register) CancelTask(s WorkerStatus) error {
-	conn, err := grpc.Dial(wm.masterAddr, grpc.WithTransportCredentials(insecure.NewCredentials()))
-	if err != nil {
+
+func (wm *Register) handleAssign(handler TaskHandler, assign *masterpb.AssignTask) {
+	ack := &masterpb.TaskAck{TaskId: assign.TaskId, Success: true, Message: fmt.Sprintf("Task <%s> is running", assign.TaskId)}
+	if err := handler.RunTask(wm.ctx, assign.TicketsInfo, assign.TaskId, scheduleFromAssign(assign)); err != nil {
+		ack.Success = false
+		ack.Message = err.Error()
+		ack.TaskError = isTaskError(err)
+	}
+	if err := wm.send(&masterpb.WorkerMessage{Payload: &masterpb.WorkerMessage_TaskAck{TaskAck: ack}}); err != nil {
+		log.Errorf("应答任务 <%s> 失败: %v", assign.TaskId, err)
+	}
+}
+
+func (wm *Register) sendHeartbeat() error {
+	if err := wm.send(heartbeatMessage(wm.workerInfo())); err != nil {
 		return err
 	}
-	defer conn.Close()
+	// 启动时注册重试用完后，心跳成功同样算作注册成功
+	wm.setRegistered(true)
+	return nil
+}
 
-	client := masterpb.NewTicketMasterClient(conn)
-	req := &masterpb.CancelTaskInfo{
+func (wm *Register) CancelTask(s WorkerStatus) error {
+	wm.mu.Lock()
+	taskId := wm.TaskAssigned
+	wm.mu.Unlock()
+	return wm.send(&masterpb.WorkerMessage{Payload: &masterpb.WorkerMessage_CancelTask{CancelTask: &masterpb.CancelTaskInfo{
 		WorkerId:     wm.workerID,
-		CancelTaskId: wm.TaskAssigned,
+		CancelTaskId: taskId,
 		WorkStatus:   int32(s),
+	}}})
+}
+
+// ReportResult 上报任务最终结果
+func (wm *Register) ReportResult(taskId string, status TaskStatus, result *BuyResult, buyErr error, duration time.Duration) error {
+	req := &masterpb.TaskResultInfo{
+		TaskId:     taskId,
+		WorkerId:   wm.workerID,
+		Status:     string(status),
+		DurationMs: duration.Milliseconds(),
 	}
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	_, err = client.CancelTask(ctx, req)
-	if err != nil {
-		log.Errorf("%v", err)
+	if result != nil {
+		req.Errno = int32(result.Errno)
+		req.Message = result.Message
+		req.OrderId = result.OrderId
+		req.PayMoney = int32(result.PayMoney)
+		req.Attempts = int32(result.Attempts)
+	}
+	if buyErr != nil {
+		req.Message = buyErr.Error()
+	}
+	return wm.send(&masterpb.WorkerMessage{Payload: &masterpb.WorkerMessage_Result{Result: req}})
+}
+
+// replyClockPing 带上收到和回复的本地时间，master 据此计算时钟偏差
+func (wm *Register) replyClockPing(ping *masterpb.ClockPing, recvAt time.Time) {
+	pong := &masterpb.ClockPong{Seq: ping.Seq, MasterSendNs: ping.MasterSendNs, WorkerRecvNs: recvAt.UnixNano()}
+	wm.sendMu.Lock()
+	defer wm.sendMu.Unlock()
+	if wm.stream == nil {
+		return
 	}
-	return err
+	pong.WorkerSendNs = time.Now().UnixNano()
+	if err := wm.stream.Send(&masterpb.WorkerMessage{Payload: &masterpb.WorkerMessage_ClockPong{ClockPong: pong}}); err != nil {
+		log.Warningf("回复时钟测量失败: %v", err)
+	}
+}
+
+// ReportStart 上报第一个 prepare 请求发出的本地时间
+func (wm *Register) ReportStart(taskId string, sentAt time.Time, bySignal bool) error {
+	return wm.send(&masterpb.WorkerMessage{Payload: &masterpb.WorkerMessage_StartReport{
+		StartReport: &masterpb.StartReport{TaskId: taskId, SentAtNs: sentAt.UnixNano(), BySignal: bySignal},
+	}})
 }
 
 // UpdateWorkerStatusAndTaskStatus 更新 ws和ts，同时触发task的updateTime
@@ -135,6 +343,16 @@ func (wm *Register) UpdateWorkerStatusAndTaskStatus(ws WorkerStatus, ts TaskStat
 
 func (wm *Register) Stop() {
 	close(wm.stopChan)
+	wm.sendMu.Lock()
+	defer wm.sendMu.Unlock()
+	if wm.stream != nil {
+		_ = wm.stream.CloseSend()
+		wm.stream = nil
+	}
+	wm.cancel()
+	if wm.conn != nil {
+		_ = wm.conn.Close()
+	}
 }
 
 func (wm *Register) GetWorkerID() string {
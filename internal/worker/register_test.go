@@ -0,0 +1,45 @@
+package worker
+
+import (
+	masterpb "biliTickerStorm/internal/master/pb"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// dropMaster 收到第一条心跳后立即断开长连接
+type dropMaster struct {
+	masterpb.UnimplementedTicketMasterServer
+}
+
+func (dropMaster) Connect(stream masterpb.TicketMaster_ConnectServer) error {
+	_, err := stream.Recv()
+	return err
+}
+
+func TestReadyClearedWhenStreamDrops(t *testing.T) {
+	lis := bufconn.Listen(1 << 20)
+	gs := grpc.NewServer()
+	masterpb.RegisterTicketMasterServer(gs, dropMaster{})
+	go func() { _ = gs.Serve(lis) }()
+	t.Cleanup(gs.Stop)
+
+	m := NewWorkerManager("passthrough:///master", WithWorkerID("w-1"), WithDialOptions(
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+	))
+	t.Cleanup(m.Stop)
+	if err := m.RegisterToMaster(); err != nil {
+		t.Fatalf("RegisterToMaster: %v", err)
+	}
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Ready(context.Background()) == nil {
+		if time.Now().After(deadline) {
+			t.Fatal("worker should become not ready after the stream drops")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -1,7 +1,9 @@
 package worker
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/valyala/fasthttp"
 	netUrl "net/url"
@@ -22,13 +24,43 @@ type BiliClient struct {
 	client  *fasthttp.Client
 	cookies []Cookies
 	worker  *Worker
+	// 测试时可以指向 fakebili
+	showBaseURL string
+	apiBaseURL  string
+	gtBaseURL   string
+	// 单次调用的超时，和 ctx 的截止时间取较早者
+	prepareTimeout time.Duration
+	createTimeout  time.Duration
+	captchaTimeout time.Duration
 }
 
-func NewBiliClient(cookies []Cookies, worker *Worker) *BiliClient {
+// defaultRequestTimeout ctx 没有截止时间时单个请求的最长时间
+const defaultRequestTimeout = 30 * time.Second
+
+// newHTTPClient 一个 worker 的所有任务共用同一个 client 以复用连接
+func newHTTPClient() *fasthttp.Client {
+	return &fasthttp.Client{
+		ReadTimeout:         defaultRequestTimeout,
+		WriteTimeout:        defaultRequestTimeout,
+		MaxIdleConnDuration: time.Minute,
+	}
+}
+
+func NewBiliClient(cfg *Config, cookies []Cookies, worker *Worker) *BiliClient {
+	client := newHTTPClient()
+	if worker != nil {
+		client = worker.httpClient
+	}
 	return &BiliClient{
-		client:  &fasthttp.Client{ReadTimeout: 30 * time.Second},
-		cookies: cookies,
-		worker:  worker,
+		client:         client,
+		cookies:        cookies,
+		worker:         worker,
+		showBaseURL:    strings.TrimSuffix(cfg.ShowBaseURL, "/"),
+		apiBaseURL:     strings.TrimSuffix(cfg.APIBaseURL, "/"),
+		gtBaseURL:      strings.TrimSuffix(cfg.GTBaseURL, "/"),
+		prepareTimeout: cfg.PrepareTimeout,
+		createTimeout:  cfg.CreateTimeout,
+		captchaTimeout: cfg.CaptchaTimeout,
 	}
 }
 
@@ -48,54 +80,31 @@ func (bc *BiliClient) setHeaders(req *fasthttp.Request) {
 	}
 }
 
-func (bc *BiliClient) Get(url string) ([]byte, error) {
+func (bc *BiliClient) Get(ctx context.Context, url string) ([]byte, error) {
 	req := fasthttp.AcquireRequest()
-	defer fasthttp.ReleaseRequest(req)
-	resp := fasthttp.AcquireResponse()
-	defer fasthttp.ReleaseResponse(resp)
-
 	req.Header.SetMethod("GET")
 	req.SetRequestURI(url)
 	bc.setHeaders(req)
 
-	if err := bc.client.Do(req, resp); err != nil {
-		return nil, err
-	}
-	err := bc.handleHTTPStatus(resp)
-	if err != nil {
-		return nil, err
-	}
-	return resp.Body(), nil
+	return bc.do(ctx, req)
 }
 
-func (bc *BiliClient) Post(url string, data interface{}) ([]byte, error) {
-	req := fasthttp.AcquireRequest()
-	defer fasthttp.ReleaseRequest(req)
-	resp := fasthttp.AcquireResponse()
-	defer fasthttp.ReleaseResponse(resp)
+func (bc *BiliClient) Post(ctx context.Context, url string, data interface{}) ([]byte, error) {
 	jsonData, err := json.Marshal(data)
 	if err != nil {
-		return resp.Body(), err
+		return nil, err
 	}
+	req := fasthttp.AcquireRequest()
 	req.Header.SetMethod("POST")
 	req.SetRequestURI(url)
 	req.SetBody(jsonData)
 	bc.setHeaders(req)
 
-	if err := bc.client.Do(req, resp); err != nil {
-		return nil, err
-	}
-	err = bc.handleHTTPStatus(resp)
-	if err != nil {
-		return nil, err
-	}
-	return resp.Body(), nil
+	return bc.do(ctx, req)
 }
-func (bc *BiliClient) DoFormRequest(url string, data map[string]string) ([]byte, error) {
+
+func (bc *BiliClient) DoFormRequest(ctx context.Context, url string, data map[string]string) ([]byte, error) {
 	req := fasthttp.AcquireRequest()
-	resp := fasthttp.AcquireResponse()
-	defer fasthttp.ReleaseRequest(req)
-	defer fasthttp.ReleaseResponse(resp)
 	req.Header.SetMethod("POST")
 	req.SetRequestURI(url)
 	bc.setHeaders(req)
@@ -105,31 +114,93 @@ func (bc *BiliClient) DoFormRequest(url string, data map[string]string) ([]byte,
 		form.Set(k, v)
 	}
 	req.SetBodyString(form.Encode())
-	client := &fasthttp.Client{}
-	err := client.Do(req, resp)
+	return bc.do(ctx, req)
+}
+
+type response struct {
+	status int
+	body   []byte
+	err    error
+}
+
+// deadlineSlack fasthttp 超时时 ctx 的截止时间在这个范围内，才认为是 ctx 到期
+const deadlineSlack = 100 * time.Millisecond
+
+// do 发送请求，非 200 状态码转换为错误，返回的是 body 的拷贝
+func (bc *BiliClient) do(ctx context.Context, req *fasthttp.Request) ([]byte, error) {
+	status, body, err := bc.send(ctx, req)
 	if err != nil {
 		return nil, err
 	}
-	err = bc.handleHTTPStatus(resp)
-	if err != nil {
+	if err := bc.handleHTTPStatus(status, body); err != nil {
 		return nil, err
 	}
-	return resp.Body(), nil
+	return body, nil
+}
+
+// send 发送请求并记录耗时，返回状态码和 body 的拷贝。
+// 截止时间取 ctx 的截止时间，没有时为 defaultRequestTimeout。fasthttp 不支持中途取消，
+// ctx 结束时立即返回，请求在后台跑完后再把 req 放回池中，结果直接丢弃。
+func (bc *BiliClient) send(ctx context.Context, req *fasthttp.Request) (int, []byte, error) {
+	if err := ctx.Err(); err != nil {
+		fasthttp.ReleaseRequest(req)
+		return 0, nil, context.Cause(ctx)
+	}
+	deadline, hasDeadline := ctx.Deadline()
+	if !hasDeadline {
+		deadline = time.Now().Add(defaultRequestTimeout)
+	}
+	done := make(chan response, 1)
+	go func() {
+		defer fasthttp.ReleaseRequest(req)
+		resp := fasthttp.AcquireResponse()
+		defer fasthttp.ReleaseResponse(resp)
+		start := time.Now()
+		err := bc.client.DoDeadline(req, resp, deadline)
+		requestDuration.WithLabelValues(string(req.URI().Path())).Observe(time.Since(start).Seconds())
+		if err != nil {
+			done <- response{err: err}
+			return
+		}
+		done <- response{status: resp.StatusCode(), body: append([]byte(nil), resp.Body()...)}
+	}()
+	select {
+	case r := <-done:
+		if errors.Is(r.err, fasthttp.ErrTimeout) && hasDeadline && time.Until(deadline) <= deadlineSlack {
+			// fasthttp 的计时可能比 ctx 早一点到期，等 ctx 结束以返回真正的原因（例如售票窗口结束）。
+			// 截止时间还远时是 client 的 ReadTimeout 到期，直接返回让调用方重试
+			<-ctx.Done()
+			return 0, nil, context.Cause(ctx)
+		}
+		return r.status, r.body, r.err
+	case <-ctx.Done():
+		// 已经放弃的请求即使返回 412 也不影响 worker 当前的任务
+		return 0, nil, context.Cause(ctx)
+	}
+}
+
+// withTimeout 给单次调用加上超时，d 为 0 时只使用 ctx 自己的截止时间
+func withTimeout(ctx context.Context, d time.Duration) (context.Context, context.CancelFunc) {
+	if d <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, d)
 }
 
-func (bc *BiliClient) handleHTTPStatus(resp *fasthttp.Response) error {
-	status := resp.StatusCode()
+func (bc *BiliClient) handleHTTPStatus(status int, body []byte) error {
 	switch status {
 	case fasthttp.StatusOK:
 		return nil
 	case fasthttp.StatusPreconditionFailed:
-		if bc.worker.cancel != nil {
-			bc.worker.cancel() //取消
+		throttledTotal.WithLabelValues("412").Inc()
+		if bc.worker != nil {
+			bc.worker.cancelByRisk() //取消
 		}
-		return fmt.Errorf("412风控")
+		return ErrRiskControl
 	case fasthttp.StatusTooManyRequests:
+		throttledTotal.WithLabelValues("429").Inc()
 		return fmt.Errorf("429请求过多")
 	default:
-		return fmt.Errorf("HTTP %d: %s", status, resp.Body())
+		return fmt.Errorf("HTTP %d: %s", status, body)
 	}
 }
@@ -16,8 +16,22 @@ func NewServer(worker *Worker) *Server {
 }
 func (s *Server) PushTask(ctx context.Context, req *pb.TaskRequest) (*pb.TaskResponse, error) {
 
-	err := s.worker.RunTask(ctx, req.TicketsInfo, req.TaskId)
+	err := s.worker.RunTask(ctx, req.TicketsInfo, req.TaskId, scheduleFromRequest(req))
 	if err != nil {
+		return &pb.TaskResponse{
+			Success:   false,
+			Message:   err.Error(),
+			TaskError: isTaskError(err),
+		}, nil
+	}
+	return &pb.TaskResponse{
+		Success: true,
+		Message: fmt.Sprintf("Task <%s> is running", req.TaskId),
+	}, nil
+}
+
+func (s *Server) StopTask(ctx context.Context, req *pb.StopTaskRequest) (*pb.TaskResponse, error) {
+	if err := s.worker.StopTask(req.TaskId, req.Reason); err != nil {
 		return &pb.TaskResponse{
 			Success: false,
 			Message: err.Error(),
@@ -25,6 +39,6 @@ func (s *Server) PushTask(ctx context.Context, req *pb.TaskRequest) (*pb.TaskRes
 	}
 	return &pb.TaskResponse{
 		Success: true,
-		Message: fmt.Sprintf("Task <%s> is running", req.TaskId),
+		Message: fmt.Sprintf("Task <%s> is stopping", req.TaskId),
 	}, nil
 }
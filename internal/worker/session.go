@@ -0,0 +1,120 @@
+package worker
+
+import (
+	. "biliTickerStorm/internal/common"
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// 未登录时 nav 返回的 code
+const codeNotLogin = -101
+
+// SessionCheckResult 开抢前账号检查的结果，Problems 为空表示通过
+type SessionCheckResult struct {
+	Mid      int64
+	Uname    string
+	Buyers   int // 账号下的购票人数量
+	Problems []string
+}
+
+func (r *SessionCheckResult) Passed() bool {
+	return len(r.Problems) == 0
+}
+
+func (r *SessionCheckResult) String() string {
+	if r.Passed() {
+		return fmt.Sprintf("账号 %s（uid=%d）登录有效，购票人 %d 个", r.Uname, r.Mid, r.Buyers)
+	}
+	return strings.Join(r.Problems, "; ")
+}
+
+// CheckSession 用配置中的 cookie 请求账号信息和购票人列表，确认登录有效、buyer_info 中的购票人仍然存在。
+// 接口不可用或响应格式异常时返回错误，检查不通过的原因放在 Problems 中
+func (w *Worker) CheckSession(ctx context.Context, config BiliTickerBuyConfig) (*SessionCheckResult, error) {
+	client := NewBiliClient(w.cfg, config.Cookies, w)
+	result := &SessionCheckResult{}
+
+	body, err := client.Get(ctx, client.apiBaseURL+"/x/web-interface/nav")
+	if err != nil {
+		return nil, fmt.Errorf("请求账号信息失败: %w", err)
+	}
+	nav, err := DecodeNavResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case nav.Code == codeNotLogin || nav.Code == 0 && !nav.IsLogin:
+		result.Problems = append(result.Problems, "cookie 已失效或账号已退出登录，请重新导出登录 cookie")
+		return result, nil
+	case nav.Code != 0:
+		return nil, fmt.Errorf("账号信息接口返回 code=%d %s", nav.Code, nav.Message)
+	}
+	result.Mid, result.Uname = nav.Mid, nav.Uname
+
+	url := fmt.Sprintf("%s/api/ticket/buyer/list?is_default&projectId=%d", client.showBaseURL, config.ProjectId)
+	body, err = client.Get(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("请求购票人列表失败: %w", err)
+	}
+	list, err := DecodeBuyerListResponse(body)
+	if err != nil {
+		return nil, err
+	}
+	if list.Errno != 0 {
+		result.Problems = append(result.Problems, fmt.Sprintf("获取购票人列表失败: errno=%d %s", list.Errno, list.Message))
+		return result, nil
+	}
+	result.Buyers = len(list.Buyers)
+	existing := make(map[int]BuyerInfo, len(list.Buyers))
+	for _, buyer := range list.Buyers {
+		existing[buyer.Id] = buyer
+	}
+	// 只输出字段和购票人 ID，不输出姓名和证件号
+	for i, buyer := range config.BuyerInfo {
+		field := fmt.Sprintf("buyer_info[%d]", i)
+		current, ok := existing[buyer.Id]
+		switch {
+		case buyer.Uid != 0 && int64(buyer.Uid) != nav.Mid:
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: 购票人属于 uid=%d，cookie 登录的是 uid=%d", field, buyer.Uid, nav.Mid))
+		case !ok:
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: 购票人 id=%d 已不在账号的购票人列表中", field, buyer.Id))
+		case current.Name != buyer.Name:
+			result.Problems = append(result.Problems, fmt.Sprintf("%s: 购票人 id=%d 的姓名与账号中的不一致", field, buyer.Id))
+		}
+	}
+	return result, nil
+}
+
+// runSessionCheck 执行账号检查任务，返回要上报给 master 的结果和状态。
+// 只有登录失效或购票人不符算检查没有通过；风控、网络错误等临时问题交还 master 重新分配，
+// 不能因为一次请求失败就报告 cookie 失效
+func (w *Worker) runSessionCheck(ctx context.Context, config BiliTickerBuyConfig) (*BuyResult, TaskStatus, error) {
+	log.WithField("username", config.Username).Info("接受到账号检查任务")
+	check, err := w.CheckSession(ctx, config)
+	if err != nil {
+		log.Warningf("账号检查失败: %v", err)
+		// 风控时 ctx 同样会被取消，先于停止任务判断
+		risk := errors.Is(err, ErrRiskControl) || errors.Is(context.Cause(ctx), ErrRiskControl)
+		if !risk && ctx.Err() != nil {
+			return nil, TaskStatusCancelled, context.Cause(ctx)
+		}
+		release := Idle
+		if risk {
+			release = Risking
+		}
+		if w.m != nil {
+			if err := w.m.CancelTask(release); err != nil {
+				log.Warningf("通知 master 释放任务失败: %v", err)
+			}
+		}
+		return nil, TaskStatusPending, err
+	}
+	if !check.Passed() {
+		log.Warningf("账号检查没有通过: %s", check)
+		return &BuyResult{Errno: -1, Message: check.String()}, TaskStatusFailed, nil
+	}
+	log.Info(check.String())
+	return &BuyResult{Message: check.String()}, TaskStatusSucceeded, nil
+}
@@ -0,0 +1,83 @@
+package worker
+
+import (
+	. "biliTickerStorm/internal/common"
+	"biliTickerStorm/internal/fakebili"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckSessionAgainstFake(t *testing.T) {
+	config := BiliTickerBuyConfig{
+		ProjectId: 1,
+		Cookies:   []Cookies{{Name: "SESSDATA", Value: "s", Domain: ".bilibili.com"}},
+		BuyerInfo: []BuyerInfo{{Id: 11, Uid: 7, Name: "张三"}, {Id: 12, Uid: 7, Name: "李四"}},
+	}
+	fake, cfg := useFakeBili(t, fakebili.Scenario{Mid: 7, Buyers: []fakebili.Buyer{{ID: 11, Name: "张三"}, {ID: 12, Name: "李四"}}})
+	w, ctx := newTestWorker(t, cfg)
+
+	check, err := w.CheckSession(ctx, config)
+	if err != nil {
+		t.Fatalf("CheckSession: %v", err)
+	}
+	if !check.Passed() || check.Mid != 7 || check.Buyers != 2 {
+		t.Fatalf("unexpected result: %+v", check)
+	}
+	if result, status, err := w.runSessionCheck(ctx, config); err != nil || status != TaskStatusSucceeded || result.Errno != 0 {
+		t.Fatalf("passed check: %+v %s %v", result, status, err)
+	}
+
+	// 购票人被删除、改名
+	fake.Reset(fakebili.Scenario{Mid: 7, Buyers: []fakebili.Buyer{{ID: 11, Name: "张三丰"}}})
+	check, err = w.CheckSession(ctx, config)
+	if err != nil {
+		t.Fatalf("CheckSession: %v", err)
+	}
+	if len(check.Problems) != 2 || !strings.Contains(check.Problems[0], "buyer_info[0]") || !strings.Contains(check.Problems[1], "id=12") {
+		t.Fatalf("unexpected problems: %q", check.Problems)
+	}
+	if strings.Contains(check.String(), "张三") {
+		t.Fatalf("buyer names should not be reported: %s", check)
+	}
+
+	// 退出登录后不再请求购票人列表
+	fake.Reset(fakebili.Scenario{LoggedOut: true})
+	result, status, err := w.runSessionCheck(ctx, config)
+	if err != nil || status != TaskStatusFailed || !strings.Contains(result.Message, "cookie") {
+		t.Fatalf("logged out: %+v %s %v", result, status, err)
+	}
+	if fake.Calls(fakebili.PathBuyerList) != 0 {
+		t.Errorf("buyer list should not be requested when logged out")
+	}
+
+	fake.Reset(fakebili.Scenario{Mid: 7, RiskAfter: 1}) // buyer/list 返回 412
+	if _, status, err := w.runSessionCheck(ctx, config); status != TaskStatusPending || err == nil {
+		t.Fatalf("412 should hand the task back: %s %v", status, err)
+	}
+}
+
+func TestSessionCheckTransientErrors(t *testing.T) {
+	config := BiliTickerBuyConfig{ProjectId: 1, BuyerInfo: []BuyerInfo{{Id: 11, Name: "张三"}}}
+	_, cfg := useFakeBili(t, fakebili.Scenario{Buyers: []fakebili.Buyer{{ID: 11, Name: "张三"}}, Delay: time.Second})
+
+	// 接口不可达不是 cookie 失效，交还 master 重新分配
+	down := *cfg
+	srv := httptest.NewServer(http.NotFoundHandler())
+	srv.Close()
+	down.ShowBaseURL, down.APIBaseURL = srv.URL, srv.URL
+	w, ctx := newTestWorker(t, &down)
+	if result, status, err := w.runSessionCheck(ctx, config); status != TaskStatusPending || err == nil || result != nil {
+		t.Fatalf("network error should hand the task back: %+v %s %v", result, status, err)
+	}
+
+	// master 停止任务时上报取消
+	w, ctx = newTestWorker(t, cfg)
+	time.AfterFunc(50*time.Millisecond, func() { w.cancel(ErrTaskStopped) })
+	if result, status, err := w.runSessionCheck(ctx, config); status != TaskStatusCancelled || !errors.Is(err, ErrTaskStopped) || result != nil {
+		t.Fatalf("stopped check should be cancelled: %+v %s %v", result, status, err)
+	}
+}
@@ -0,0 +1,156 @@
+package worker
+
+import (
+	"biliTickerStorm/internal/notify"
+	"bytes"
+	"errors"
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"io"
+	"os"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// NotifyData 通知模板可以使用的字段
+type NotifyData struct {
+	TaskID   string
+	TaskName string // master 上的配置文件名
+	Detail   string // 配置中的 detail，一般是项目、场次和票档
+	Buyers   []string
+	Count    int
+	PayMoney int // 分，模板中用 {{yuan .PayMoney}} 转换为元
+	OrderID  int64
+	WorkerID string
+	Attempts int           // createV2 请求次数
+	Elapsed  time.Duration // 从开始抢票到现在
+	Errno    int
+	Message  string // errno 策略表中的说明
+}
+
+// NotifyTemplate 一种通知的标题和正文模板，语法见 text/template
+type NotifyTemplate struct {
+	Title   string `yaml:"title"`
+	Content string `yaml:"content"`
+
+	title   *template.Template
+	content *template.Template
+}
+
+// NotifyTemplates 各种通知的模板
+type NotifyTemplates struct {
+	Success NotifyTemplate `yaml:"success"` // 下单成功
+	Errno   NotifyTemplate `yaml:"errno"`   // 策略表中 action 为 notify 的错误码
+}
+
+var templateFuncs = template.FuncMap{
+	"yuan": func(fen int) string { return fmt.Sprintf("%.2f", float64(fen)/100) },
+	"join": func(items []string, sep string) string { return strings.Join(items, sep) },
+}
+
+var defaultNotifyTemplates = map[string]NotifyTemplates{
+	"zh": {
+		Success: NotifyTemplate{
+			Title: "抢票成功：{{.Detail}}",
+			Content: `任务：{{.TaskName}}
+项目：{{.Detail}}
+购票人：{{join .Buyers "、"}}（{{.Count}} 张）
+金额：{{yuan .PayMoney}} 元
+订单号：{{.OrderID}}
+worker：{{.WorkerID}}，第 {{.Attempts}} 次下单，用时 {{.Elapsed}}
+前往订单中心付款吧`,
+		},
+		Errno: NotifyTemplate{
+			Title: "抢票提醒：{{.Detail}}",
+			Content: `任务：{{.TaskName}}
+errno={{.Errno}} {{.Message}}
+worker：{{.WorkerID}}，已下单 {{.Attempts}} 次，用时 {{.Elapsed}}`,
+		},
+	},
+	"en": {
+		Success: NotifyTemplate{
+			Title: "Tickets secured: {{.Detail}}",
+			Content: `Task: {{.TaskName}}
+Event: {{.Detail}}
+Buyers: {{join .Buyers ", "}} ({{.Count}} tickets)
+Amount: CNY {{yuan .PayMoney}}
+Order ID: {{.OrderID}}
+Worker: {{.WorkerID}}, attempt {{.Attempts}}, elapsed {{.Elapsed}}
+Please pay in the order center.`,
+		},
+		Errno: NotifyTemplate{
+			Title: "Ticket alert: {{.Detail}}",
+			Content: `Task: {{.TaskName}}
+errno={{.Errno}} {{.Message}}
+Worker: {{.WorkerID}}, {{.Attempts}} attempts, elapsed {{.Elapsed}}`,
+		},
+	},
+}
+
+// DefaultNotifyTemplates 内置的中文（zh）或英文（en）模板
+func DefaultNotifyTemplates(lang string) (*NotifyTemplates, error) {
+	if lang == "" {
+		lang = "zh"
+	}
+	defaults, ok := defaultNotifyTemplates[lang]
+	if !ok {
+		return nil, fmt.Errorf("不支持的通知语言 %q，可选 zh、en", lang)
+	}
+	t := defaults
+	return &t, t.compile()
+}
+
+// LoadNotifyTemplates 读取 YAML 模板文件，没有写的模板使用 lang 的内置模板
+func LoadNotifyTemplates(path, lang string) (*NotifyTemplates, error) {
+	t, err := DefaultNotifyTemplates(lang)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取通知模板文件失败: %w", err)
+	}
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(t); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("解析通知模板文件 %s 失败: %w", path, err)
+	}
+	if err := t.compile(); err != nil {
+		return nil, fmt.Errorf("通知模板 %s: %w", path, err)
+	}
+	return t, nil
+}
+
+func (t *NotifyTemplates) compile() error {
+	for name, tmpl := range map[string]*NotifyTemplate{"success": &t.Success, "errno": &t.Errno} {
+		if err := tmpl.compile(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *NotifyTemplate) compile(name string) error {
+	var err error
+	if t.title, err = template.New(name + ".title").Funcs(templateFuncs).Parse(t.Title); err != nil {
+		return err
+	}
+	if t.content, err = template.New(name + ".content").Funcs(templateFuncs).Parse(t.Content); err != nil {
+		return err
+	}
+	// 用零值渲染一次，提前发现写错的字段名
+	_, err = t.render(NotifyData{})
+	return err
+}
+
+func (t *NotifyTemplate) render(data NotifyData) (notify.Message, error) {
+	var title, content strings.Builder
+	if err := t.title.Execute(&title, data); err != nil {
+		return notify.Message{}, err
+	}
+	if err := t.content.Execute(&content, data); err != nil {
+		return notify.Message{}, err
+	}
+	return notify.Message{Title: strings.TrimSpace(title.String()), Content: content.String()}, nil
+}
@@ -0,0 +1,61 @@
+package worker
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNotifyTemplates(t *testing.T) {
+	data := NotifyData{
+		TaskName: "bw2025.json", Detail: "BW2025 VIP 票", Buyers: []string{"张三", "李四"}, Count: 2,
+		PayMoney: 128000, OrderID: 42, WorkerID: "worker-1", Attempts: 7, Elapsed: 1500 * time.Millisecond,
+	}
+	for lang, want := range map[string][]string{
+		"zh": {"抢票成功：BW2025 VIP 票", "张三、李四（2 张）", "1280.00 元", "订单号：42", "第 7 次下单，用时 1.5s"},
+		"en": {"Tickets secured: BW2025 VIP 票", "张三, 李四 (2 tickets)", "CNY 1280.00", "Order ID: 42", "worker-1"},
+	} {
+		templates, err := DefaultNotifyTemplates(lang)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msg, err := templates.Success.render(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, s := range want {
+			if !strings.Contains(msg.Title+"\n"+msg.Content, s) {
+				t.Errorf("%s: %q missing from\n%s\n%s", lang, s, msg.Title, msg.Content)
+			}
+		}
+	}
+	if _, err := DefaultNotifyTemplates("fr"); err == nil {
+		t.Error("unsupported language should be rejected")
+	}
+}
+
+func TestLoadNotifyTemplates(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "notify.yaml")
+	if err := os.WriteFile(path, []byte("success:\n  title: '{{.TaskName}} 成功 {{.OrderID}}'\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	templates, err := LoadNotifyTemplates(path, "en")
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err := templates.Success.render(NotifyData{TaskName: "a", OrderID: 1})
+	if err != nil || msg.Title != "a 成功 1" || !strings.Contains(msg.Content, "Order ID: 1") {
+		t.Fatalf("unexpected message %+v %v", msg, err)
+	}
+
+	// 字段名写错在加载时就报错
+	if err := os.WriteFile(path, []byte("errno:\n  content: '{{.OrderNo}}'\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadNotifyTemplates(path, "zh"); err == nil || !strings.Contains(err.Error(), "OrderNo") {
+		t.Fatalf("expected unknown field error, got %v", err)
+	}
+}
@@ -4,58 +4,195 @@ import (
 	. "biliTickerStorm/internal/common"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/sirupsen/logrus"
+	"github.com/valyala/fasthttp"
 	"sync"
+	"time"
+)
+
+var (
+	// ErrRiskControl 请求触发 412 风控，任务交还 master 重新分配
+	ErrRiskControl = errors.New("412风控")
+	// ErrTaskStopped master 主动停止任务（删除、暂停或被其他 worker 接替）
+	ErrTaskStopped = errors.New("任务被 master 停止")
+	// ErrInvalidConfig 任务配置无法解析，重试也不会成功
+	ErrInvalidConfig = errors.New("解析配置失败")
+	// ErrSaleEnded 已过任务的售票窗口结束时间
+	ErrSaleEnded = errors.New("售票窗口已结束")
 )
 
 type Worker struct {
-	m      *Register
-	cancel context.CancelFunc
-	mu     sync.Mutex // 保证并发安全地访问 cancel
+	cfg   *Config
+	clock Clock // 开抢等待和重试间隔使用的时钟
+	// errnos createV2 返回值的处理策略
+	errnos *ErrnoPolicy
+	// templates 通知的标题和正文
+	templates *NotifyTemplates
+	// httpClient 所有任务共用，复用到 B 站的连接
+	httpClient *fasthttp.Client
+	m          *Register
+	cancel     context.CancelCauseFunc
+	taskID     string
+	// started 收到 master 开始信号时关闭
+	started     chan struct{}
+	startSignal func()
+	mu          sync.Mutex // 保证并发安全地访问 cancel
 }
 
-func NewWorker(m *Register) *Worker {
-	return &Worker{
-		m: m,
+func NewWorker(cfg *Config, m *Register) *Worker {
+	w := &Worker{
+		cfg:        cfg,
+		clock:      cfg.clock(),
+		httpClient: newHTTPClient(),
+		errnos:     cfg.errnoPolicy(),
+		templates:  cfg.notifyTemplates(),
+		m:          m,
+	}
+	// m 为空时不连接 master，只能直接调用 Buy
+	if m != nil {
+		m.SetHandler(w)
 	}
+	return w
 }
 
-func (w *Worker) RunTask(ctx context.Context, info, taskId string) error {
+// workerID 没有连接 master 时为空
+func (w *Worker) workerID() string {
+	if w.m == nil {
+		return ""
+	}
+	return w.m.workerID
+}
+
+func (w *Worker) RunTask(ctx context.Context, info, taskId string, schedule TaskSchedule) error {
 	w.mu.Lock()
 	if w.cancel != nil {
 		w.mu.Unlock()
 		return fmt.Errorf("已有任务正在执行")
 	}
-	cancelCtx, cancel := context.WithCancel(context.Background())
+	cancelCtx, cancel := context.WithCancelCause(context.Background())
 	w.cancel = cancel
+	w.taskID = taskId
+	started := make(chan struct{})
+	w.started, w.startSignal = started, sync.OnceFunc(func() { close(started) })
 	w.mu.Unlock()
 
 	var config BiliTickerBuyConfig
 	if err := json.Unmarshal([]byte(info), &config); err != nil {
 		log.Printf("[ConfigError] BiliTickerBuy: %v", err)
-		return fmt.Errorf("解析配置失败: %w", err)
+		w.mu.Lock()
+		w.cancel = nil
+		w.taskID = ""
+		w.mu.Unlock()
+		cancel(err)
+		return fmt.Errorf("%w: %v", ErrInvalidConfig, err)
+	}
+	schedule = schedule.withDefaults(w.cfg)
+	var buyCtx context.Context = cancelCtx
+	stopBuy := func() {}
+	if schedule.TimeEnd != nil {
+		buyCtx, stopBuy = context.WithDeadlineCause(cancelCtx, *schedule.TimeEnd, ErrSaleEnded)
 	}
 	go func() {
+		defer stopBuy()
+		fields := logrus.Fields{"username": config.Username, "detail": config.Detail}
+		// 不把上一个任务的终态带到新任务的心跳里
+		w.m.SetPurchaseState("")
 		err := w.m.UpdateWorkerStatusAndTaskStatus(Working, TaskStatusDoing, taskId) //set and send heartbeat
 		if err != nil {
-			log.WithFields(logrus.Fields{"username": config.Username, "detail": config.Detail}).Warningf("设置状态 Working,TaskStatusDoing 失败: %v", err)
+			log.WithFields(fields).Warningf("设置状态 Working,TaskStatusDoing 失败: %v", err)
 		}
-		defer func() {
-			w.mu.Lock()
-			w.cancel = nil
-			err := w.m.UpdateWorkerStatusAndTaskStatus(Idle, TaskStatusDone, taskId)
+		start := time.Now()
+		var result *BuyResult
+		var status TaskStatus
+		if schedule.Kind == TaskKindSessionCheck {
+			// 账号检查不等开抢时间，也不受售票窗口限制
+			result, status, err = w.runSessionCheck(cancelCtx, config)
+		} else {
+			result, err = w.Buy(buyCtx, config, schedule)
 			if err != nil {
-				log.WithFields(logrus.Fields{"username": config.Username, "detail": config.Detail}).Warningf("设置状态 Idle,TaskStatusDone失败: %v", err)
+				log.WithFields(fields).Warningf("抢票失败: %v", err)
 			}
-			w.mu.Unlock()
-		}() //执行完成
-		err = w.Buy(cancelCtx, config, Cfg.TimeStart, Cfg.Interval, Cfg.PushplusToken)
-		if err != nil {
-			log.WithFields(logrus.Fields{"username": config.Username, "detail": config.Detail}).Warningf("抢票失败: %v", err)
+			status = taskOutcome(result, err)
+		}
+		if status != TaskStatusPending {
+			if err := w.m.ReportResult(taskId, status, result, err, time.Since(start)); err != nil {
+				log.WithFields(fields).Warningf("上报结果 %s 失败: %v", status, err)
+			}
+		} else {
+			taskId = "" // 风控时任务已通过 CancelTask 交还 master
 		}
 
+		w.mu.Lock()
+		w.cancel = nil
+		w.taskID = ""
+		w.started, w.startSignal = nil, nil
+		err = w.m.UpdateWorkerStatusAndTaskStatus(Idle, status, taskId)
+		if err != nil {
+			log.WithFields(fields).Warningf("设置状态 Idle,%s 失败: %v", status, err)
+		}
+		w.mu.Unlock()
 	}()
 
 	return nil
 }
+
+// StopTask 取消正在执行的任务，Buy 在下一次检查 ctx 时退出
+func (w *Worker) StopTask(taskId, reason string) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel == nil || w.taskID != taskId {
+		return fmt.Errorf("任务 <%s> 未在执行", taskId)
+	}
+	log.Infof("[Stop] 任务 <%s> 被 master 停止: %s", taskId, reason)
+	w.cancel(ErrTaskStopped)
+	return nil
+}
+
+// StartSignal 收到 master 的开始信号，唤醒正在等待开抢的任务
+func (w *Worker) StartSignal(taskId string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.taskID != taskId || w.startSignal == nil {
+		log.Warningf("[Start] 收到任务 <%s> 的开始信号，但当前任务是 <%s>", taskId, w.taskID)
+		return
+	}
+	w.startSignal()
+}
+
+// CurrentTask 返回正在执行的任务 ID，空闲时为空
+func (w *Worker) CurrentTask() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.taskID
+}
+
+// cancelByRisk 当前任务触发风控时取消
+func (w *Worker) cancelByRisk() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.cancel != nil {
+		w.cancel(ErrRiskControl)
+	}
+}
+
+// taskOutcome 根据 Buy 的返回值判断任务最终状态，风控返回 Pending 表示交还 master 重新分配
+func taskOutcome(result *BuyResult, err error) TaskStatus {
+	switch {
+	case errors.Is(err, ErrRiskControl):
+		return TaskStatusPending
+	case errors.Is(err, ErrTaskStopped):
+		return TaskStatusCancelled
+	case err != nil:
+		return TaskStatusFailed
+	case result != nil && result.Ordered:
+		return TaskStatusSucceeded
+	}
+	return TaskStatusFailed
+}
+
+// isTaskError 拒绝原因是否出在任务本身，master 会把它计入重试次数
+func isTaskError(err error) bool {
+	return errors.Is(err, ErrInvalidConfig)
+}